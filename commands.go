@@ -3,6 +3,8 @@ package main
 import (
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
 
 	"github.com/mitchellh/cli"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/hashicorp/terraform-svchost/disco"
 	"github.com/hashicorp/terraform/internal/addrs"
 	"github.com/hashicorp/terraform/internal/command"
+	"github.com/hashicorp/terraform/internal/command/arguments"
 	"github.com/hashicorp/terraform/internal/command/cliconfig"
 	"github.com/hashicorp/terraform/internal/command/views"
 	"github.com/hashicorp/terraform/internal/command/webbrowser"
@@ -25,6 +28,12 @@ import (
 // that assume that Terraform is being run from a command prompt.
 const runningInAutomationEnvName = "TF_IN_AUTOMATION"
 
+// generateHintsEnvName gives the name of an environment variable that
+// overrides the hints setting from any generate block in the CLI
+// configuration, so automation can suppress the next-step hints printed by
+// configuration-generating commands without a config file change.
+const generateHintsEnvName = "TF_GENERATE_HINTS"
+
 // Commands is the mapping of all the available Terraform commands.
 var Commands map[string]cli.CommandFactory
 
@@ -79,6 +88,43 @@ func initCommands(
 
 	dataDir := os.Getenv("TF_DATA_DIR")
 
+	var redactionProfiles map[string][]string
+	if len(config.RedactionProfiles) > 0 {
+		redactionProfiles = make(map[string][]string)
+		for name, profile := range config.RedactionProfiles {
+			redactionProfiles[name] = profile.Patterns
+		}
+	}
+
+	// Generators run in pattern order, so that generation is deterministic
+	// when several patterns match the same resource type.
+	var addGenerators []arguments.AddGenerator
+	if len(config.AddGenerators) > 0 {
+		patterns := make([]string, 0, len(config.AddGenerators))
+		for pattern := range config.AddGenerators {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			gen := config.AddGenerators[pattern]
+			addGenerators = append(addGenerators, arguments.AddGenerator{
+				Pattern: pattern,
+				Command: gen.Command,
+				Args:    gen.Args,
+			})
+		}
+	}
+
+	// A generate block in the CLI configuration can suppress the next-step
+	// hints printed after generated configuration; the environment variable
+	// overrides the files either way.
+	suppressAddHints := !config.GenerateHints()
+	if env := os.Getenv(generateHintsEnvName); env != "" {
+		if hints, err := strconv.ParseBool(env); err == nil {
+			suppressAddHints = !hints
+		}
+	}
+
 	meta := command.Meta{
 		OriginalWorkingDir: originalWorkingDir,
 		Streams:            streams,
@@ -94,6 +140,10 @@ func initCommands(
 		RunningInAutomation: inAutomation,
 		CLIConfigDir:        configDir,
 		PluginCacheDir:      config.PluginCacheDir,
+		RedactionProfiles:   redactionProfiles,
+		AddGenerators:       addGenerators,
+		AddPlaceholders:     config.AddPlaceholders,
+		SuppressAddHints:    suppressAddHints,
 		OverrideDataDir:     dataDir,
 
 		ShutdownCh: makeShutdownCh(),
@@ -110,6 +160,12 @@ func initCommands(
 	// that to match.
 
 	Commands = map[string]cli.CommandFactory{
+		"add": func() (cli.Command, error) {
+			return &command.AddCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"apply": func() (cli.Command, error) {
 			return &command.ApplyCommand{
 				Meta: meta,
@@ -0,0 +1,3073 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/backend"
+	backendInit "github.com/hashicorp/terraform/internal/backend/init"
+	"github.com/hashicorp/terraform/internal/command/arguments"
+	"github.com/hashicorp/terraform/internal/command/views"
+	"github.com/hashicorp/terraform/internal/configs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/didyoumean"
+	"github.com/hashicorp/terraform/internal/getproviders"
+	"github.com/hashicorp/terraform/internal/providercache"
+	"github.com/hashicorp/terraform/internal/providers"
+	"github.com/hashicorp/terraform/internal/states"
+	"github.com/hashicorp/terraform/internal/states/statemgr"
+	"github.com/hashicorp/terraform/internal/terraform"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// AddCommand is a Command implementation that generates resource configuration templates.
+type AddCommand struct {
+	Meta
+
+	// stateSerial is the pinned state snapshot serial from -state-serial, or
+	// nil when no pin was requested. Run sets it from the parsed arguments
+	// before anything reads state, and getState enforces it.
+	stateSerial *uint64
+
+	// schemaCache holds the full schema of each provider already fetched
+	// directly from its plugin in this run, so bulk modes which resolve many
+	// resource types pay for one plugin launch and one GetProviderSchema
+	// round-trip per provider; see zeroConfigSchemas.
+	schemaCache map[addrs.Provider]*terraform.ProviderSchema
+
+	// stateCache holds the state snapshot already read from the backend in
+	// this run. State is consulted once per requested address, and for a
+	// remote backend each read is a network round-trip, so the first
+	// snapshot is reused for the rest of the run; see getState.
+	stateCache *states.State
+}
+
+// tfcRunIDEnvName is the environment variable Terraform Cloud and its agents
+// set to the ID of the run a command is executing inside. When it is present,
+// bulk runs also record a structured check summary; see Run.
+const tfcRunIDEnvName = "TFC_RUN_ID"
+
+// addCheckSummaryFilename is the file inside the data directory where bulk
+// runs executing inside a Terraform Cloud run record their check summary.
+const addCheckSummaryFilename = "add-check-summary.json"
+
+// addExitWriteFailure is the exit status used when the generated
+// configuration could not be written to its destination, so that scripts can
+// distinguish a write failure (such as a closed stdout pipe in CI) from an
+// invalid request.
+const addExitWriteFailure = 2
+
+// addExitPartialFailure is the exit status used when a bulk run generated
+// configuration for some of the selected resources but had to skip others,
+// so pipelines can decide whether a partial result is good enough to
+// proceed with.
+const addExitPartialFailure = 3
+
+// writeFailureDiag gives a failure to write generated output enough context
+// to act on, since the underlying error (such as EPIPE from a closed stdout)
+// does not say what Terraform was trying to write at the time.
+func writeFailureDiag(err error) tfdiags.Diagnostic {
+	return tfdiags.Sourceless(
+		tfdiags.Error,
+		"Failed to write generated configuration",
+		fmt.Sprintf("Error writing the generated configuration: %s.\n\nIf standard output is not writable in this environment, use -out or -out-dir to write to a file instead.", err),
+	)
+}
+
+// viewFailure maps an error returned by the view to a diagnostic and an exit
+// status. The view lints its own output against the provider schema before
+// writing it, so a views.GenerationError means add itself produced invalid
+// configuration — a bug in Terraform, reported as an internal error with
+// status 1. A views.BlockDriftError means the -out file already holds a
+// different block for the same address, reported with a diff and the
+// addExitWriteFailure status so pipelines notice. Anything else is a failure
+// to write to the destination, which keeps the same distinct status.
+func viewFailure(err error) (tfdiags.Diagnostic, int) {
+	var genErr *views.GenerationError
+	if errors.As(err, &genErr) {
+		return tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid generated configuration",
+			fmt.Sprintf("The configuration generated for this request does not validate against the provider schema: %s.\n\nThis is a bug in Terraform; please report it on the Terraform issue tracker, including the command line you used.", genErr.Problem),
+		), 1
+	}
+	var driftErr *views.BlockDriftError
+	if errors.As(err, &driftErr) {
+		return tfdiags.Sourceless(
+			tfdiags.Error,
+			"Existing block differs from generated configuration",
+			fmt.Sprintf("The file %s already contains a block for %s, and its content differs from what this run would generate:\n\n%s\nNothing was written. Remove the existing block, or write to a different file, to regenerate it.", driftErr.Path, driftErr.Addr, driftErr.Diff),
+		), addExitWriteFailure
+	}
+	var progErr *views.GeneratorError
+	if errors.As(err, &progErr) {
+		return tfdiags.Sourceless(
+			tfdiags.Error,
+			"Generator program failed",
+			fmt.Sprintf("An add_generator program from the CLI configuration failed: %s.\n\nCheck the add_generator blocks in your CLI configuration, or remove them to use the default generation.", progErr),
+		), 1
+	}
+	return writeFailureDiag(err), addExitWriteFailure
+}
+
+func (c *AddCommand) Run(rawArgs []string) int {
+	// The start time feeds the optional metrics record written at the end of
+	// the run when TF_ADD_METRICS_FILE is set.
+	start := time.Now()
+
+	// Parse and apply global view arguments
+	common, rawArgs := arguments.ParseView(rawArgs)
+	c.View.Configure(common)
+
+	args, diags := arguments.ParseAdd(rawArgs)
+
+	// The -redact option names a redaction profile from the CLI
+	// configuration, which the argument parser has no access to, so we
+	// resolve it here before constructing the view.
+	if !diags.HasErrors() && args.Redact != "" {
+		patterns, ok := c.RedactionProfiles[args.Redact]
+		if !ok {
+			var detail string
+			if len(c.RedactionProfiles) == 0 {
+				detail = fmt.Sprintf("There is no redaction_profile %q block in the CLI configuration, and no redaction profiles are configured at all.", args.Redact)
+			} else {
+				names := make([]string, 0, len(c.RedactionProfiles))
+				for name := range c.RedactionProfiles {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				detail = fmt.Sprintf("There is no redaction_profile %q block in the CLI configuration. The configured profiles are: %s.", args.Redact, strings.Join(names, ", "))
+			}
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Unknown redaction profile",
+				detail,
+			))
+		}
+		args.RedactPatterns = patterns
+	}
+
+	// Any generator programs configured via add_generator blocks in the CLI
+	// configuration post-process the generated output; like redaction
+	// profiles they come from the CLI configuration rather than the command
+	// line.
+	args.Generators = c.AddGenerators
+
+	view := views.NewAdd(args.ViewType, c.View, args)
+	if diags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	// Any add_placeholders block in the CLI configuration customizes the
+	// value rendered for required attributes with no known value, per
+	// primitive type.
+	if placeholders := typePlaceholderValues(c.AddPlaceholders); placeholders != nil {
+		view.SetTypePlaceholders(placeholders)
+	}
+
+	// A generate block in the CLI configuration, or the TF_GENERATE_HINTS
+	// environment variable, can suppress the next-step hints globally for
+	// automation embedding this command, in addition to the per-run -quiet
+	// option.
+	if c.SuppressAddHints {
+		view.SetHintsDisabled(true)
+	}
+
+	// With -type=backend the request names a backend type rather than a
+	// resource, and the scaffold comes from the backend's own configuration
+	// schema, so no configuration, backend or state is loaded at all.
+	if args.BackendType != "" {
+		return c.runBackendScaffold(args, view)
+	}
+
+	// A data.external address is scaffolded from the external data source's
+	// fixed protocol schema, so like the backend scaffold it loads no
+	// configuration, backend or state.
+	if args.ExternalData {
+		return c.runExternalData(args, view)
+	}
+
+	// With -values-stdin the attribute values arrive as key=value pairs on
+	// standard input. They are read here, but only type-checked further down
+	// once the provider schemas are known.
+	if args.ValuesStdin {
+		values, moreDiags := parseStdinValues(os.Stdin)
+		diags = diags.Append(moreDiags)
+		if moreDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+		args.StdinValues = values
+	}
+
+	// The versions selected in the dependency lock file, where one exists,
+	// let required_providers scaffolds record a version floor: the generated
+	// configuration follows the installed provider's schema, which older
+	// releases may not support.
+	if versions := c.lockedProviderVersions(); len(versions) > 0 {
+		view.SetProviderVersions(versions)
+	}
+
+	// A directory with no configuration files at all has no backend to
+	// initialize and no module to resolve resource types against, so we
+	// switch to a schema-only mode in that case: -provider names the
+	// provider explicitly and the configuration is generated from its
+	// schema alone, which lets this command bootstrap a brand new project.
+	if !c.dirIsConfigPath(".") {
+		return c.runZeroConfig(args, view)
+	}
+
+	// In case the output configuration path is specified, we should ensure the
+	// target resource address doesn't exist in the module tree indicated by
+	// the existing configuration files. With -merge an existing resource is
+	// not an error; we instead remember its declaration so we can report any
+	// required arguments it is missing.
+	existing := make(map[string]*configs.Resource)
+	var cloneSrc *configs.Resource
+	if args.OutPath != "" || args.OutDir != "" || args.Merge || args.DiffConfig || args.FromExisting != nil || args.CloneSource != nil {
+		// Ensure the directory to the path exists and is accessible.
+		outDir := args.OutDir
+		if outDir == "" && args.OutPath != "" {
+			outDir = filepath.Dir(args.OutPath)
+		}
+		if outDir == "" {
+			outDir = "."
+		}
+		if _, err := os.Stat(outDir); os.IsNotExist(err) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"The out path doesn't exist or is not accessible",
+				err.Error(),
+			))
+			view.Diagnostics(diags)
+			return 1
+		}
+
+		config, loadDiags := c.loadConfig(outDir)
+		diags = diags.Append(loadDiags)
+		if diags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+
+		// The lookups below run once per address, so the tree is indexed up
+		// front rather than re-walked for each one.
+		idx := indexConfigResources(config)
+
+		if config != nil {
+			for _, addr := range args.Addrs {
+				rs := idx.managedResource(addr)
+				if rs == nil {
+					continue
+				}
+				// A declaration inside the -out file itself is most likely
+				// the product of a previous run of this command; the view
+				// compares the blocks at write time and reports up-to-date
+				// or drift instead, so reruns are idempotent.
+				if args.OutPath != "" && filepath.Clean(rs.DeclRange.Filename) == filepath.Clean(args.OutPath) {
+					continue
+				}
+				if !args.Merge && !args.DiffConfig {
+					diags = diags.Append(&hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Resource already in configuration",
+						Detail:   fmt.Sprintf("The resource %s is already in this configuration at %s. Resource names must be unique per type in each module.", addr, rs.DeclRange),
+						Subject:  &rs.DeclRange,
+					})
+					view.Diagnostics(diags)
+					return 1
+				}
+				existing[addr.String()] = rs
+			}
+		}
+
+		// With -from-existing-resource we copy the source resource block
+		// verbatim, including its meta-arguments (provider, lifecycle,
+		// depends_on), since a cloned resource usually wants the same
+		// operational behavior. No provider schema or state is needed, so
+		// this mode finishes without initializing the backend.
+		if args.FromExisting != nil {
+			src := idx.managedResource(*args.FromExisting)
+			if src == nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Resource not in configuration",
+					fmt.Sprintf("The -from-existing-resource option copies an existing resource block, but %s is not declared in this configuration.", args.FromExisting),
+				))
+				view.Diagnostics(diags)
+				return 1
+			}
+
+			body, moreDiags := resourceBlockBody(src)
+			diags = diags.Append(moreDiags)
+			if diags.HasErrors() {
+				view.Diagnostics(diags)
+				return 1
+			}
+
+			for _, addr := range args.Addrs {
+				if addr.Resource.Resource.Type != src.Type {
+					diags = diags.Append(tfdiags.Sourceless(
+						tfdiags.Error,
+						"Resource type mismatch",
+						fmt.Sprintf("Cannot copy the configuration of %s %s to %s: the source and target addresses must have the same resource type.", src.Type, src.Name, addr),
+					))
+					view.Diagnostics(diags)
+					return 1
+				}
+				if err := view.ResourceFromExisting(addr, *args.FromExisting, body); err != nil {
+					failure, status := viewFailure(err)
+					diags = diags.Append(failure)
+					view.Diagnostics(diags)
+					return status
+				}
+			}
+			view.Diagnostics(diags)
+			view.Summary(warningCount(diags))
+			return 0
+		}
+
+		// With -clone we rewrite the source resource block's own HCL rather
+		// than re-deriving it from schema, so we retain its declaration here.
+		// Unlike -from-existing-resource the copy still needs the provider
+		// schema, to strip computed-only attributes, so this mode continues
+		// through the backend initialization below.
+		if args.CloneSource != nil {
+			cloneSrc = idx.managedResource(*args.CloneSource)
+			if cloneSrc == nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Resource not in configuration",
+					fmt.Sprintf("The -clone option copies an existing resource block, but %s is not declared in this configuration.", args.CloneSource),
+				))
+				view.Diagnostics(diags)
+				return 1
+			}
+			for _, addr := range args.Addrs {
+				if addr.Resource.Resource.Type != cloneSrc.Type {
+					diags = diags.Append(tfdiags.Sourceless(
+						tfdiags.Error,
+						"Resource type mismatch",
+						fmt.Sprintf("Cannot clone %s %s to %s: the source and target addresses must have the same resource type.", cloneSrc.Type, cloneSrc.Name, addr),
+					))
+					view.Diagnostics(diags)
+					return 1
+				}
+			}
+		}
+
+		// Comparing state against configuration only makes sense for
+		// resources which are actually declared.
+		if args.DiffConfig {
+			for _, addr := range args.Addrs {
+				if _, ok := existing[addr.String()]; !ok {
+					diags = diags.Append(tfdiags.Sourceless(
+						tfdiags.Error,
+						"Resource not in configuration",
+						fmt.Sprintf("The -diff-config option compares state against an existing resource block, but %s is not declared in this configuration.", addr),
+					))
+					view.Diagnostics(diags)
+					return 1
+				}
+			}
+		}
+	}
+
+	// Check for user-supplied plugin path
+	var err error
+	if c.pluginPath, err = c.loadPluginPath(); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error loading plugin path",
+			err.Error(),
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	// Apply the state arguments to the meta object here because they are later
+	// used when initializing the backend. The pinned state serial, if any, is
+	// enforced by getState once the snapshot has been read.
+	c.Meta.applyStateArguments(args.State)
+	c.stateSerial = args.StateSerial
+
+	// Load the backend
+	b, backendDiags := c.Backend(nil)
+	diags = diags.Append(backendDiags)
+	if backendDiags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	// We require a local backend
+	local, ok := b.(backend.Local)
+	if !ok {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Unsupported backend",
+			ErrUnsupportedLocalOp,
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	// This is a read-only command (until -import is implemented)
+	c.ignoreRemoteBackendVersionConflict(b)
+
+	// Expand any wildcard or whole-module address patterns against the
+	// state, using the same lookup rules as "terraform state list".
+	if len(args.Patterns) > 0 {
+		state, stateDiags := c.getState(b)
+		if stateDiags.HasErrors() {
+			diags = diags.Append(stateDiags)
+			view.Diagnostics(diags)
+			return 1
+		}
+		sm := &StateMeta{Meta: c.Meta}
+		expanded, lookupDiags := sm.lookupResourceInstanceAddrs(state, args.Patterns...)
+		if lookupDiags.HasErrors() {
+			diags = diags.Append(lookupDiags)
+			view.Diagnostics(diags)
+			return 1
+		}
+		args.Addrs = append(args.Addrs, expanded...)
+
+		// A pattern run against a large state can select far more resources
+		// than the user expected, so report how much is about to be generated
+		// and ask for confirmation before producing anything. -auto-approve
+		// skips the prompt for automation.
+		if !args.AutoApprove {
+			dest := bulkDestination(args)
+			if c.InputMode() == 0 {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Approval required for bulk generation",
+					fmt.Sprintf("The given patterns select %d resources, whose generated configuration would be written to %s. Terraform cannot ask for interactive approval when input is disabled, so pass -auto-approve to generate them without confirmation.", len(args.Addrs), dest),
+				))
+				view.Diagnostics(diags)
+				return 1
+			}
+			v, err := c.UIInput().Input(context.Background(), &terraform.InputOpts{
+				Id:    "add-bulk-approve",
+				Query: "Do you want to generate this configuration?",
+				Description: fmt.Sprintf("Terraform will generate configuration for %d resources and write the result to %s.\nOnly 'yes' will be accepted to confirm.",
+					len(args.Addrs), dest),
+			})
+			if err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Error asking for approval",
+					fmt.Sprintf("Terraform could not ask for confirmation of the bulk generation: %s. Pass -auto-approve to generate without confirmation.", err),
+				))
+				view.Diagnostics(diags)
+				return 1
+			}
+			if v != "yes" {
+				view.BulkCancelled()
+				return 1
+			}
+		}
+	}
+
+	// Honor any exclusion patterns the configuration directory declares,
+	// before we go to the trouble of building a full context.
+	excludes, excludeDiags := loadGenExcludes(".")
+	diags = diags.Append(excludeDiags)
+	if excludeDiags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+	for _, addr := range args.Addrs {
+		if pattern, excluded := excludes.Excludes(addr); excluded {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Resource excluded from generation",
+				fmt.Sprintf("The resource %s matches the pattern %q in %s, which marks it as managed elsewhere. Remove the pattern from that file if you really intend to generate configuration for it here.", addr, pattern, genExcludeFilename),
+			))
+			view.Diagnostics(diags)
+			return 1
+		}
+	}
+
+	// With -auto-init we repair a missing data directory by running the
+	// equivalent of "terraform init" before building the context. We only
+	// reach this point with a local backend, which init can set up without
+	// any further input.
+	if args.AutoInit {
+		if _, statErr := os.Stat(c.DataDir()); os.IsNotExist(statErr) {
+			initCmd := &InitCommand{Meta: c.Meta}
+			// Init's provider installation event hooks report download and
+			// verification progress through its Ui. Route that through the
+			// view instead, so the progress lands on the progress stream (or
+			// becomes -json-diags lines) rather than interleaving with the
+			// generated configuration on stdout.
+			initCmd.Meta.Ui = &installProgressUi{view: view}
+			if initCode := initCmd.Run([]string{"-input=false"}); initCode != 0 {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Initialization failed",
+					"The automatic \"terraform init\" run requested by -auto-init did not succeed; see the output above for details.",
+				))
+				view.Diagnostics(diags)
+				return 1
+			}
+		}
+	}
+
+	// Build the operation
+	opReq := c.Operation(b)
+	opReq.AllowUnsetVariables = true
+	// Use a relative config path like the other operation-based commands do,
+	// so that the configuration is found in whatever directory the global
+	// -chdir option may have switched us into.
+	opReq.ConfigDir = "."
+	opReq.ConfigLoader, err = c.initConfigLoader()
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error initializing config loader",
+			err.Error(),
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	// Get the context
+	ctx, _, ctxDiags := local.Context(opReq)
+	var schemas *terraform.Schemas
+	var config *configs.Config
+	if ctxDiags.HasErrors() {
+		// A working directory that has never been initialized fails here
+		// with low-level plugin errors, so lead with the same advice that
+		// "terraform init" users get from other commands.
+		if _, statErr := os.Stat(c.DataDir()); os.IsNotExist(statErr) {
+			diags = diags.Append(ctxDiags)
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Backend initialization required, please run \"terraform init\"",
+				`This working directory has not been initialized, so the plugins required to generate configuration are not installed. Run "terraform init", or pass -auto-init to let this command initialize the directory itself, and then retry.`,
+			))
+			view.Diagnostics(diags)
+			return 1
+		}
+
+		// One broken provider fails context creation outright, even though
+		// generation may only need a different provider's schema. Retry by
+		// fetching the schemas of just the providers the requested resources
+		// resolve to, so an unrelated broken provider doesn't block
+		// generation; if that works the context errors are reported as
+		// warnings instead.
+		schemas, config, ctxDiags = c.targetProviderSchemas(args, ctxDiags)
+		diags = diags.Append(ctxDiags)
+		if schemas == nil {
+			view.Diagnostics(diags)
+			return 1
+		}
+	} else {
+		diags = diags.Append(ctxDiags)
+
+		// Successfully creating the context can result in a lock, so ensure we release it
+		defer func() {
+			diags := opReq.StateLocker.Unlock()
+			if diags.HasErrors() {
+				c.showDiagnostics(diags)
+			}
+		}()
+
+		// Get the schemas from the context
+		schemas = ctx.Schemas()
+		config = ctx.Config()
+	}
+
+	// Schema overrides shadow what the installed providers reported, so
+	// provider developers can generate configuration against an
+	// in-development schema before a provider build ships it. Any per-block
+	// example values the document's metadata declares render in place of
+	// zero placeholders.
+	if args.SchemaOverridePath != "" {
+		blockExamples, overrideDiags := applySchemaOverrides(schemas, args.SchemaOverridePath)
+		diags = diags.Append(overrideDiags)
+		if overrideDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+		if len(blockExamples) > 0 {
+			view.SetBlockExamples(blockExamples)
+		}
+	}
+
+	// With -audit we report how the resources already in the configuration
+	// compare against these schemas, rather than generating anything new.
+	if args.Audit {
+		if err := view.Audit(auditConfig(config, schemas), args.AuditFormat); err != nil {
+			diags = diags.Append(writeFailureDiag(err))
+			view.Diagnostics(diags)
+			return addExitWriteFailure
+		}
+		view.Diagnostics(diags)
+		return 0
+	}
+
+	// With -hcl2json or -json2hcl we print the requested resource blocks in
+	// the other configuration syntax, rather than generating anything new.
+	if args.HCL2JSON || args.JSON2HCL {
+		return c.runConvert(args, view, config, schemas, diags)
+	}
+
+	// If the user gave a policy directory, load it once up front; the
+	// policies are evaluated against each generated resource below.
+	var policies []genPolicy
+	if args.PolicyDir != "" {
+		var policyDiags tfdiags.Diagnostics
+		policies, policyDiags = loadGenPolicies(args.PolicyDir)
+		diags = diags.Append(policyDiags)
+		if policyDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+	}
+
+	// In a bulk run a few broken resources shouldn't abort the other
+	// hundred: a failure to read or decode one resource, or to find its
+	// schema, marks it as failed here and the run carries on. The failures
+	// are reported together at the end, with a distinct exit status when
+	// anything else generated successfully.
+	bulk := len(args.Patterns) > 0
+	failed := make(map[string]bool)
+
+	// If we are getting the values from state, fetch every requested resource
+	// up front so that we can order the generated blocks by their recorded
+	// dependencies.
+	resources := make(map[string]*states.Resource)
+	if args.FromState || args.DiffConfig {
+		for _, addr := range args.Addrs {
+			resource, moreDiags := c.getResource(b, addr.ContainingResource())
+			if moreDiags.HasErrors() {
+				diags = diags.Append(moreDiags)
+				if bulk {
+					failed[addr.String()] = true
+					continue
+				}
+				view.Diagnostics(diags)
+				return 1
+			}
+			resources[addr.String()] = resource
+		}
+	}
+
+	// Index the output values of the child module instances recorded in
+	// state, so values which came from another module's output can be
+	// written as references to that output instead of as literals.
+	if args.FromState {
+		state, moreDiags := c.getState(b)
+		if moreDiags.HasErrors() {
+			diags = diags.Append(moreDiags)
+			view.Diagnostics(diags)
+			return 1
+		}
+		view.ModuleOutputs(moduleOutputValues(state))
+	}
+
+	// When generating several resources from state we emit them in dependency
+	// order, so the result reads naturally from foundational resources to
+	// their dependents. Without state there are no recorded dependencies, so
+	// the resources keep the order they were given on the command line.
+	order := args.Addrs
+	if args.FromState && len(order) > 1 {
+		order = orderForGeneration(order, func(addr addrs.AbsResourceInstance) []addrs.ConfigResource {
+			// Resources which failed to read from state have no entry here.
+			if rs := resources[addr.String()]; rs != nil {
+				if ri := rs.Instance(addr.Resource.Key); ri != nil && ri.Current != nil {
+					return ri.Current.Dependencies
+				}
+			}
+			return nil
+		})
+	}
+
+	// Collect groups of requested instances which together make up a
+	// for_each resource: more than one instance of the same resource, all
+	// with string instance keys. Those are generated as a single block with
+	// for_each over a generated local map; see ResourceForEach.
+	forEachGroups := make(map[string][]addrs.AbsResourceInstance)
+	if args.FromState && !args.DiffConfig && !args.AsData {
+		byResource := make(map[string][]addrs.AbsResourceInstance)
+		for _, addr := range order {
+			key := addr.ContainingResource().String()
+			byResource[key] = append(byResource[key], addr)
+		}
+		for key, insts := range byResource {
+			if len(insts) < 2 {
+				continue
+			}
+			allKeyed := true
+			for _, inst := range insts {
+				if _, ok := inst.Resource.Key.(addrs.StringKey); !ok {
+					allKeyed = false
+					break
+				}
+			}
+			if allKeyed {
+				forEachGroups[key] = insts
+			}
+		}
+	}
+	forEachEmitted := make(map[string]bool)
+
+	// Each provider only warrants one undeclared-provider warning, even when
+	// several of the requested resources belong to it.
+	warnedProviders := make(map[addrs.Provider]bool)
+
+	// The blocks to generate are collected first and rendered together once
+	// the whole request is known to be valid.
+	var toGenerate []views.ResourceToGenerate
+
+	// Resources in a child module which receives its provider configuration
+	// through a providers = {} map on the module call get a comment naming
+	// the caller-side configuration, collected here per address.
+	passedProviders := make(map[string]string)
+
+	// The providers consulted during the run, for the metrics record written
+	// when TF_ADD_METRICS_FILE is set.
+	usedProviders := make(map[addrs.Provider]struct{})
+
+	// Module and resource lookups below run once per address, so the
+	// configuration tree is indexed up front rather than re-walked for each.
+	cfgIndex := indexConfigResources(config)
+
+	for _, addr := range order {
+		// Resources which already failed to read from state are skipped; the
+		// diagnostics were recorded when the read failed.
+		if failed[addr.String()] {
+			continue
+		}
+
+		// Find the module the resource will belong to, if it is declared.
+		// It's weird, but users can potentially specify non-existant module
+		// names, for which the index resolves nil.
+		module := cfgIndex.moduleForInstance(addr.Module)
+
+		// Determine the correct provider config address. The provider-related
+		// variables may get updated below
+		absProviderConfig := args.Provider
+		var providerLocalName string
+		rs := addr.Resource.Resource
+
+		// An alias chosen with -provider-alias refines the -provider
+		// address. The special name "default" pins the module's default
+		// configuration explicitly, which leaves the alias empty but marks
+		// the choice as deliberate for the ambiguity check below.
+		if args.Provider != nil && args.ProviderAlias != "" && args.ProviderAlias != "default" {
+			pc := *args.Provider
+			pc.Alias = args.ProviderAlias
+			absProviderConfig = &pc
+		}
+
+		// If we are getting the values from state, use the AbsProviderConfig
+		// recorded in state as well.
+		resource := resources[addr.String()]
+		if args.FromState || args.DiffConfig {
+			absProviderConfig = &resource.ProviderConfig
+		}
+
+		if absProviderConfig == nil {
+			ip := rs.ImpliedProvider()
+			if module != nil {
+				provider := module.ImpliedProviderForUnqualifiedType(ip)
+				providerLocalName = module.LocalNameForProvider(provider)
+				absProviderConfig = &addrs.AbsProviderConfig{
+					Provider: provider,
+					Module:   addr.Module.Module(),
+				}
+			} else {
+				// lacking any configuration to query, we'll go with a default provider.
+				absProviderConfig = &addrs.AbsProviderConfig{
+					Provider: addrs.NewDefaultProvider(ip),
+				}
+				providerLocalName = ip
+			}
+		} else {
+			if module != nil {
+				providerLocalName = module.LocalNameForProvider(absProviderConfig.Provider)
+			} else {
+				providerLocalName = absProviderConfig.Provider.Type
+			}
+		}
+
+		localProviderConfig := addrs.LocalProviderConfig{
+			LocalName: providerLocalName,
+			Alias:     absProviderConfig.Alias,
+		}
+
+		// A child module which declares configuration_aliases for the
+		// provider expects its caller to pass those aliased configurations
+		// in, and usually has no default configuration of its own, so the
+		// generated block references the first declared alias rather than
+		// silently selecting a default configuration that may not exist. An
+		// alias chosen explicitly with -provider still wins.
+		if module != nil && !addr.Module.IsRoot() && localProviderConfig.Alias == "" {
+			localProviderConfig.Alias = configurationAliasFor(module, providerLocalName)
+		}
+
+		// With -provider but no chosen alias, a module which declares
+		// several aliased configurations for that provider leaves the
+		// reference ambiguous. Rather than silently generating a block
+		// that uses the default configuration, report the candidates and
+		// ask for a -provider-alias choice.
+		if args.Provider != nil && args.ProviderAlias == "" && localProviderConfig.Alias == "" && module != nil {
+			var aliases []string
+			hasDefault := false
+			for _, pc := range module.ProviderConfigs {
+				if pc.Name != providerLocalName {
+					continue
+				}
+				if pc.Alias == "" {
+					hasDefault = true
+					continue
+				}
+				aliases = append(aliases, fmt.Sprintf("%s.%s", pc.Name, pc.Alias))
+			}
+			if len(aliases) > 1 {
+				sort.Strings(aliases)
+				suffix := "; note that the module declares no default (unaliased) configuration for this provider"
+				if hasDefault {
+					suffix = ", or -provider-alias=default to reference the module's default configuration"
+				}
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Ambiguous provider configuration",
+					fmt.Sprintf("The module declares multiple aliased configurations for provider %s: %s. Terraform cannot tell which of them the generated block should reference.\n\nRe-run with -provider-alias=ALIAS naming one of the aliases above%s.", absProviderConfig.Provider.ForDisplay(), strings.Join(aliases, ", "), suffix),
+				))
+				if bulk {
+					failed[addr.String()] = true
+					continue
+				}
+				view.Diagnostics(diags)
+				return 1
+			}
+		}
+
+		// Which credentials apply to a resource in a child module may be
+		// decided by the caller, through the providers = {} map on the module
+		// call; record the caller-side configuration so the generated block
+		// can name it.
+		if !addr.Module.IsRoot() {
+			if passed := passedProviderRef(cfgIndex, addr.Module.Module(), localProviderConfig); passed != "" {
+				passedProviders[addr.String()] = passed
+			}
+		}
+
+		// The local name above is only authoritative if the module declares
+		// the provider in required_providers; otherwise we've fallen back to
+		// the provider type, and the generated configuration may not select
+		// the provider the user intended.
+		if module != nil && !warnedProviders[absProviderConfig.Provider] {
+			if _, declared := module.ProviderLocalNames[absProviderConfig.Provider]; !declared {
+				warnedProviders[absProviderConfig.Provider] = true
+				detail := fmt.Sprintf("The module does not declare provider %s in its required_providers block, so Terraform has assumed the local name %q for it. To pin the provider's source and choose its local name explicitly, add it to required_providers.", absProviderConfig.Provider.ForDisplay(), providerLocalName)
+				// A wrong provider here often means a typo in the resource
+				// type's prefix, so list the providers the module does
+				// declare and suggest the closest prefix.
+				if declared := declaredProviderLocalNames(module); len(declared) > 0 {
+					hints := make([]string, len(declared))
+					for i, name := range declared {
+						hints[i] = fmt.Sprintf("%s (%q)", name, name+"_*")
+					}
+					detail = fmt.Sprintf("%s\n\nThe module declares these providers, with the resource type prefixes they imply: %s.", detail, strings.Join(hints, ", "))
+					if suggestion := didyoumean.NameSuggestion(providerLocalName, declared); suggestion != "" {
+						detail = fmt.Sprintf("%s Did you mean a resource type starting with %q?", detail, suggestion+"_")
+					}
+				}
+				// Spell out the exact declaration the module needs, so the
+				// user can paste it rather than reconstructing the source
+				// address and version themselves.
+				snippet := requiredProvidersSnippet(providerLocalName, absProviderConfig.Provider, c.lockedProviderVersions()[absProviderConfig.Provider])
+				detail = fmt.Sprintf("%s\n\nTo declare the provider, add the following to the module:\n\n%s", detail, snippet)
+				if (args.OutPath != "" || args.OutDir != "") && !args.Fix {
+					detail = fmt.Sprintf("%s\n\nRe-run with -fix to append this declaration to the generated file.", detail)
+				}
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Warning,
+					"Provider not declared in required_providers",
+					detail,
+				))
+			}
+		}
+
+		// Get the schemas from the context
+		if _, exists := schemas.Providers[absProviderConfig.Provider]; !exists {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Missing schema for provider",
+				fmt.Sprintf("No schema found for provider %s. Please verify that this provider exists in the configuration.", absProviderConfig.Provider.String()),
+			))
+			if bulk {
+				failed[addr.String()] = true
+				continue
+			}
+			view.Diagnostics(diags)
+			return 1
+		}
+
+		// Get the schema for the resource
+		schema, schemaVersion := schemas.ResourceTypeConfig(absProviderConfig.Provider, rs.Mode, rs.Type)
+		if schema == nil {
+			// The type name may just be misspelled, so check it against the
+			// names the provider's schema actually declares and suggest the
+			// closest one, which catches common typos like aws_s3bucket.
+			detail := fmt.Sprintf("No resource schema found for %s.", rs.Type)
+			if suggestion := didyoumean.NameSuggestion(rs.Type, resourceTypeNames(schemas.Providers[absProviderConfig.Provider], rs.Mode)); suggestion != "" {
+				detail = fmt.Sprintf("%s Did you mean %q?", detail, suggestion)
+			} else if !strings.HasPrefix(rs.Type, providerLocalName+"_") {
+				detail = fmt.Sprintf("%s Note that resource types from provider %q normally start with %q.", detail, providerLocalName, providerLocalName+"_")
+			}
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Missing resource schema from provider",
+				detail,
+			))
+			if bulk {
+				failed[addr.String()] = true
+				continue
+			}
+			view.Diagnostics(diags)
+			return 1
+		}
+
+		usedProviders[absProviderConfig.Provider] = struct{}{}
+
+		// With -inputs-json we only describe the arguments the resource type
+		// accepts; no configuration is rendered at all.
+		if args.InputsJSON {
+			if err := view.InputsJSON(schema); err != nil {
+				diags = diags.Append(writeFailureDiag(err))
+				view.Diagnostics(diags)
+				return addExitWriteFailure
+			}
+			continue
+		}
+
+		// With -path we render just one nested section of the resource
+		// type's schema, for pasting into a resource block the user
+		// already has.
+		if args.SubtreePath != "" {
+			subtree, moreDiags := subtreeSchema(schema, args.SubtreePath)
+			diags = diags.Append(moreDiags)
+			if moreDiags.HasErrors() {
+				view.Diagnostics(diags)
+				return 1
+			}
+			if err := view.ResourceSubtree(addr, subtree); err != nil {
+				failure, status := viewFailure(err)
+				diags = diags.Append(failure)
+				view.Diagnostics(diags)
+				return status
+			}
+			continue
+		}
+
+		// With -type=test we generate a minimal test suite fixture for the
+		// resource type instead of a bare resource block, for use under the
+		// tests directory read by "terraform test".
+		if args.TestFixture {
+			if err := view.TestFixture(addr, schema, localProviderConfig, absProviderConfig.Provider); err != nil {
+				failure, status := viewFailure(err)
+				diags = diags.Append(failure)
+				view.Diagnostics(diags)
+				return status
+			}
+			continue
+		}
+
+		// With -clone we copy the source block in the hclwrite token stream,
+		// rename it and strip the attributes the configuration cannot set,
+		// preserving the author's formatting, comments and expressions.
+		if args.Clone {
+			body, moreDiags := cloneResourceBlock(cloneSrc, addr.Resource.Resource.Name, schema)
+			diags = diags.Append(moreDiags)
+			if moreDiags.HasErrors() {
+				view.Diagnostics(diags)
+				return 1
+			}
+			if err := view.ResourceClone(addr, *args.CloneSource, body); err != nil {
+				failure, status := viewFailure(err)
+				diags = diags.Append(failure)
+				view.Diagnostics(diags)
+				return status
+			}
+			continue
+		}
+
+		// With -merge, a resource already in configuration gets a report of
+		// the required arguments its block is missing, instead of a second
+		// resource block.
+		if rsCfg, ok := existing[addr.String()]; ok && args.Merge {
+			missing := missingRequiredAttributes(schema, rsCfg.Config)
+			if err := view.MissingRequired(addr, schema, localProviderConfig, missing, rsCfg.DeclRange); err != nil {
+				diags = diags.Append(writeFailureDiag(err))
+				view.Diagnostics(diags)
+				return addExitWriteFailure
+			}
+			continue
+		}
+
+		// When every requested instance of a for_each resource is being
+		// generated, the original abstraction is reconstructed as a single
+		// block with for_each over a generated local map, rather than N
+		// near-identical blocks.
+		if insts, ok := forEachGroups[addr.ContainingResource().String()]; ok {
+			groupKey := addr.ContainingResource().String()
+			if forEachEmitted[groupKey] {
+				continue
+			}
+			forEachEmitted[groupKey] = true
+
+			vals := make(map[string]cty.Value, len(insts))
+			groupFailed := false
+			for _, inst := range insts {
+				val, moreDiags := c.decodeInstanceStateValue(resources[inst.String()], inst, absProviderConfig.Provider, schema, schemaVersion)
+				if moreDiags.HasErrors() {
+					diags = diags.Append(moreDiags)
+					// One undecodable instance fails the whole group, since a
+					// for_each block with a hole in its map would be wrong.
+					if bulk {
+						failed[addr.String()] = true
+						groupFailed = true
+						break
+					}
+					view.Diagnostics(diags)
+					return 1
+				}
+				vals[string(inst.Resource.Key.(addrs.StringKey))] = val
+			}
+			if groupFailed {
+				continue
+			}
+			if err := view.ResourceForEach(addr.ContainingResource(), schema, localProviderConfig, vals); err != nil {
+				failure, status := viewFailure(err)
+				diags = diags.Append(failure)
+				view.Diagnostics(diags)
+				return status
+			}
+			continue
+		}
+
+		stateVal := cty.NilVal
+		// Now that we have the schema, we can decode the previously-acquired resource state
+		if args.FromState || args.DiffConfig {
+			val, moreDiags := c.decodeInstanceStateValue(resource, addr, absProviderConfig.Provider, schema, schemaVersion)
+			if moreDiags.HasErrors() {
+				diags = diags.Append(moreDiags)
+				if bulk {
+					failed[addr.String()] = true
+					continue
+				}
+				view.Diagnostics(diags)
+				return 1
+			}
+			stateVal = val
+		}
+
+		// With -diff-config we compare the values in state against the
+		// existing resource block and report the attributes which are set in
+		// state but not written in the configuration, instead of generating
+		// a new block.
+		if args.DiffConfig {
+			rsCfg := existing[addr.String()]
+			drifted := driftedAttributes(schema, rsCfg.Config, stateVal, args.IncludeOptionalComputed)
+			if err := view.DiffConfig(addr, schema, localProviderConfig, stateVal, drifted, rsCfg.DeclRange); err != nil {
+				diags = diags.Append(writeFailureDiag(err))
+				view.Diagnostics(diags)
+				return addExitWriteFailure
+			}
+			continue
+		}
+
+		// With -as-data we generate a data block looking up the same object
+		// the managed resource tracks in state, using the provider's data
+		// source of the same type name, for configurations being split where
+		// one side should consume the object rather than manage it.
+		if args.AsData {
+			dataSchema, _ := schemas.ResourceTypeConfig(absProviderConfig.Provider, addrs.DataResourceMode, rs.Type)
+			if dataSchema == nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"No matching data source",
+					fmt.Sprintf("Provider %s does not offer a data source named %q, so -as-data cannot generate a lookup for %s.", absProviderConfig.Provider.ForDisplay(), rs.Type, addr),
+				))
+				view.Diagnostics(diags)
+				return 1
+			}
+			if err := view.DataSource(addr, localProviderConfig, dataSourceLookupValues(dataSchema, stateVal)); err != nil {
+				diags = diags.Append(writeFailureDiag(err))
+				view.Diagnostics(diags)
+				return addExitWriteFailure
+			}
+			continue
+		}
+
+		// Evaluate any policies against the values the generated configuration
+		// will contain before writing anything.
+		if args.PolicyDir != "" {
+			checkVal := stateVal
+			if checkVal.RawEquals(cty.NilVal) {
+				checkVal = schema.EmptyValue()
+			}
+			violationDiags := checkGenPolicies(policies, checkVal, schema.ImpliedType())
+			diags = diags.Append(violationDiags)
+			if violationDiags.HasErrors() && (args.OutPath != "" || args.OutDir != "") {
+				// Error-level violations block writing the result to a file;
+				// warnings are reported below alongside the generated output.
+				view.Diagnostics(diags)
+				return 1
+			}
+		}
+
+		// A provider the module doesn't declare also gets a ready-to-use
+		// scaffold pinning it in required_providers — including any provider
+		// configuration its schema makes mandatory, such as azurerm's
+		// features block — so the generated configuration initializes
+		// cleanly. When writing to a file the scaffold is opt-in via -fix,
+		// since appending provider pins to the module's own files is a more
+		// consequential edit than printing them; without -fix the warning
+		// above carries the declaration instead.
+		if module != nil && (args.OutPath == "" && args.OutDir == "" || args.Fix) {
+			if _, declared := module.ProviderLocalNames[absProviderConfig.Provider]; !declared {
+				if err := view.ProviderScaffold(addr, localProviderConfig, absProviderConfig.Provider, schemas.Providers[absProviderConfig.Provider].Provider); err != nil {
+					failure, status := viewFailure(err)
+					diags = diags.Append(failure)
+					view.Diagnostics(diags)
+					return status
+				}
+			}
+		}
+
+		// If the module's existing resources derive their names from
+		// terraform.workspace, suggest a name expression following the same
+		// convention next to the placeholder, so the scaffold stays
+		// consistent with the module's naming.
+		if stateVal.RawEquals(cty.NilVal) && module != nil {
+			if attrS, ok := schema.Attributes["name"]; ok && (attrS.Required || attrS.Optional) {
+				if expr, ok := workspaceNameExample(module, addr.Resource.Resource.Name); ok {
+					view.SuggestExample("name", expr)
+				}
+			}
+		}
+
+		toGenerate = append(toGenerate, views.ResourceToGenerate{
+			Addr:     addr,
+			Schema:   schema,
+			Provider: localProviderConfig,
+			State:    stateVal,
+		})
+	}
+
+	// Values from standard input must type-check against the schemas before
+	// anything is rendered.
+	if len(args.StdinValues) > 0 {
+		moreDiags := checkStdinValues(args.StdinValues, toGenerate)
+		diags = diags.Append(moreDiags)
+		if moreDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+		view.SetValues(args.StdinValues)
+	}
+
+	if len(passedProviders) > 0 {
+		view.SetPassedProviders(passedProviders)
+	}
+
+	// Render all of the blocks at once; the view renders them concurrently
+	// and then emits them in the order collected above.
+	if err := view.Resources(toGenerate); err != nil {
+		failure, status := viewFailure(err)
+		diags = diags.Append(failure)
+		view.Diagnostics(diags)
+		return status
+	}
+
+	view.Diagnostics(diags)
+	if diags.HasErrors() {
+		// Tolerated per-resource failures reach here with the other
+		// resources already rendered above; a distinct exit status tells
+		// pipelines this was a partial success rather than a failed run.
+		if len(failed) > 0 && len(failed) < len(order) {
+			return addExitPartialFailure
+		}
+		return 1
+	}
+
+	// Inside a Terraform Cloud run (or a cloud agent), a bulk run also
+	// records its results as a structured check summary in the data
+	// directory, so configuration-generation jobs can publish what was
+	// generated into the remote workflow.
+	if runID := os.Getenv(tfcRunIDEnvName); runID != "" && len(args.Patterns) > 0 {
+		path := filepath.Join(c.DataDir(), addCheckSummaryFilename)
+		if err := view.CheckSummary(path, runID, warningCount(diags)); err != nil {
+			diags = diags.Append(writeFailureDiag(err))
+			view.Diagnostics(diags)
+			return addExitWriteFailure
+		}
+	}
+
+	// With TF_ADD_METRICS_FILE set, a machine-readable record of the run is
+	// appended to the named file, so platform teams rolling out configuration
+	// generation can track its adoption and performance without scraping the
+	// human-oriented output.
+	if path := os.Getenv(addMetricsFileEnvName); path != "" {
+		rec := addRunMetricsRecord(start, len(toGenerate), warningCount(diags), schemas, usedProviders, c.lockedProviderVersions())
+		if err := appendAddMetrics(path, rec); err != nil {
+			diags = diags.Append(writeFailureDiag(err))
+			view.Diagnostics(diags)
+			return addExitWriteFailure
+		}
+	}
+
+	view.Summary(warningCount(diags))
+	return 0
+}
+
+// runBackendScaffold generates a terraform block containing a backend
+// configuration skeleton for the backend type named with -type=backend.
+// Backends are compiled into Terraform, so the schema comes straight from
+// the built-in backend registry with nothing to initialize first.
+func (c *AddCommand) runBackendScaffold(args *arguments.Add, view views.Add) int {
+	var diags tfdiags.Diagnostics
+
+	f := backendInit.Backend(args.BackendType)
+	if f == nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Unsupported backend type",
+			fmt.Sprintf("There is no backend type named %q. See the Terraform documentation for the backend types this version of Terraform supports.", args.BackendType),
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	if err := view.BackendScaffold(args.BackendType, f().ConfigSchema()); err != nil {
+		diags = diags.Append(writeFailureDiag(err))
+		view.Diagnostics(diags)
+		return addExitWriteFailure
+	}
+
+	view.Diagnostics(diags)
+	return 0
+}
+
+// runExternalData scaffolds data "external" blocks, and with -with-script
+// the program stubs they run, from the data source's fixed protocol schema.
+func (c *AddCommand) runExternalData(args *arguments.Add, view views.Add) int {
+	var diags tfdiags.Diagnostics
+
+	// Ensure the directory the configuration (and any script stub) goes
+	// into exists and is accessible.
+	if args.OutPath != "" || args.OutDir != "" {
+		outDir := args.OutDir
+		if outDir == "" {
+			outDir = filepath.Dir(args.OutPath)
+		}
+		if _, err := os.Stat(outDir); os.IsNotExist(err) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"The out path doesn't exist or is not accessible",
+				err.Error(),
+			))
+			view.Diagnostics(diags)
+			return 1
+		}
+	}
+
+	for _, addr := range args.Addrs {
+		if err := view.ExternalDataSource(addr, args.WithScript); err != nil {
+			failure, status := viewFailure(err)
+			diags = diags.Append(failure)
+			view.Diagnostics(diags)
+			return status
+		}
+	}
+
+	view.Diagnostics(diags)
+	return 0
+}
+
+// runZeroConfig generates configuration without loading any configuration or
+// state, for working directories which contain no .tf files yet. There is no
+// required_providers block to resolve resource types against, so the provider
+// must be named explicitly with -provider, and its schema comes directly from
+// the installed plugin (or from a -schema-override file). Since a brand new
+// project has no required_providers block either, each generated resource is
+// preceded by the same provider scaffold an undeclared provider gets in a
+// configured directory.
+func (c *AddCommand) runZeroConfig(args *arguments.Add, view views.Add) int {
+	var diags tfdiags.Diagnostics
+
+	// The options which read existing configuration or state have nothing
+	// to work from here.
+	if args.FromState || args.DiffConfig || args.Merge || args.Audit || args.FromExisting != nil || args.CloneSource != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"No Terraform configuration files",
+			"The working directory contains no Terraform configuration files, so there is no existing configuration or state to read. The -from-state, -diff-config, -merge, -audit, -from-existing-resource and -clone options are only available in a directory with existing configuration.",
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	if args.Provider == nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"No Terraform configuration files",
+			"The working directory contains no Terraform configuration files, so there is no required_providers block to resolve the resource types against. Pass -provider to name the provider explicitly and Terraform will generate the configuration from its schema alone.",
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+	provider := args.Provider.Provider
+
+	// With no configuration files there is no required_providers block, so
+	// no version constraint narrows the cached package selection.
+	schemas, schemaDiags := c.zeroConfigSchemas(provider, nil)
+	diags = diags.Append(schemaDiags)
+	if schemaDiags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	// Schema overrides apply here like they do in the configured path, and
+	// additionally stand in for the plugin entirely when it isn't installed.
+	if args.SchemaOverridePath != "" {
+		blockExamples, overrideDiags := applySchemaOverrides(schemas, args.SchemaOverridePath)
+		diags = diags.Append(overrideDiags)
+		if overrideDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+		if len(blockExamples) > 0 {
+			view.SetBlockExamples(blockExamples)
+		}
+	}
+
+	if _, exists := schemas.Providers[provider]; !exists {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Provider plugin not available",
+			fmt.Sprintf("The plugin for provider %s is not installed, and with no configuration files in the working directory there is nothing for \"terraform init\" to install it from. Write a required_providers block and run \"terraform init\" first, or supply the provider's schema with -schema-override.", provider.ForDisplay()),
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	// Lacking a module to consult for a local name, the provider type is
+	// the best name available, as in the configured path.
+	localProviderConfig := addrs.LocalProviderConfig{
+		LocalName: provider.Type,
+		Alias:     args.Provider.Alias,
+	}
+
+	var toGenerate []views.ResourceToGenerate
+	for _, addr := range args.Addrs {
+		rs := addr.Resource.Resource
+
+		schema, _ := schemas.ResourceTypeConfig(provider, rs.Mode, rs.Type)
+		if schema == nil {
+			detail := fmt.Sprintf("No resource schema found for %s.", rs.Type)
+			if suggestion := didyoumean.NameSuggestion(rs.Type, resourceTypeNames(schemas.Providers[provider], rs.Mode)); suggestion != "" {
+				detail = fmt.Sprintf("%s Did you mean %q?", detail, suggestion)
+			}
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Missing resource schema from provider",
+				detail,
+			))
+			view.Diagnostics(diags)
+			return 1
+		}
+
+		if args.InputsJSON {
+			if err := view.InputsJSON(schema); err != nil {
+				diags = diags.Append(writeFailureDiag(err))
+				view.Diagnostics(diags)
+				return addExitWriteFailure
+			}
+			continue
+		}
+
+		if args.TestFixture {
+			if err := view.TestFixture(addr, schema, localProviderConfig, provider); err != nil {
+				failure, status := viewFailure(err)
+				diags = diags.Append(failure)
+				view.Diagnostics(diags)
+				return status
+			}
+			continue
+		}
+
+		if err := view.ProviderScaffold(addr, localProviderConfig, provider, schemas.Providers[provider].Provider); err != nil {
+			failure, status := viewFailure(err)
+			diags = diags.Append(failure)
+			view.Diagnostics(diags)
+			return status
+		}
+
+		toGenerate = append(toGenerate, views.ResourceToGenerate{
+			Addr:     addr,
+			Schema:   schema,
+			Provider: localProviderConfig,
+			State:    cty.NilVal,
+		})
+	}
+
+	if len(args.StdinValues) > 0 {
+		moreDiags := checkStdinValues(args.StdinValues, toGenerate)
+		diags = diags.Append(moreDiags)
+		if moreDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+		view.SetValues(args.StdinValues)
+	}
+
+	if err := view.Resources(toGenerate); err != nil {
+		failure, status := viewFailure(err)
+		diags = diags.Append(failure)
+		view.Diagnostics(diags)
+		return status
+	}
+
+	view.Diagnostics(diags)
+	if diags.HasErrors() {
+		return 1
+	}
+	view.Summary(warningCount(diags))
+	return 0
+}
+
+// targetProviderSchemas is a fallback for when building the full context
+// fails because a provider could not report its schema: the context loads
+// every provider the configuration uses, while generation only needs the
+// providers the requested resources resolve to. It fetches the schemas of
+// just those providers directly from their plugins, and if they all load it
+// returns them along with the context's errors downgraded to warnings. If
+// the failure was something other than schema loading, or one of the needed
+// providers is itself broken, it returns a nil Schemas and the original
+// errors unchanged.
+func (c *AddCommand) targetProviderSchemas(args *arguments.Add, ctxDiags tfdiags.Diagnostics) (*terraform.Schemas, *configs.Config, tfdiags.Diagnostics) {
+	for _, diag := range ctxDiags {
+		if diag.Severity() == tfdiags.Error && diag.Description().Summary != "Could not load plugin" {
+			return nil, nil, ctxDiags
+		}
+	}
+
+	config, loadDiags := c.loadConfig(".")
+	if loadDiags.HasErrors() || config == nil {
+		return nil, nil, ctxDiags
+	}
+
+	needed := make(map[addrs.Provider]struct{})
+	for _, addr := range args.Addrs {
+		if args.Provider != nil {
+			needed[args.Provider.Provider] = struct{}{}
+			continue
+		}
+		var module *configs.Module
+		if addr.Module.IsRoot() {
+			module = config.Module
+		} else if cfg := config.Root.Descendent(addr.Module.Module()); cfg != nil {
+			module = cfg.Module
+		}
+		ip := addr.Resource.Resource.ImpliedProvider()
+		if module != nil {
+			needed[module.ImpliedProviderForUnqualifiedType(ip)] = struct{}{}
+		} else {
+			needed[addrs.NewDefaultProvider(ip)] = struct{}{}
+		}
+	}
+
+	// The configuration's version constraints steer which cached provider
+	// package serves the schema when the lock file has no say; constraint
+	// errors here would have failed the config load above already.
+	reqs, _ := config.ProviderRequirements()
+
+	schemas := &terraform.Schemas{
+		Providers: make(map[addrs.Provider]*terraform.ProviderSchema),
+	}
+	for provider := range needed {
+		got, moreDiags := c.zeroConfigSchemas(provider, reqs[provider])
+		ps, ok := got.Providers[provider]
+		if moreDiags.HasErrors() || !ok {
+			// A provider we actually need is broken or missing, so the
+			// original errors stand.
+			return nil, nil, ctxDiags
+		}
+		schemas.Providers[provider] = ps
+	}
+
+	var diags tfdiags.Diagnostics
+	for _, diag := range ctxDiags {
+		desc := diag.Description()
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Some provider schemas could not be loaded",
+			fmt.Sprintf("%s\n\nThe schemas of the providers needed for this generation loaded successfully, so generation continued anyway. Other commands will keep failing until the problem above is fixed.", strings.TrimSpace(desc.Detail)),
+		))
+	}
+	return schemas, config, diags
+}
+
+// lockedProviderVersions returns the version selected for each provider in
+// the dependency lock file, keyed by provider address. These become version
+// floors in generated required_providers scaffolds. A missing or unreadable
+// lock file simply means no floors are known; it is never an error here.
+func (c *AddCommand) lockedProviderVersions() map[addrs.Provider]string {
+	locks, diags := c.lockedDependencies()
+	if diags.HasErrors() {
+		return nil
+	}
+	versions := make(map[addrs.Provider]string)
+	for provider, lock := range locks.AllProviders() {
+		versions[provider] = lock.Version().String()
+	}
+	return versions
+}
+
+// Provider-reported throttling while fetching schemas is retried this many
+// times, doubling the delay each attempt. Variables rather than constants so
+// tests can shorten the delays.
+var (
+	schemaRetryAttempts  = 3
+	schemaRetryBaseDelay = 500 * time.Millisecond
+)
+
+// schemaFetchThrottled reports whether the given diagnostics look like the
+// provider (or a service behind it) throttling the request, as opposed to a
+// permanent failure not worth retrying. Providers don't report throttling in
+// any structured way, so this matches the conventional wordings.
+func schemaFetchThrottled(diags tfdiags.Diagnostics) bool {
+	for _, diag := range diags {
+		desc := diag.Description()
+		text := strings.ToLower(desc.Summary + " " + desc.Detail)
+		for _, marker := range []string{"throttl", "rate limit", "rate exceeded", "too many requests", "429"} {
+			if strings.Contains(text, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// zeroConfigSchemas fetches the schema of a single explicitly-named provider
+// directly from its plugin, for use when there is no configuration from which
+// to build a context. An unavailable plugin is not an error here: the
+// returned schemas are simply missing the provider, since a -schema-override
+// file may still supply it, and the caller reports the absence after any
+// overrides are applied.
+func (c *AddCommand) zeroConfigSchemas(provider addrs.Provider, constraints getproviders.VersionConstraints) (*terraform.Schemas, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	schemas := &terraform.Schemas{
+		Providers: make(map[addrs.Provider]*terraform.ProviderSchema),
+	}
+
+	// The full schema is fetched at most once per provider per run; bulk
+	// modes resolving many resource types all share the indexed result.
+	if ps, ok := c.schemaCache[provider]; ok {
+		log.Printf("[TRACE] terraform add: schema for %s served from cache", provider)
+		schemas.Providers[provider] = ps
+		return schemas, diags
+	}
+
+	var factories map[addrs.Provider]providers.Factory
+	if c.testingOverrides != nil {
+		factories = c.testingOverrides.Providers
+	} else {
+		var err error
+		factories, err = c.providerFactories()
+		if err != nil {
+			// The factories map is as complete as it can be even on error,
+			// and we only need the one provider from it, so we report the
+			// problem only if that provider turns out to be missing below.
+			log.Printf("[WARN] Failed to determine selected providers: %s", err)
+		}
+	}
+
+	factory, ok := factories[provider]
+	if !ok {
+		// The dependency lock file doesn't cover this provider, but one or
+		// more versions of it may still be cached locally; pick the best of
+		// those rather than failing outright.
+		factory, ok = c.cachedProviderFactory(provider, constraints)
+		if !ok {
+			return schemas, diags
+		}
+	} else if version := c.lockedProviderVersions()[provider]; version != "" {
+		// The factory built from the lock file launches exactly the locked
+		// version; surface which one that is, and whether it still satisfies
+		// the configured constraint, in the logs.
+		log.Printf("[INFO] terraform add: using %s v%s per the dependency lock file", provider, version)
+		if v, err := getproviders.ParseVersion(version); len(constraints) > 0 && err == nil && !getproviders.MeetingConstraints(constraints).Has(v) {
+			log.Printf("[WARN] terraform add: locked version %s of %s does not satisfy the configured constraint %q; run \"terraform init -upgrade\" to reselect", version, provider, getproviders.VersionConstraintsString(constraints))
+		}
+	}
+
+	inst, err := factory()
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to start provider plugin",
+			fmt.Sprintf("Could not start the plugin for provider %s to read its schema: %s.", provider.ForDisplay(), err),
+		))
+		return schemas, diags
+	}
+	defer inst.Close()
+
+	// Timing the round-trip makes the cost of each schema fetch visible in
+	// the logs, so the effect of the per-run cache above is measurable.
+	start := time.Now()
+	resp := inst.GetProviderSchema()
+	log.Printf("[TRACE] terraform add: GetProviderSchema for %s took %s", provider, time.Since(start))
+
+	// Some providers consult a remote service when reporting their schema,
+	// so a run resolving many resource types can be throttled. Throttling is
+	// transient by definition, so it gets a few retries with exponential
+	// backoff before the run gives up.
+	for attempt := 1; resp.Diagnostics.HasErrors() && schemaFetchThrottled(resp.Diagnostics) && attempt <= schemaRetryAttempts; attempt++ {
+		wait := schemaRetryBaseDelay << (attempt - 1)
+		log.Printf("[WARN] terraform add: provider %s reported throttling while fetching its schema; retrying in %s (attempt %d of %d)", provider, wait, attempt, schemaRetryAttempts)
+		time.Sleep(wait)
+		resp = inst.GetProviderSchema()
+	}
+	if resp.Diagnostics.HasErrors() {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read provider schema",
+			fmt.Sprintf("The plugin for provider %s could not report its schema: %s.", provider.ForDisplay(), resp.Diagnostics.Err()),
+		))
+		return schemas, diags
+	}
+
+	ps := &terraform.ProviderSchema{
+		Provider:                   resp.Provider.Block,
+		ProviderMeta:               resp.ProviderMeta.Block,
+		ResourceTypes:              make(map[string]*configschema.Block),
+		DataSources:                make(map[string]*configschema.Block),
+		ResourceTypeSchemaVersions: make(map[string]uint64),
+	}
+	for t, r := range resp.ResourceTypes {
+		ps.ResourceTypes[t] = r.Block
+		ps.ResourceTypeSchemaVersions[t] = uint64(r.Version)
+	}
+	for t, d := range resp.DataSources {
+		ps.DataSources[t] = d.Block
+	}
+	schemas.Providers[provider] = ps
+
+	if c.schemaCache == nil {
+		c.schemaCache = make(map[addrs.Provider]*terraform.ProviderSchema)
+	}
+	c.schemaCache[provider] = ps
+
+	return schemas, diags
+}
+
+// cachedProviderFactory selects a provider package from the cache
+// directories for a provider the dependency lock file does not cover: the
+// newest cached version which satisfies the configuration's version
+// constraint for the provider, rather than whichever package happens to be
+// found first. The factories built from the lock file always launch the
+// locked version exactly, so this only decides among cached versions when
+// there is no lock entry to decide for us.
+func (c *AddCommand) cachedProviderFactory(provider addrs.Provider, constraints getproviders.VersionConstraints) (providers.Factory, bool) {
+	entry := c.selectCachedProvider(provider, constraints)
+	if entry == nil {
+		return nil, false
+	}
+	return providerFactory(entry), true
+}
+
+// selectCachedProvider picks the cache entry cachedProviderFactory builds its
+// factory from: the newest cached version satisfying the constraint, or nil
+// when nothing in the caches does. The working directory's own cache is
+// preferred, since anything there was selected by an init run in this
+// directory; the shared global plugin cache is consulted when the local
+// cache has nothing suitable, so quick scaffolding works in a throwaway
+// directory that has never been initialized, as long as some other project
+// has cached the provider. The plugin is only launched from the global cache
+// to read its schema; nothing is installed into the working directory.
+func (c *AddCommand) selectCachedProvider(provider addrs.Provider, constraints getproviders.VersionConstraints) *providercache.CachedProvider {
+	if entry := selectFromCacheDir(c.providerLocalCacheDir(), provider, constraints); entry != nil {
+		return entry
+	}
+	if global := c.providerGlobalCacheDir(); global != nil {
+		if entry := selectFromCacheDir(global, provider, constraints); entry != nil {
+			log.Printf("[INFO] terraform add: %s is not installed in this working directory; launching v%s read-only from the global plugin cache", provider, entry.Version)
+			return entry
+		}
+	}
+	return nil
+}
+
+// selectFromCacheDir picks the newest version of the given provider in one
+// cache directory which satisfies the constraint, or nil when the directory
+// holds no acceptable version.
+func selectFromCacheDir(dir *providercache.Dir, provider addrs.Provider, constraints getproviders.VersionConstraints) *providercache.CachedProvider {
+	entries := dir.AllAvailablePackages()[provider]
+	if len(entries) == 0 {
+		return nil
+	}
+
+	acceptable := getproviders.MeetingConstraints(constraints)
+	// The cache entries are sorted with the newest version first, so the
+	// first acceptable entry is the best one.
+	for _, entry := range entries {
+		if len(constraints) > 0 && !acceptable.Has(entry.Version) {
+			log.Printf("[TRACE] terraform add: cached %s v%s does not satisfy the configured constraint %q", provider, entry.Version, getproviders.VersionConstraintsString(constraints))
+			continue
+		}
+		entry := entry
+		log.Printf("[INFO] terraform add: selected %s v%s from the %d cached version(s)", provider, entry.Version, len(entries))
+		return &entry
+	}
+	log.Printf("[WARN] terraform add: none of the %d cached version(s) of %s satisfy the configured constraint %q", len(entries), provider, getproviders.VersionConstraintsString(constraints))
+	return nil
+}
+
+func (c *AddCommand) Help() string {
+	helpText := `
+Usage: terraform [global options] add [options] ADDRESS...
+
+  Generates a blank resource template for each of the given addresses. With
+  no additional options, Terraform will write the result to standard output.
+
+  When generating several resources with -from-state, the blocks are ordered
+  by the dependencies recorded in the state, so that foundational resources
+  come before the resources which refer to them.
+
+  With -from-state, an address may also be a wildcard pattern selecting every
+  resource of a type ('aws_s3_bucket.*', or 'module.foo.aws_s3_bucket.*' for
+  one module), or a whole-module address selecting every resource in that
+  module subtree, using the same rules as "terraform state list".
+
+Options:
+
+  -after='resource "aws_vpc" "main"'
+                      Insert the generated block into the -out file directly
+                      after the named existing block, instead of appending it
+                      at the end of the file. The anchor is a block type
+                      followed by its quoted labels. Requires -out.
+
+  -audit              Instead of generating configuration, compare every
+                      resource block already in the configuration against its
+                      provider schema and report the required attributes the
+                      block does not set, together with the settable
+                      attributes the schema declares but the block leaves
+                      unset. Takes no address arguments.
+
+  -audit-format=table Choose how the -audit report is presented: "table"
+                      (the default) or "json".
+
+  -attr-provenance    Annotate each attribute in -from-state output with the
+                      source of its value: "from state", "default" for an
+                      optional attribute the provider will decide, or
+                      "REQUIRED - please set" for a required attribute with
+                      no value in state. Requires -from-state.
+
+  -auto-approve       Skip the confirmation prompt normally shown before
+                      bulk generation when wildcard or whole-module address
+                      patterns are used. Required for pattern use in
+                      automation, where Terraform cannot ask for approval.
+
+  -auto-init          Run the equivalent of "terraform init" first if the
+                      working directory has not been initialized yet, instead
+                      of failing with advice to do so.
+
+  -clone              Copy an existing resource block's own HCL to a new
+                      name, preserving the author's formatting, comments and
+                      expressions, with computed-only attributes stripped.
+                      Takes exactly two addresses: the block to copy,
+                      followed by the address to declare the copy at.
+
+  -compact-json       Emit -inputs-json output as newline-delimited compact
+                      JSON objects, one per argument, instead of a
+                      pretty-printed array. Friendlier to line-oriented
+                      consumers such as jq.
+
+  -diff-config        Instead of generating a new block, compare the values in
+                      state against the existing resource block and report (as
+                      comments) any attributes which are set in state but
+                      missing from the configuration.
+
+  -explain            After each generated block, report why every emitted
+                      attribute and nested block is present: required by the
+                      schema, a min_items constraint, requested with
+                      -optional, or populated from state. The report is an
+                      aside on stderr, so saved output is unaffected.
+
+  -from-existing-resource=ADDRESS
+                      Copy the block of a resource already declared in the
+                      configuration as the starting point for the generated
+                      block, including its meta-arguments (provider,
+                      lifecycle, depends_on). The source and target addresses
+                      must have the same resource type.
+
+  -from-state         Fill the template with values from an existing resource
+                      instance tracked in the state. By default, Terraform will
+                      emit only placeholder values based on the resource type.
+
+  -include-optional-computed  Include attributes that are both optional and
+                      computed when generating from state. By default those
+                      are omitted, since the provider will recompute them
+                      from the other arguments anyway.
+
+  -out=string         Write the template to a file, instead of to standard
+                      output. Reruns are idempotent: an identical existing
+                      block is reported as up to date, and a differing one
+                      as drift with exit status 2, instead of appending a
+                      duplicate.
+
+  -out-dir=DIR        Write the template into a file inside the given
+                      directory, chosen deterministically according to the
+                      -split-by grouping, instead of to standard output.
+                      Each generated file carries an index of the blocks
+                      appended to it.
+
+  -split-by=MODE      Choose how -out-dir output is organized into files:
+                      "type" (the default, e.g. s3.tf), "provider" (e.g.
+                      aws.tf) or "module".
+
+  -policy-dir=DIR     Evaluate the JSON policy documents in the given
+                      directory against the generated values before writing.
+                      Error-level violations block writing the result with
+                      -out; warning-level violations are only reported.
+
+  -optional           Include optional arguments. By default, the result will
+                      include only required arguments.
+
+  -path=PATH          Render just the named nested block or attribute of the
+                      resource type's schema, such as "network_interface" or
+                      "timeouts.create", for pasting into a resource block
+                      that already exists. Implies standard output.
+
+  -file-header=FILE   Prepend the contents of the given text file (such as a
+                      copyright header) whenever a brand new configuration
+                      file is created with -out or -out-dir. Files which
+                      already exist are appended to unchanged.
+
+  -example-expressions=FILE  Read a JSON object from the given file mapping
+                      attribute names to example expressions, extending or
+                      replacing the built-in table of suggestions shown as
+                      comments next to placeholder values.
+
+  -extract-variables  Write each placeholder attribute as a reference to an
+                      input variable instead, and declare the variables
+                      alongside the resource blocks (in a variables.tf file
+                      with -out or -out-dir). When the provider documents the
+                      attribute's allowed values or length limits, a matching
+                      validation block is scaffolded in the declaration.
+
+  -fix                When the module doesn't declare the target provider in
+                      required_providers, append the missing declaration to
+                      the file named by -out, instead of only showing it in
+                      the warning. Requires -out.
+
+  -hcl2json           Instead of generating configuration, print the JSON
+                      syntax (.tf.json) representation of a resource block
+                      already declared in a native syntax file, using the
+                      provider schema to distinguish nested blocks from
+                      attributes. Non-literal expressions are carried over
+                      in the "${...}" template form.
+
+  -json2hcl           The inverse of -hcl2json: print the native syntax
+                      representation of a resource block already declared in
+                      a JSON syntax (.tf.json) file.
+
+  -schema-override=FILE  Read a JSON object from the given file mapping
+                      provider source addresses to schema documents, in the
+                      same shape as "terraform providers schema -json", which
+                      shadow what the installed providers report. Useful for
+                      testing generation against an in-development provider
+                      schema.
+
+  -secure-defaults    Pre-populate security-sensitive attributes such as
+                      "encrypted" or "publicly_accessible" with conservative
+                      values from a built-in catalog, where the resource type
+                      supports them, instead of empty placeholders.
+
+  -infer              Annotate placeholder attributes whose names match
+                      well-known environment conventions (region from
+                      AWS_REGION, project from GOOGLE_PROJECT, and so on)
+                      with the value found in the environment, as a commented
+                      suggestion next to the placeholder.
+
+  -import-identity    Mark the attributes which identify the real remote
+                      object (id, name, arn) with a comment, for
+                      configuration intended to be "terraform import"ed:
+                      those values must match the object exactly.
+
+  -inputs-json        Describe the arguments the resource type accepts as a
+                      JSON array of {name, type, required, description,
+                      sensitive} objects on standard output, instead of
+                      rendering configuration. Useful for building forms or
+                      other tooling around resource creation.
+
+  -json-diags         Render diagnostics as newline-delimited JSON objects
+                      on standard error instead of human-readable text. Each
+                      object carries the diagnostic's stable TF-ADD code, so
+                      automation can match on codes rather than the English
+                      summary text, which may be reworded between releases.
+                      In human output the code appears in brackets after the
+                      summary.
+
+  -max-line-width=120 Break single-line list and object values which would
+                      make a line longer than this many columns across
+                      several lines.
+
+  -merge              Instead of failing when a resource is already in the
+                      configuration, report (as comments) any required
+                      arguments the existing block is missing according to
+                      the provider schema. Useful after a provider upgrade
+                      introduces new required arguments.
+
+  -migration          Append a commented migration runbook after the
+                      generated resource block: the "terraform state mv"
+                      command when -moved-from names the old address, the
+                      "terraform import" command to adopt an unmanaged
+                      object, and the plan check confirming the migration.
+
+  -moved-from=ADDR    The address the resource is currently managed at, for
+                      the -migration runbook's "terraform state mv" step.
+                      Requires -migration and exactly one target address.
+
+  -parallelism=N      Bound the number of workers rendering blocks
+                      concurrently during bulk generation. The default is one
+                      worker per CPU.
+
+  -checkpoint=FILE    Record the address of each block written to the given
+                      file, and skip the addresses already recorded there, so
+                      an interrupted bulk run can be re-run with the same
+                      arguments and resume where it left off. The file is
+                      removed when the run completes. Requires -out or
+                      -out-dir.
+
+  -newline=auto       Line endings for files written with -out or -out-dir:
+                      "lf", "crlf", or "auto" to match the file being
+                      appended to, falling back to the platform convention.
+
+  -no-comments        Emit the leanest possible block: no experimental-command
+                      note, no provenance marker, and no annotation comments
+                      next to placeholder values. Useful when the output is
+                      post-processed by scripts.
+
+  -comment-style=hash The comment style for the REQUIRED/OPTIONAL annotations
+                      next to placeholder values: "hash" for # comments,
+                      "slash" for // comments, or "none" to omit just the
+                      annotations while keeping the other comments.
+
+  -as-data            Generate a data block which looks up the object a
+                      managed resource tracks in state, using the provider's
+                      data source of the same type name, instead of a resource
+                      block. Requires -from-state.
+
+  -no-provenance      Omit the marker comment recording the Terraform version,
+                      date and source of values that is normally emitted above
+                      the generated block.
+
+  -position=FORMAT    Report where each generated block landed in the -out
+                      file — the file name and the start and end lines of the
+                      inserted content — as a "human" aside on standard error
+                      or as "json" objects on standard output, so editors
+                      invoking this command can scroll to or select the new
+                      block. Requires -out.
+
+  -placeholder-format=FORMAT
+                      Emit placeholder values as string tokens in the given
+                      format instead of null, with one %s verb replaced by the
+                      attribute name (e.g. '{{ %s }}'), for post-processing
+                      with template engines such as Jinja or cookiecutter.
+
+  -redact=NAME        Apply the named redaction profile from the CLI
+                      configuration: values taken from state for attributes
+                      matching the profile's patterns are written as
+                      references to generated sensitive variables instead.
+                      Requires -from-state.
+
+  -quiet              Suppress the next-step hints (import command, init and
+                      fmt/validate reminders) normally printed after the
+                      generated configuration.
+
+  -state=statefile    Path to a local state file to read values from, instead
+                      of the state of the current workspace. Useful for
+                      regenerating configuration from a backup file or a
+                      "terraform state pull" export.
+
+  -state-serial=N     Require the state snapshot being read to have exactly
+                      this serial, and fail otherwise, so values are only
+                      taken from a snapshot known to be good. Requires
+                      -from-state or -diff-config.
+
+  -values-stdin       Read attribute values as whitespace-separated key=value
+                      pairs from standard input and use them instead of
+                      placeholders, so shell scripts can fill attributes
+                      inline. The values are type-checked against the
+                      provider schema before anything is written. A value
+                      with the @expr: prefix is written verbatim as an
+                      expression (subnet_id=@expr:aws_subnet.main.id), so
+                      the generated configuration can reference other
+                      resources from the start.
+
+  -type=TYPE          The kind of block to generate: "resource" (the
+                      default), "backend" or "test". With -type=backend the
+                      positional argument is a backend type name rather than
+                      a resource address, and the output is a terraform block
+                      containing a backend configuration skeleton built from
+                      that backend's schema. With -type=test the output is a
+                      minimal test suite configuration for the resource type
+                      (provider blocks, variables, the resource under test
+                      and a starter assertion), for use with
+                      "terraform test".
+
+  -with-check         Scaffold a top-level check block after each generated
+                      resource, with a data block re-reading the object the
+                      resource manages and an assert verifying it, as a
+                      starting point for post-deployment validation.
+
+  -with-script=LANG   With a data.external address, also write an executable
+                      stub ("sh" or "python") implementing the external data
+                      source protocol next to the generated configuration,
+                      and point the program argument at it. Requires -out or
+                      -out-dir.
+
+  -provider=provider  Override the provider configuration for the resource,
+                      using the absolute provider configuration address syntax.
+
+                      This is incompatible with -from-state, because in that
+                      case Terraform will use the provider configuration already
+                      selected in the state.
+
+  -provider-alias=NAME
+                      Choose, by alias, which of the module's configurations
+                      of the -provider provider the generated block should
+                      reference, when the module declares several. The
+                      special name "default" picks the default (unaliased)
+                      configuration explicitly. Requires -provider.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AddCommand) Synopsis() string {
+	return "Generate a resource configuration template"
+}
+
+func (c *AddCommand) getResource(b backend.Enhanced, addr addrs.AbsResource) (*states.Resource, tfdiags.Diagnostics) {
+	state, diags := c.getState(b)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	resource := state.Resource(addr)
+	if resource == nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"No state for resource",
+			fmt.Sprintf("There is no resource %s in the current state, so add cannot populate values.", addr),
+		))
+		return nil, diags
+	}
+	return resource, nil
+}
+
+// getState reads and refreshes the state for the current workspace. The
+// snapshot read by the first call is reused for the rest of the run, so
+// multi-address invocations pay for one backend read rather than one per
+// address; callers only ever read the returned state.
+func (c *AddCommand) getState(b backend.Enhanced) (*states.State, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	if c.stateCache != nil {
+		return c.stateCache, nil
+	}
+	// Get the state
+	env, err := c.Workspace()
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error selecting workspace",
+			err.Error(),
+		))
+		return nil, diags
+	}
+
+	stateMgr, err := b.StateMgr(env)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error loading state",
+			fmt.Sprintf(errStateLoadingState, err),
+		))
+		return nil, diags
+	}
+
+	if err := stateMgr.RefreshState(); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error refreshing state",
+			err.Error(),
+		))
+		return nil, diags
+	}
+
+	state := stateMgr.State()
+	if state == nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"No state",
+			"There is no state found for the current workspace, so add cannot populate values.",
+		))
+		return nil, diags
+	}
+
+	// With -state-serial the user pinned the exact snapshot values may be
+	// read from, so that configuration is not silently regenerated from a
+	// state which has moved on since they inspected it.
+	if c.stateSerial != nil {
+		sm, ok := stateMgr.(statemgr.PersistentMeta)
+		if !ok {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"State serial not available",
+				"The current backend does not report state snapshot metadata, so -state-serial cannot verify which snapshot is being read. Export the snapshot with \"terraform state pull\" and read it with -state instead.",
+			))
+			return nil, diags
+		}
+		if meta := sm.StateSnapshotMeta(); meta.Serial != *c.stateSerial {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"State serial mismatch",
+				fmt.Sprintf("The state snapshot for the current workspace has serial %d, but -state-serial requires serial %d. If the state has moved on since that serial, read the matching snapshot from a backup file or a \"terraform state pull\" export with -state.", meta.Serial, *c.stateSerial),
+			))
+			return nil, diags
+		}
+	}
+
+	c.stateCache = state
+	return state, nil
+}
+
+// bulkDestination describes where bulk-generated configuration will be
+// written, for the confirmation prompt shown before generation begins: the
+// number of files under -out-dir, the single -out file, or standard output.
+// The -out-dir file count is estimated from each resource type's implied
+// provider, which is also what the split modes themselves fall back to when
+// the configuration declares nothing further.
+func bulkDestination(args *arguments.Add) string {
+	switch {
+	case args.OutDir != "":
+		files := make(map[string]struct{})
+		for _, addr := range args.Addrs {
+			pc := addrs.NewDefaultLocalProviderConfig(addr.Resource.Resource.ImpliedProvider())
+			files[views.GeneratedFileName(args.SplitBy, addr, pc)] = struct{}{}
+		}
+		if len(files) == 1 {
+			return fmt.Sprintf("1 file in %s", args.OutDir)
+		}
+		return fmt.Sprintf("%d files in %s", len(files), args.OutDir)
+	case args.OutPath != "":
+		return fmt.Sprintf("1 file (%s)", args.OutPath)
+	default:
+		return "standard output"
+	}
+}
+
+// resourceBlockBody returns the source text between the braces of the given
+// resource block, so -from-existing-resource can copy its arguments and
+// meta-arguments verbatim.
+func resourceBlockBody(rs *configs.Resource) ([]byte, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	filename := rs.DeclRange.Filename
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot read source configuration",
+			fmt.Sprintf("Error reading %s to copy the configuration of %s.%s: %s.", filename, rs.Type, rs.Name, err),
+		))
+		return nil, diags
+	}
+
+	f, parseDiags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if parseDiags.HasErrors() {
+		diags = diags.Append(parseDiags)
+		return nil, diags
+	}
+
+	for _, block := range f.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "resource" || len(block.Labels) != 2 {
+			continue
+		}
+		if block.Labels[0] != rs.Type || block.Labels[1] != rs.Name {
+			continue
+		}
+		return src[block.OpenBraceRange.End.Byte:block.CloseBraceRange.Start.Byte], diags
+	}
+
+	diags = diags.Append(tfdiags.Sourceless(
+		tfdiags.Error,
+		"Cannot copy resource configuration",
+		fmt.Sprintf("Failed to locate the resource block for %s.%s in %s. Resource blocks in JSON syntax configuration files cannot be copied.", rs.Type, rs.Name, filename),
+	))
+	return nil, diags
+}
+
+// subtreeSchema resolves a dot-separated -path option against a resource
+// type's schema, descending through nested blocks and nested object
+// attributes, and returns a reduced schema holding just the entry the final
+// path segment names. The view then renders that reduced schema on its own,
+// so the user can paste the section into an existing resource block.
+func subtreeSchema(schema *configschema.Block, path string) (*configschema.Block, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	current := schema
+	segments := strings.Split(path, ".")
+	for i, seg := range segments {
+		last := i == len(segments)-1
+
+		if blockS, ok := current.BlockTypes[seg]; ok {
+			if last {
+				return &configschema.Block{
+					BlockTypes: map[string]*configschema.NestedBlock{seg: blockS},
+				}, diags
+			}
+			current = &blockS.Block
+			continue
+		}
+
+		if attrS, ok := current.Attributes[seg]; ok {
+			if last {
+				return &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{seg: attrS},
+				}, diags
+			}
+			if attrS.NestedType == nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Invalid -path option",
+					fmt.Sprintf("Cannot descend into %q in -path=%s: it is a plain attribute of type %s, with no nested arguments.", seg, path, attrS.Type.FriendlyName()),
+				))
+				return nil, diags
+			}
+			current = &configschema.Block{Attributes: attrS.NestedType.Attributes}
+			continue
+		}
+
+		// The segment may just be misspelled, so suggest the closest name
+		// declared at this level of the schema.
+		names := make([]string, 0, len(current.Attributes)+len(current.BlockTypes))
+		for name := range current.Attributes {
+			names = append(names, name)
+		}
+		for name := range current.BlockTypes {
+			names = append(names, name)
+		}
+		detail := fmt.Sprintf("The schema declares no nested block or attribute named %q at %q in -path=%s.", seg, strings.Join(segments[:i+1], "."), path)
+		if suggestion := didyoumean.NameSuggestion(seg, names); suggestion != "" {
+			detail = fmt.Sprintf("%s Did you mean %q?", detail, suggestion)
+		}
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid -path option",
+			detail,
+		))
+		return nil, diags
+	}
+
+	// Unreachable: every segment of a non-empty path either returns above
+	// or fails to resolve, and an unset -path never reaches this function.
+	return current, diags
+}
+
+// decodeInstanceStateValue decodes the state of one resource instance
+// against the given schema, reporting missing state and undecodable state as
+// diagnostics. A state object recorded at an older schema version is first
+// upgraded through the provider's own upgrade logic, so state written by an
+// earlier provider release still decodes correctly.
+func (c *AddCommand) decodeInstanceStateValue(resource *states.Resource, addr addrs.AbsResourceInstance, provider addrs.Provider, schema *configschema.Block, schemaVersion uint64) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	rs := addr.Resource.Resource
+
+	ri := resource.Instance(addr.Resource.Key)
+	if ri == nil || ri.Current == nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"No state for resource",
+			fmt.Sprintf("There is no state found for the resource %s, so add cannot populate values.", rs.String()),
+		))
+		return cty.NilVal, diags
+	}
+
+	src := ri.Current
+	if src.SchemaVersion != schemaVersion {
+		upgraded, moreDiags := c.upgradeInstanceState(addr, provider, src, schema, schemaVersion)
+		diags = diags.Append(moreDiags)
+		if moreDiags.HasErrors() {
+			return cty.NilVal, diags
+		}
+		src = upgraded
+	}
+
+	rio, err := src.Decode(schema.ImpliedType())
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error decoding state",
+			fmt.Sprintf("Error decoding state for resource %s: %s", rs.String(), err.Error()),
+		))
+		return cty.NilVal, diags
+	}
+
+	return rio.Value, diags
+}
+
+// upgradeInstanceState brings a state object recorded at an older schema
+// version up to the provider's current version by launching the provider
+// plugin and running its UpgradeResourceState logic, which is also the only
+// way to decode legacy flatmap state. State recorded at a newer version than
+// the provider's schema cannot be downgraded and is an error.
+func (c *AddCommand) upgradeInstanceState(addr addrs.AbsResourceInstance, provider addrs.Provider, src *states.ResourceInstanceObjectSrc, schema *configschema.Block, schemaVersion uint64) (*states.ResourceInstanceObjectSrc, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	rs := addr.Resource.Resource
+
+	if src.SchemaVersion > schemaVersion {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Resource instance managed by newer provider version",
+			fmt.Sprintf("The state of %s was created by a newer version of provider %s than is currently selected (state schema version %d, provider schema version %d). Upgrade the provider to work with this state.", addr, provider.ForDisplay(), src.SchemaVersion, schemaVersion),
+		))
+		return nil, diags
+	}
+
+	var factories map[addrs.Provider]providers.Factory
+	if c.testingOverrides != nil {
+		factories = c.testingOverrides.Providers
+	} else {
+		var err error
+		factories, err = c.providerFactories()
+		if err != nil {
+			// The factories map is as complete as it can be even on error,
+			// and we only need the one provider from it, so we report the
+			// problem only if that provider turns out to be missing below.
+			log.Printf("[WARN] Failed to determine selected providers: %s", err)
+		}
+	}
+	factory, ok := factories[provider]
+	if !ok {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Provider plugin not available",
+			fmt.Sprintf("The state of %s was recorded at schema version %d, but the current schema of provider %s is version %d and the provider plugin is not available to upgrade the state. Run \"terraform init\" to install the provider, then retry.", addr, src.SchemaVersion, provider.ForDisplay(), schemaVersion),
+		))
+		return nil, diags
+	}
+
+	inst, err := factory()
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to start provider plugin",
+			fmt.Sprintf("Could not start provider %s to upgrade the state of %s: %s.", provider.ForDisplay(), addr, err),
+		))
+		return nil, diags
+	}
+	defer inst.Close()
+
+	log.Printf("[TRACE] terraform add: upgrading state for %s from schema version %d to %d using provider %s", addr, src.SchemaVersion, schemaVersion, provider)
+	req := providers.UpgradeResourceStateRequest{
+		TypeName: rs.Type,
+		Version:  int64(src.SchemaVersion),
+	}
+	// Legacy state written before Terraform v0.12 carries a flatmap encoding
+	// rather than JSON, and only the provider knows enough to decode it.
+	if len(src.AttrsJSON) > 0 {
+		req.RawStateJSON = src.AttrsJSON
+	} else {
+		req.RawStateFlatmap = src.AttrsFlat
+	}
+
+	resp := inst.UpgradeResourceState(req)
+	diags = diags.Append(resp.Diagnostics)
+	if resp.Diagnostics.HasErrors() {
+		return nil, diags
+	}
+
+	// Over RPC the marshaling already enforces conformance, but check here
+	// anyway for robustness with in-process providers.
+	if errs := resp.UpgradedState.Type().TestConformance(schema.ImpliedType()); len(errs) > 0 {
+		for _, err := range errs {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid resource state upgrade",
+				fmt.Sprintf("The %s provider upgraded the state for %s from a previous version, but produced an invalid result: %s.", provider.ForDisplay(), addr, tfdiags.FormatError(err)),
+			))
+		}
+		return nil, diags
+	}
+
+	upgraded, err := src.CompleteUpgrade(resp.UpgradedState, schema.ImpliedType(), schemaVersion)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to encode result of resource state upgrade",
+			fmt.Sprintf("Failed to encode state for %s after resource schema upgrade: %s.", addr, tfdiags.FormatError(err)),
+		))
+		return nil, diags
+	}
+	return upgraded, diags
+}
+
+// cloneResourceBlock copies the given resource block in the hclwrite token
+// stream of its source file, renames it, and strips the attributes the
+// configuration cannot set (computed-only, per the provider schema). Working
+// on the token stream rather than re-rendering from schema preserves the
+// author's formatting, comments and expressions intact.
+func cloneResourceBlock(rs *configs.Resource, newName string, schema *configschema.Block) ([]byte, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	filename := rs.DeclRange.Filename
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot read source configuration",
+			fmt.Sprintf("Error reading %s to clone the configuration of %s.%s: %s.", filename, rs.Type, rs.Name, err),
+		))
+		return nil, diags
+	}
+
+	f, parseDiags := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if parseDiags.HasErrors() {
+		diags = diags.Append(parseDiags)
+		return nil, diags
+	}
+
+	block := f.Body().FirstMatchingBlock("resource", []string{rs.Type, rs.Name})
+	if block == nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot clone resource configuration",
+			fmt.Sprintf("Failed to locate the resource block for %s.%s in %s. Resource blocks in JSON syntax configuration files cannot be cloned.", rs.Type, rs.Name, filename),
+		))
+		return nil, diags
+	}
+
+	block.SetLabels([]string{rs.Type, newName})
+	for name, attrS := range schema.Attributes {
+		if attrS.Computed && !attrS.Optional {
+			block.Body().RemoveAttribute(name)
+		}
+	}
+
+	out := hclwrite.NewEmptyFile()
+	out.Body().AppendBlock(block)
+	return out.Bytes(), diags
+}
+
+// moduleOutputValues indexes the output values of the root module's direct
+// child module instances by the expression which references them, such as
+// "module.network.subnet_id". Null and sensitive outputs are skipped, since
+// they either carry no information or must not be written into
+// configuration as literals would be.
+func moduleOutputValues(state *states.State) map[string]cty.Value {
+	outputs := make(map[string]cty.Value)
+	for _, ms := range state.Modules {
+		if len(ms.Addr) != 1 {
+			continue
+		}
+		for name, os := range ms.OutputValues {
+			if os.Value == cty.NilVal || os.Value.IsNull() {
+				continue
+			}
+			if os.Sensitive || os.Value.IsMarked() {
+				continue
+			}
+			outputs[fmt.Sprintf("%s.%s", ms.Addr.String(), name)] = os.Value
+		}
+	}
+	return outputs
+}
+
+// orderForGeneration sorts resource instance addresses into dependency
+// order, using the dependencies recorded in state, so that generated
+// configuration reads naturally from foundational resources to their
+// dependents. Unrelated resources keep a stable alphabetical order, and a
+// dependency cycle (which state should never contain) degrades to that
+// same order for the resources involved.
+func orderForGeneration(insts []addrs.AbsResourceInstance, deps func(addrs.AbsResourceInstance) []addrs.ConfigResource) []addrs.AbsResourceInstance {
+	sorted := make([]addrs.AbsResourceInstance, len(insts))
+	copy(sorted, insts)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].String() < sorted[j].String() })
+
+	// Index the requested addresses by their config resource, which is the
+	// granularity at which state records dependencies.
+	byConfig := make(map[string][]int)
+	for i, inst := range sorted {
+		key := inst.ContainingResource().Config().String()
+		byConfig[key] = append(byConfig[key], i)
+	}
+
+	// Build edges from each requested resource to the requested resources
+	// which depend on it.
+	dependents := make(map[int][]int)
+	indegree := make([]int, len(sorted))
+	for i, inst := range sorted {
+		for _, dep := range deps(inst) {
+			for _, j := range byConfig[dep.String()] {
+				if j == i {
+					continue
+				}
+				dependents[j] = append(dependents[j], i)
+				indegree[i]++
+			}
+		}
+	}
+
+	queue := make([]int, 0, len(sorted))
+	for i := range sorted {
+		if indegree[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	ret := make([]addrs.AbsResourceInstance, 0, len(sorted))
+	emitted := make([]bool, len(sorted))
+	for len(queue) > 0 {
+		// Always emit the alphabetically-first ready resource, so the order
+		// is deterministic regardless of how the edges were discovered.
+		sort.Ints(queue)
+		i := queue[0]
+		queue = queue[1:]
+		emitted[i] = true
+		ret = append(ret, sorted[i])
+		for _, j := range dependents[i] {
+			indegree[j]--
+			if indegree[j] == 0 {
+				queue = append(queue, j)
+			}
+		}
+	}
+	for i := range sorted {
+		if !emitted[i] {
+			ret = append(ret, sorted[i])
+		}
+	}
+	return ret
+}
+
+// missingRequiredAttributes returns the names of the attributes the provider
+// schema requires which are not set in the given configuration body, in a
+// stable alphabetical order.
+func missingRequiredAttributes(schema *configschema.Block, body hcl.Body) []string {
+	var hclSchema hcl.BodySchema
+	for name := range schema.Attributes {
+		hclSchema.Attributes = append(hclSchema.Attributes, hcl.AttributeSchema{Name: name})
+	}
+	// The body will also contain nested blocks and meta-arguments we aren't
+	// asking about; PartialContent tolerates those.
+	content, _, _ := body.PartialContent(&hclSchema)
+
+	var missing []string
+	for name, attrS := range schema.Attributes {
+		if !attrS.Required {
+			continue
+		}
+		if _, ok := content.Attributes[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// unsetOptionalAttributes returns the names of the optional attributes the
+// provider schema declares which are not set in the given configuration
+// body, in a stable alphabetical order. After a provider upgrade these are
+// typically the attributes the new provider version added.
+func unsetOptionalAttributes(schema *configschema.Block, body hcl.Body) []string {
+	var hclSchema hcl.BodySchema
+	for name, attrS := range schema.Attributes {
+		hclSchema.Attributes = append(hclSchema.Attributes, hcl.AttributeSchema{Name: name})
+		// Legacy SDK attrs-as-blocks attributes may appear in the
+		// configuration in block syntax instead.
+		ty := attrS.Type
+		if attrS.NestedType == nil && (ty.IsListType() || ty.IsSetType()) && ty.ElementType().IsObjectType() {
+			hclSchema.Blocks = append(hclSchema.Blocks, hcl.BlockHeaderSchema{Type: name})
+		}
+	}
+	// The body will also contain nested blocks and meta-arguments we aren't
+	// asking about; PartialContent tolerates those.
+	content, _, _ := body.PartialContent(&hclSchema)
+	setAsBlock := make(map[string]bool)
+	for _, block := range content.Blocks {
+		setAsBlock[block.Type] = true
+	}
+
+	var unset []string
+	for name, attrS := range schema.Attributes {
+		if !attrS.Optional {
+			continue
+		}
+		if _, ok := content.Attributes[name]; ok {
+			continue
+		}
+		if setAsBlock[name] {
+			continue
+		}
+		unset = append(unset, name)
+	}
+	sort.Strings(unset)
+	return unset
+}
+
+// auditConfig compares every resource block in the configuration, across the
+// whole module tree, against its provider schema, producing one report row
+// per resource sorted by address.
+func auditConfig(config *configs.Config, schemas *terraform.Schemas) []views.AuditResult {
+	var results []views.AuditResult
+	config.DeepEach(func(c *configs.Config) {
+		resources := make([]*configs.Resource, 0, len(c.Module.ManagedResources)+len(c.Module.DataResources))
+		for _, rc := range c.Module.ManagedResources {
+			resources = append(resources, rc)
+		}
+		for _, rc := range c.Module.DataResources {
+			resources = append(resources, rc)
+		}
+		for _, rc := range resources {
+			provider := c.Module.ProviderForLocalConfig(rc.ProviderConfigAddr())
+			schema, _ := schemas.ResourceTypeConfig(provider, rc.Mode, rc.Type)
+			if schema == nil {
+				// A resource whose schema is unavailable cannot be compared;
+				// the rest of the report is still useful, so it is skipped
+				// rather than failing the whole run.
+				continue
+			}
+			results = append(results, views.AuditResult{
+				Addr:            rc.Addr().InModule(c.Path),
+				MissingRequired: missingRequiredAttributes(schema, rc.Config),
+				UnsetOptional:   unsetOptionalAttributes(schema, rc.Config),
+			})
+		}
+	})
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Addr.String() < results[j].Addr.String()
+	})
+	return results
+}
+
+// driftedAttributes returns the names of the attributes which have a
+// non-null value in state but are not set in the given resource block,
+// sorted for stable output. Attributes the configuration cannot set, and
+// optional+computed attributes whose values were likely decided by the
+// provider, are not considered drift; the latter can be included with
+// -include-optional-computed.
+func driftedAttributes(schema *configschema.Block, body hcl.Body, stateVal cty.Value, includeOptionalComputed bool) []string {
+	var hclSchema hcl.BodySchema
+	for name, attrS := range schema.Attributes {
+		hclSchema.Attributes = append(hclSchema.Attributes, hcl.AttributeSchema{Name: name})
+		// Legacy SDK attrs-as-blocks attributes may appear in the
+		// configuration in block syntax instead.
+		ty := attrS.Type
+		if attrS.NestedType == nil && (ty.IsListType() || ty.IsSetType()) && ty.ElementType().IsObjectType() {
+			hclSchema.Blocks = append(hclSchema.Blocks, hcl.BlockHeaderSchema{Type: name})
+		}
+	}
+	// The body will also contain nested blocks and meta-arguments we aren't
+	// asking about; PartialContent tolerates those.
+	content, _, _ := body.PartialContent(&hclSchema)
+	setAsBlock := make(map[string]bool)
+	for _, block := range content.Blocks {
+		setAsBlock[block.Type] = true
+	}
+
+	var drifted []string
+	for name, attrS := range schema.Attributes {
+		if attrS.Computed && !attrS.Optional {
+			continue
+		}
+		if attrS.Optional && attrS.Computed && !includeOptionalComputed {
+			continue
+		}
+		if _, ok := content.Attributes[name]; ok {
+			continue
+		}
+		if setAsBlock[name] {
+			continue
+		}
+		if !stateVal.Type().HasAttribute(name) {
+			continue
+		}
+		if val := stateVal.GetAttr(name); val.IsNull() {
+			continue
+		}
+		drifted = append(drifted, name)
+	}
+	sort.Strings(drifted)
+	return drifted
+}
+
+// parseStdinValues reads attribute values for -values-stdin: whitespace-
+// separated key=value pairs, so shell scripts can fill attributes inline
+// ("echo 'ami=ami-123 value=foo' | terraform add -values-stdin ...") without
+// writing a file first.
+func parseStdinValues(r io.Reader) (map[string]string, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read values from standard input",
+			fmt.Sprintf("The -values-stdin option reads attribute values from standard input, which failed: %s.", err),
+		))
+		return nil, diags
+	}
+
+	values := make(map[string]string)
+	for _, pair := range strings.Fields(string(src)) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid value on standard input",
+				fmt.Sprintf("The -values-stdin option expects whitespace-separated key=value pairs on standard input, but %q is not one.", pair),
+			))
+			return nil, diags
+		}
+		values[parts[0]] = parts[1]
+	}
+	return values, diags
+}
+
+// checkStdinValues type-checks the values read for -values-stdin against the
+// schemas of the resources being generated, before anything is rendered:
+// every name must be an attribute of at least one of them, and its value must
+// convert to the attribute's type wherever the name appears.
+func checkStdinValues(values map[string]string, toGenerate []views.ResourceToGenerate) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// A value with the @expr: marker is a raw expression to emit
+		// verbatim, so instead of converting it to the attribute's type we
+		// only check that it parses as an expression.
+		if expr := strings.TrimPrefix(values[name], views.ExprValuePrefix); expr != values[name] {
+			if _, exprDiags := hclsyntax.ParseExpression([]byte(expr), "", hcl.InitialPos); exprDiags.HasErrors() {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Invalid attribute expression",
+					fmt.Sprintf("The expression given for %q on standard input is not valid HCL: %s.", name, exprDiags.Error()),
+				))
+				continue
+			}
+		}
+
+		found := false
+		for _, r := range toGenerate {
+			attrS, ok := r.Schema.Attributes[name]
+			if !ok {
+				continue
+			}
+			found = true
+			if strings.HasPrefix(values[name], views.ExprValuePrefix) {
+				continue
+			}
+			if _, err := convert.Convert(cty.StringVal(values[name]), attrS.Type); err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Invalid attribute value",
+					fmt.Sprintf("The value given for %q on standard input cannot be used for %s: %s.", name, r.Addr, err),
+				))
+			}
+		}
+		if !found {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Unknown attribute",
+				fmt.Sprintf("The value given for %q on standard input does not match any attribute of the resource types being generated.", name),
+			))
+		}
+	}
+	return diags
+}
+
+// dataSourceLookupValues chooses the arguments for a generated data block
+// from a resource's state: the first well-known identifying attribute the
+// data source accepts (id, name, arn, self_link) which has a value in state,
+// or failing that every required argument of the data source which does.
+func dataSourceLookupValues(schema *configschema.Block, stateVal cty.Value) map[string]cty.Value {
+	lookup := make(map[string]cty.Value)
+	for _, name := range []string{"id", "name", "arn", "self_link"} {
+		attrS, ok := schema.Attributes[name]
+		if !ok || (!attrS.Required && !attrS.Optional) {
+			continue
+		}
+		if val, ok := stateAttrValue(stateVal, name); ok {
+			lookup[name] = val
+			return lookup
+		}
+	}
+	for name, attrS := range schema.Attributes {
+		if !attrS.Required {
+			continue
+		}
+		if val, ok := stateAttrValue(stateVal, name); ok {
+			lookup[name] = val
+		}
+	}
+	return lookup
+}
+
+// stateAttrValue returns the unmarked value of the named attribute from a
+// decoded state object, reporting false when the attribute is absent or null.
+func stateAttrValue(stateVal cty.Value, name string) (cty.Value, bool) {
+	if !stateVal.Type().HasAttribute(name) {
+		return cty.NilVal, false
+	}
+	val := stateVal.GetAttr(name)
+	val, _ = val.Unmark()
+	if val.IsNull() {
+		return cty.NilVal, false
+	}
+	return val, true
+}
+
+// workspaceNameExample inspects the module's existing resources for "name"
+// arguments which interpolate terraform.workspace, and returns an example
+// name expression following the same convention for a new resource named
+// newName: `"${terraform.workspace}-web"` when existing names use the
+// workspace as a prefix, or `"web-${terraform.workspace}"` as a suffix. Only
+// resources in native syntax files can be inspected this way.
+func workspaceNameExample(module *configs.Module, newName string) (string, bool) {
+	// Map iteration order is undefined, so walk the resources in a stable
+	// order in case several of them use different conventions.
+	rsNames := make([]string, 0, len(module.ManagedResources))
+	for name := range module.ManagedResources {
+		rsNames = append(rsNames, name)
+	}
+	sort.Strings(rsNames)
+
+	for _, rsName := range rsNames {
+		body, ok := module.ManagedResources[rsName].Config.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+		attr, ok := body.Attributes["name"]
+		if !ok {
+			continue
+		}
+		te, ok := attr.Expr.(*hclsyntax.TemplateExpr)
+		if !ok {
+			continue
+		}
+		for i, part := range te.Parts {
+			if !isWorkspaceTraversal(part) {
+				continue
+			}
+			switch {
+			case i == 0 && len(te.Parts) > 1:
+				sep := templateSeparator(te.Parts[1], true)
+				return fmt.Sprintf("\"${terraform.workspace}%s%s\"", sep, newName), true
+			case i == len(te.Parts)-1 && i > 0:
+				sep := templateSeparator(te.Parts[i-1], false)
+				return fmt.Sprintf("\"%s%s${terraform.workspace}\"", newName, sep), true
+			}
+		}
+	}
+	return "", false
+}
+
+// isWorkspaceTraversal reports whether the expression is a reference to
+// terraform.workspace.
+func isWorkspaceTraversal(expr hclsyntax.Expression) bool {
+	st, ok := expr.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(st.Traversal) < 2 || st.Traversal.RootName() != "terraform" {
+		return false
+	}
+	attr, ok := st.Traversal[1].(hcl.TraverseAttr)
+	return ok && attr.Name == "workspace"
+}
+
+// templateSeparator returns the character joining the workspace reference to
+// the rest of an existing name, taken from the adjacent literal part of its
+// template, defaulting to "-".
+func templateSeparator(expr hclsyntax.Expression, leading bool) string {
+	lit, ok := expr.(*hclsyntax.LiteralValueExpr)
+	if !ok || lit.Val.Type() != cty.String || lit.Val.IsNull() {
+		return "-"
+	}
+	s := lit.Val.AsString()
+	if s == "" {
+		return "-"
+	}
+	c := s[0]
+	if !leading {
+		c = s[len(s)-1]
+	}
+	switch c {
+	case '-', '_', '.', '/':
+		return string(c)
+	}
+	return "-"
+}
+
+// warningCount returns the number of warning diagnostics collected during a
+// run, for the summary the view prints after generation.
+func warningCount(diags tfdiags.Diagnostics) int {
+	n := 0
+	for _, diag := range diags {
+		if diag.Severity() == tfdiags.Warning {
+			n++
+		}
+	}
+	return n
+}
+
+// declaredProviderLocalNames returns the local names the module declares in
+// its required_providers block, sorted for stable output.
+func declaredProviderLocalNames(module *configs.Module) []string {
+	names := make([]string, 0, len(module.ProviderLocalNames))
+	for _, name := range module.ProviderLocalNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configurationAliasFor returns the first alias the module declares for the
+// given provider local name with configuration_aliases in its
+// required_providers block, or "" when the module declares none. A module
+// with its own default provider block for that name keeps the default
+// configuration for its resources, so "" is returned for it too.
+func configurationAliasFor(module *configs.Module, localName string) string {
+	if module.ProviderRequirements == nil {
+		return ""
+	}
+	rp, ok := module.ProviderRequirements.RequiredProviders[localName]
+	if !ok {
+		return ""
+	}
+	if _, ok := module.ProviderConfigs[localName]; ok {
+		return ""
+	}
+	for _, alias := range rp.Aliases {
+		if alias.Alias != "" {
+			return alias.Alias
+		}
+	}
+	return ""
+}
+
+// requiredProvidersSnippet returns the required_providers declaration a
+// module needs for the given provider, exactly as it would be written in
+// configuration, for embedding in diagnostics. When the dependency lock file
+// records an installed version it is pinned as a floor, matching what
+// ProviderScaffold generates.
+func requiredProvidersSnippet(localName string, provider addrs.Provider, version string) string {
+	var buf strings.Builder
+	buf.WriteString("terraform {\n  required_providers {\n")
+	fmt.Fprintf(&buf, "    %s = {\n", localName)
+	fmt.Fprintf(&buf, "      source = %q\n", provider.ForDisplay())
+	if version != "" {
+		fmt.Fprintf(&buf, "      version = %q\n", ">= "+version)
+	}
+	buf.WriteString("    }\n  }\n}")
+	return buf.String()
+}
+
+// passedProviderRef looks up the caller-side provider configuration a child
+// module receives for the given local provider config through the
+// providers = {} map on its module call, as a string like "aws.usw2". It
+// returns "" when the module is not declared, its call passes nothing for
+// this provider, or the module inherits the default configuration
+// implicitly.
+func passedProviderRef(idx *configResourceIndex, path addrs.Module, pc addrs.LocalProviderConfig) string {
+	if len(path) == 0 {
+		return ""
+	}
+	parent := idx.module(path[:len(path)-1])
+	if parent == nil {
+		return ""
+	}
+	call, ok := parent.ModuleCalls[path[len(path)-1]]
+	if !ok {
+		return ""
+	}
+	for _, passed := range call.Providers {
+		if passed.InChild.Name == pc.LocalName && passed.InChild.Alias == pc.Alias {
+			return passed.InParent.String()
+		}
+	}
+	return ""
+}
+
+// resourceTypeNames returns the type names a provider schema declares for
+// the given resource mode, for use in "did you mean" suggestions.
+func resourceTypeNames(ps *terraform.ProviderSchema, mode addrs.ResourceMode) []string {
+	if ps == nil {
+		return nil
+	}
+	var types map[string]*configschema.Block
+	switch mode {
+	case addrs.ManagedResourceMode:
+		types = ps.ResourceTypes
+	case addrs.DataResourceMode:
+		types = ps.DataSources
+	}
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// installProgressUi is the Ui given to the init command run by -auto-init.
+// Init's provider installation event hooks print download and verification
+// progress through their Ui, so forwarding every line to the view's
+// InstallProgress method surfaces that progress the way the rest of this
+// command's output is surfaced: on the progress stream in human output, or
+// as newline-delimited JSON objects with -json-diags.
+type installProgressUi struct {
+	view views.Add
+}
+
+func (u *installProgressUi) Output(message string) { u.view.InstallProgress(message) }
+func (u *installProgressUi) Info(message string)   { u.view.InstallProgress(message) }
+func (u *installProgressUi) Warn(message string)   { u.view.InstallProgress(message) }
+func (u *installProgressUi) Error(message string)  { u.view.InstallProgress(message) }
+
+// The automatic init always runs with -input=false, so it never asks.
+func (u *installProgressUi) Ask(query string) (string, error) {
+	return "", fmt.Errorf("cannot ask for input during automatic initialization")
+}
+
+func (u *installProgressUi) AskSecret(query string) (string, error) {
+	return "", fmt.Errorf("cannot ask for input during automatic initialization")
+}
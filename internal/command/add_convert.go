@@ -0,0 +1,558 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/command/arguments"
+	"github.com/hashicorp/terraform/internal/command/views"
+	"github.com/hashicorp/terraform/internal/configs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/terraform"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// runConvert implements the -hcl2json and -json2hcl modes: each requested
+// address must already be declared in the configuration, and its block is
+// printed in the other syntax rather than anything new being generated.
+func (c *AddCommand) runConvert(args *arguments.Add, view views.Add, config *configs.Config, schemas *terraform.Schemas, diags tfdiags.Diagnostics) int {
+	idx := indexConfigResources(config)
+
+	for _, addr := range args.Addrs {
+		rs := idx.managedResource(addr)
+		if rs == nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Resource not in configuration",
+				fmt.Sprintf("The -hcl2json and -json2hcl options convert an existing resource block, but %s is not declared in this configuration.", addr),
+			))
+			view.Diagnostics(diags)
+			return 1
+		}
+
+		// The schema decides which body entries convert as nested blocks.
+		// Conversion still works when the provider has no schema for the
+		// type, falling back to treating everything but the well-known
+		// meta-arguments as attributes.
+		provider := addrs.NewDefaultProvider(addr.Resource.Resource.ImpliedProvider())
+		if args.Provider != nil {
+			provider = args.Provider.Provider
+		} else if module := idx.moduleForInstance(addr.Module); module != nil {
+			provider = module.ImpliedProviderForUnqualifiedType(addr.Resource.Resource.ImpliedProvider())
+		}
+		schema, _ := schemas.ResourceTypeConfig(provider, addrs.ManagedResourceMode, rs.Type)
+
+		var out []byte
+		var moreDiags tfdiags.Diagnostics
+		if args.HCL2JSON {
+			out, moreDiags = convertResourceToJSON(rs, schema)
+		} else {
+			out, moreDiags = convertResourceToHCL(rs, schema)
+		}
+		diags = diags.Append(moreDiags)
+		if moreDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+
+		if err := view.Converted(out); err != nil {
+			diags = diags.Append(writeFailureDiag(err))
+			view.Diagnostics(diags)
+			return addExitWriteFailure
+		}
+	}
+
+	view.Diagnostics(diags)
+	return 0
+}
+
+// The -hcl2json and -json2hcl options convert a resource block already in
+// the configuration between the native and JSON configuration syntaxes,
+// using the provider schema to decide which body entries are nested blocks
+// and which are attributes, so the result round-trips through Terraform's
+// own understanding of the block rather than a purely textual mapping.
+//
+// Attribute expressions which are not literal values are carried over in the
+// JSON template form ("${...}"), which is how the JSON syntax spells an
+// arbitrary expression; literal values become plain JSON values. The
+// "provider" and "depends_on" meta-arguments keep the plain-string reference
+// form the JSON syntax defines for them.
+
+// convertResourceToJSON renders the block of the given resource, which must
+// be declared in a native syntax file, as a JSON syntax configuration
+// document containing just that resource.
+func convertResourceToJSON(rs *configs.Resource, schema *configschema.Block) ([]byte, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	filename := rs.DeclRange.Filename
+	if strings.HasSuffix(filename, ".tf.json") {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Resource is already in JSON syntax",
+			fmt.Sprintf("The resource %s.%s is declared in %s, which is already a JSON syntax file. Use -json2hcl to convert it to the native syntax instead.", rs.Type, rs.Name, filename),
+		))
+		return nil, diags
+	}
+
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot read source configuration",
+			fmt.Sprintf("Error reading %s to convert the configuration of %s.%s: %s.", filename, rs.Type, rs.Name, err),
+		))
+		return nil, diags
+	}
+
+	f, parseDiags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if parseDiags.HasErrors() {
+		diags = diags.Append(parseDiags)
+		return nil, diags
+	}
+
+	var block *hclsyntax.Block
+	for _, b := range f.Body.(*hclsyntax.Body).Blocks {
+		if b.Type == "resource" && len(b.Labels) == 2 && b.Labels[0] == rs.Type && b.Labels[1] == rs.Name {
+			block = b
+			break
+		}
+	}
+	if block == nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot convert resource configuration",
+			fmt.Sprintf("Failed to locate the resource block for %s.%s in %s.", rs.Type, rs.Name, filename),
+		))
+		return nil, diags
+	}
+
+	body, err := jsonBodyFromHCL(block.Body, schema, src)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot convert resource configuration",
+			fmt.Sprintf("The block for %s.%s in %s cannot be represented in JSON syntax: %s.", rs.Type, rs.Name, filename, err),
+		))
+		return nil, diags
+	}
+
+	doc := map[string]interface{}{
+		"resource": map[string]interface{}{
+			rs.Type: map[string]interface{}{
+				rs.Name: body,
+			},
+		},
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot convert resource configuration",
+			err.Error(),
+		))
+		return nil, diags
+	}
+	return append(out, '\n'), diags
+}
+
+// jsonBodyFromHCL converts a native syntax block body to the equivalent JSON
+// syntax object. Nested blocks group under their type name, as an array for
+// the repeating nesting modes and a single object otherwise, with map-nested
+// block labels becoming object keys.
+func jsonBodyFromHCL(body *hclsyntax.Body, schema *configschema.Block, src []byte) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+
+	for name, attr := range body.Attributes {
+		switch name {
+		case "provider":
+			// The JSON syntax spells the provider meta-argument as a plain
+			// string reference, not a template.
+			out[name] = strings.TrimSpace(string(exprSource(attr.Expr, src)))
+			continue
+		case "depends_on":
+			// Likewise an array of plain string references.
+			if tup, ok := attr.Expr.(*hclsyntax.TupleConsExpr); ok {
+				refs := make([]interface{}, len(tup.Exprs))
+				for i, expr := range tup.Exprs {
+					refs[i] = strings.TrimSpace(string(exprSource(expr, src)))
+				}
+				out[name] = refs
+				continue
+			}
+		}
+		val, err := jsonValueFromExpr(attr.Expr, src)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q: %s", name, err)
+		}
+		out[name] = val
+	}
+
+	for _, block := range body.Blocks {
+		var blockSchema *configschema.Block
+		nesting := configschema.NestingList
+		if schema != nil {
+			if blockS, ok := schema.BlockTypes[block.Type]; ok {
+				blockSchema = &blockS.Block
+				nesting = blockS.Nesting
+			}
+		}
+		converted, err := jsonBodyFromHCL(block.Body, blockSchema, src)
+		if err != nil {
+			return nil, fmt.Errorf("block %q: %s", block.Type, err)
+		}
+
+		switch {
+		case len(block.Labels) == 1:
+			// A labeled block — map-nested per the schema — becomes an
+			// object keyed by the label.
+			group, _ := out[block.Type].(map[string]interface{})
+			if group == nil {
+				group = make(map[string]interface{})
+				out[block.Type] = group
+			}
+			group[block.Labels[0]] = converted
+		case nesting == configschema.NestingSingle || nesting == configschema.NestingGroup:
+			out[block.Type] = converted
+		default:
+			// Repeating blocks, and block types the schema doesn't describe
+			// (such as lifecycle or provisioner), group into an array, which
+			// the JSON syntax accepts for single occurrences too.
+			group, _ := out[block.Type].([]interface{})
+			out[block.Type] = append(group, converted)
+		}
+	}
+
+	return out, nil
+}
+
+// jsonValueFromExpr converts one attribute expression to its JSON syntax
+// form: a plain JSON value when the expression is a literal, recursing into
+// list and object constructors so that partially-literal collections keep
+// their structure, and the "${...}" template form for anything else.
+func jsonValueFromExpr(expr hclsyntax.Expression, src []byte) (interface{}, error) {
+	switch e := expr.(type) {
+	case *hclsyntax.TupleConsExpr:
+		vals := make([]interface{}, len(e.Exprs))
+		for i, elem := range e.Exprs {
+			val, err := jsonValueFromExpr(elem, src)
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = val
+		}
+		return vals, nil
+	case *hclsyntax.ObjectConsExpr:
+		vals := make(map[string]interface{}, len(e.Items))
+		for _, item := range e.Items {
+			key := hcl.ExprAsKeyword(item.KeyExpr)
+			if key == "" {
+				keyVal, keyDiags := item.KeyExpr.Value(nil)
+				if keyDiags.HasErrors() || keyVal.IsNull() {
+					return nil, fmt.Errorf("object key is not a static value")
+				}
+				key = keyVal.AsString()
+			}
+			val, err := jsonValueFromExpr(item.ValueExpr, src)
+			if err != nil {
+				return nil, err
+			}
+			vals[key] = val
+		}
+		return vals, nil
+	}
+
+	if val, valDiags := expr.Value(nil); !valDiags.HasErrors() && val.IsWhollyKnown() {
+		raw, err := ctyjson.Marshal(val, val.Type())
+		if err == nil {
+			return json.RawMessage(raw), nil
+		}
+	}
+
+	// Anything else — references, function calls, arithmetic — carries over
+	// as a template expression. The JSON syntax cannot contain raw newlines
+	// inside a string, so a multi-line expression collapses to one line,
+	// which HCL reads identically outside of heredocs.
+	exprSrc := string(exprSource(expr, src))
+	if strings.Contains(exprSrc, "<<") {
+		return nil, fmt.Errorf("heredoc expressions cannot be represented in JSON syntax")
+	}
+	if strings.ContainsAny(exprSrc, "\n\r") {
+		exprSrc = strings.Join(strings.Fields(exprSrc), " ")
+	}
+	return "${" + exprSrc + "}", nil
+}
+
+// exprSource returns the source bytes of an expression.
+func exprSource(expr hclsyntax.Expression, src []byte) []byte {
+	rng := expr.Range()
+	return src[rng.Start.Byte:rng.End.Byte]
+}
+
+// convertResourceToHCL renders the block of the given resource, which must
+// be declared in a JSON syntax file, in the native configuration syntax.
+func convertResourceToHCL(rs *configs.Resource, schema *configschema.Block) ([]byte, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	filename := rs.DeclRange.Filename
+	if !strings.HasSuffix(filename, ".tf.json") {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Resource is already in native syntax",
+			fmt.Sprintf("The resource %s.%s is declared in %s, which is already a native syntax file. Use -hcl2json to convert it to JSON syntax instead.", rs.Type, rs.Name, filename),
+		))
+		return nil, diags
+	}
+
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot read source configuration",
+			fmt.Sprintf("Error reading %s to convert the configuration of %s.%s: %s.", filename, rs.Type, rs.Name, err),
+		))
+		return nil, diags
+	}
+
+	var doc struct {
+		Resource map[string]map[string]json.RawMessage `json:"resource"`
+	}
+	if err := json.Unmarshal(src, &doc); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot convert resource configuration",
+			fmt.Sprintf("The file %s is not a valid JSON syntax configuration file: %s.", filename, err),
+		))
+		return nil, diags
+	}
+
+	raw, ok := doc.Resource[rs.Type][rs.Name]
+	if !ok {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot convert resource configuration",
+			fmt.Sprintf("Failed to locate the resource object for %s.%s in %s.", rs.Type, rs.Name, filename),
+		))
+		return nil, diags
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &body); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot convert resource configuration",
+			fmt.Sprintf("The resource object for %s.%s in %s is invalid: %s.", rs.Type, rs.Name, filename, err),
+		))
+		return nil, diags
+	}
+
+	out := hclwrite.NewEmptyFile()
+	block := out.Body().AppendNewBlock("resource", []string{rs.Type, rs.Name})
+	if err := hclBodyFromJSON(block.Body(), body, schema); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot convert resource configuration",
+			fmt.Sprintf("The resource object for %s.%s in %s cannot be converted: %s.", rs.Type, rs.Name, filename, err),
+		))
+		return nil, diags
+	}
+
+	return hclwrite.Format(out.Bytes()), diags
+}
+
+// hclBodyFromJSON populates a native syntax block body from a JSON syntax
+// resource object, reversing jsonBodyFromHCL: entries the schema declares as
+// block types become nested blocks, "${...}" strings become bare
+// expressions, and everything else becomes a literal attribute value.
+func hclBodyFromJSON(body *hclwrite.Body, obj map[string]json.RawMessage, schema *configschema.Block) error {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		raw := obj[name]
+
+		switch name {
+		case "provider":
+			var ref string
+			if err := json.Unmarshal(raw, &ref); err == nil {
+				body.SetAttributeRaw(name, rawExprTokens(ref))
+				continue
+			}
+		case "depends_on":
+			var refs []string
+			if err := json.Unmarshal(raw, &refs); err == nil {
+				body.SetAttributeRaw(name, rawExprTokens("["+strings.Join(refs, ", ")+"]"))
+				continue
+			}
+		}
+
+		isBlock := name == "lifecycle" || name == "provisioner" || name == "connection"
+		if schema != nil {
+			if _, ok := schema.BlockTypes[name]; ok {
+				isBlock = true
+			}
+		}
+		if isBlock {
+			if err := hclBlocksFromJSON(body, name, raw, schema); err != nil {
+				return fmt.Errorf("block %q: %s", name, err)
+			}
+			continue
+		}
+
+		tokens, err := hclExprTokensFromJSON(raw)
+		if err != nil {
+			return fmt.Errorf("attribute %q: %s", name, err)
+		}
+		body.SetAttributeRaw(name, tokens)
+	}
+	return nil
+}
+
+// hclBlocksFromJSON appends the nested blocks a JSON syntax entry describes:
+// an object is one block, an array of objects is one block per element, and
+// for map-nested block types the object keys become block labels.
+func hclBlocksFromJSON(body *hclwrite.Body, name string, raw json.RawMessage, schema *configschema.Block) error {
+	var blockSchema *configschema.Block
+	nesting := configschema.NestingList
+	if schema != nil {
+		if blockS, ok := schema.BlockTypes[name]; ok {
+			blockSchema = &blockS.Block
+			nesting = blockS.Nesting
+		}
+	}
+
+	var elems []json.RawMessage
+	if err := json.Unmarshal(raw, &elems); err == nil {
+		for _, elem := range elems {
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal(elem, &obj); err != nil {
+				return err
+			}
+			block := body.AppendNewBlock(name, nil)
+			if err := hclBodyFromJSON(block.Body(), obj, blockSchema); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return err
+	}
+
+	if nesting == configschema.NestingMap {
+		labels := make([]string, 0, len(obj))
+		for label := range obj {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			var labeled map[string]json.RawMessage
+			if err := json.Unmarshal(obj[label], &labeled); err != nil {
+				return err
+			}
+			block := body.AppendNewBlock(name, []string{label})
+			if err := hclBodyFromJSON(block.Body(), labeled, blockSchema); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	block := body.AppendNewBlock(name, nil)
+	return hclBodyFromJSON(block.Body(), obj, blockSchema)
+}
+
+// hclExprTokensFromJSON converts one JSON syntax attribute value to native
+// syntax tokens: a string of the form "${...}" becomes the expression it
+// wraps, arrays and objects are rebuilt element by element so that template
+// expressions nested inside them survive, and any other value becomes the
+// equivalent literal.
+func hclExprTokensFromJSON(raw json.RawMessage) (hclwrite.Tokens, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") && !strings.Contains(s[2:len(s)-1], "${") {
+			return rawExprTokens(s[2 : len(s)-1]), nil
+		}
+		return hclwrite.TokensForValue(cty.StringVal(s)), nil
+	}
+
+	var arr []json.RawMessage
+	if err := json.Unmarshal(raw, &arr); err == nil {
+		tokens := hclwrite.Tokens{
+			{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		}
+		for i, elem := range arr {
+			if i > 0 {
+				tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(", ")})
+			}
+			elemTokens, err := hclExprTokensFromJSON(elem)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, elemTokens...)
+		}
+		return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")}), nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err == nil {
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		tokens := hclwrite.Tokens{
+			{Type: hclsyntax.TokenOBrace, Bytes: []byte("{")},
+		}
+		for i, key := range keys {
+			if i > 0 {
+				tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(", ")})
+			}
+			if hclsyntax.ValidIdentifier(key) {
+				tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte(key)})
+			} else {
+				tokens = append(tokens, hclwrite.TokensForValue(cty.StringVal(key))...)
+			}
+			tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenEqual, Bytes: []byte(" = ")})
+			valTokens, err := hclExprTokensFromJSON(obj[key])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, valTokens...)
+		}
+		return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrace, Bytes: []byte("}")}), nil
+	}
+
+	ty, err := ctyjson.ImpliedType([]byte(raw))
+	if err != nil {
+		return nil, err
+	}
+	val, err := ctyjson.Unmarshal([]byte(raw), ty)
+	if err != nil {
+		return nil, err
+	}
+	return hclwrite.TokensForValue(val), nil
+}
+
+// rawExprTokens wraps already-valid expression source as a token stream, for
+// writing references and other non-literal expressions verbatim.
+func rawExprTokens(src string) hclwrite.Tokens {
+	return hclwrite.Tokens{
+		{Type: hclsyntax.TokenIdent, Bytes: []byte(src)},
+	}
+}
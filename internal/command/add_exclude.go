@@ -0,0 +1,83 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// genExcludeFilename is the name of an optional file in the configuration
+// directory listing resource types and addresses which "terraform add" must
+// not generate configuration for, so that resources another repository or
+// team intentionally manages elsewhere don't get scaffolded here by mistake.
+//
+// Each non-empty line is a glob pattern (path.Match syntax) matched against
+// both the resource type and the full resource address. Lines starting with
+// "#" are comments.
+const genExcludeFilename = ".tfgenignore"
+
+// genExcludes is a parsed set of exclusion patterns from a .tfgenignore file.
+type genExcludes []string
+
+// loadGenExcludes reads the exclusion patterns from dir, returning an empty
+// set if no .tfgenignore file is present.
+func loadGenExcludes(dir string) (genExcludes, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	src, err := os.ReadFile(filepath.Join(dir, genExcludeFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read generation exclude file",
+			err.Error(),
+		))
+		return nil, diags
+	}
+
+	var ret genExcludes
+	for i, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Validate the pattern eagerly so a malformed file fails loudly
+		// rather than silently excluding nothing.
+		if _, err := path.Match(line, ""); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid generation exclude pattern",
+				fmt.Sprintf("The pattern %q on line %d of %s is not a valid glob pattern.", line, i+1, genExcludeFilename),
+			))
+			continue
+		}
+		ret = append(ret, line)
+	}
+	return ret, diags
+}
+
+// Excludes reports whether the given resource instance matches one of the
+// exclusion patterns, either by its resource type or by its full address,
+// and returns the matching pattern.
+func (ex genExcludes) Excludes(addr addrs.AbsResourceInstance) (string, bool) {
+	candidates := []string{
+		addr.Resource.Resource.Type,
+		addr.ContainingResource().String(),
+		addr.String(),
+	}
+	for _, pattern := range ex {
+		for _, candidate := range candidates {
+			if matched, _ := path.Match(pattern, candidate); matched {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,96 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs"
+)
+
+// configResourceIndex is an address-indexed view of a configuration tree.
+// Resolving a target through Root.Descendent and a formatted ManagedResources
+// key is fine for a single address, but bulk mode repeats that work per
+// address; the index walks the tree once, answers each module lookup with a
+// single map access, and keeps the formatting out of the per-address path.
+// Bulk requests also tend to name many resources in the same module —
+// wildcard and whole-module patterns expand that way — so the most recent
+// resolution is memoized and answered with a plain path comparison. The memo
+// makes the index unsafe for concurrent use, which the command has no need
+// for.
+type configResourceIndex struct {
+	// modules holds every module in the tree, keyed by the dot-joined
+	// module path, with the root module under "".
+	modules map[string]*configs.Module
+
+	lastPath   addrs.ModuleInstance
+	lastModule *configs.Module
+	lastValid  bool
+}
+
+// indexConfigResources builds a configResourceIndex over the given
+// configuration tree. A nil configuration yields an empty index which
+// resolves nothing, matching how lookups against a missing tree behave.
+func indexConfigResources(config *configs.Config) *configResourceIndex {
+	idx := &configResourceIndex{
+		modules: make(map[string]*configs.Module),
+	}
+	if config == nil {
+		return idx
+	}
+	config.Root.DeepEach(func(c *configs.Config) {
+		if c.Module == nil {
+			return
+		}
+		idx.modules[strings.Join(c.Path, ".")] = c.Module
+	})
+	return idx
+}
+
+// managedResource finds the configuration block for a managed resource
+// address, consulting the module the address belongs to rather than only the
+// root module, so that addresses in child modules resolve against that
+// module's own configuration — and, by extension, its own
+// required_providers, which may map the same local name to a different
+// namespace than the root.
+func (idx *configResourceIndex) managedResource(addr addrs.AbsResourceInstance) *configs.Resource {
+	module := idx.moduleForInstance(addr.Module)
+	if module == nil {
+		return nil
+	}
+	r := addr.Resource.Resource
+	if r.Mode != addrs.ManagedResourceMode {
+		return nil
+	}
+	// ManagedResources is keyed by "type.name"; concatenating directly
+	// avoids the fmt round trip of Resource.String in the per-address path.
+	return module.ManagedResources[r.Type+"."+r.Name]
+}
+
+// module finds the module configuration at the given path, or nil when the
+// configuration tree has no module there.
+func (idx *configResourceIndex) module(path addrs.Module) *configs.Module {
+	return idx.modules[strings.Join(path, ".")]
+}
+
+// moduleForInstance is module for a dynamic module instance address, as
+// resource addresses carry, saving the conversion to a configuration path
+// when the memo already holds the answer.
+func (idx *configResourceIndex) moduleForInstance(path addrs.ModuleInstance) *configs.Module {
+	if idx.lastValid && len(path) == len(idx.lastPath) {
+		same := true
+		for i := range path {
+			if path[i].Name != idx.lastPath[i].Name {
+				same = false
+				break
+			}
+		}
+		if same {
+			return idx.lastModule
+		}
+	}
+	module := idx.module(path.Module())
+	idx.lastPath = path
+	idx.lastModule = module
+	idx.lastValid = true
+	return module
+}
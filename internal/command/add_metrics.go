@@ -0,0 +1,88 @@
+package command
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/terraform"
+)
+
+// addMetricsFileEnvName is the environment variable naming a file to which
+// each "terraform add" run appends a machine-readable metrics record, so
+// platform teams can track adoption and performance of configuration
+// generation across an organization without scraping human-oriented output.
+const addMetricsFileEnvName = "TF_ADD_METRICS_FILE"
+
+// addRunMetrics is one run's metrics record: when the run started, how long
+// it took, how much it generated, and which providers (and versions) it
+// generated against, including how large their schemas were, since schema
+// size dominates generation cost.
+type addRunMetrics struct {
+	Timestamp  string              `json:"timestamp"`
+	DurationMs int64               `json:"duration_ms"`
+	Resources  int                 `json:"resources_generated"`
+	Warnings   int                 `json:"warnings"`
+	Providers  []addProviderMetric `json:"providers"`
+}
+
+// addProviderMetric describes one provider consulted during a run. Version
+// is the selection recorded in the dependency lock file, and is omitted when
+// the directory has no lock entry for the provider.
+type addProviderMetric struct {
+	Source          string `json:"source"`
+	Version         string `json:"version,omitempty"`
+	ResourceSchemas int    `json:"resource_schemas"`
+	DataSchemas     int    `json:"data_schemas"`
+}
+
+// addRunMetricsRecord builds the metrics record for a run which started at
+// the given time and consulted the given providers, with schema sizes taken
+// from the loaded schemas and versions from the dependency lock file.
+func addRunMetricsRecord(start time.Time, generated, warnings int, schemas *terraform.Schemas, used map[addrs.Provider]struct{}, versions map[addrs.Provider]string) addRunMetrics {
+	providerMetrics := make([]addProviderMetric, 0, len(used))
+	for provider := range used {
+		metric := addProviderMetric{
+			Source:  provider.ForDisplay(),
+			Version: versions[provider],
+		}
+		if ps, ok := schemas.Providers[provider]; ok && ps != nil {
+			metric.ResourceSchemas = len(ps.ResourceTypes)
+			metric.DataSchemas = len(ps.DataSources)
+		}
+		providerMetrics = append(providerMetrics, metric)
+	}
+	sort.Slice(providerMetrics, func(i, j int) bool {
+		return providerMetrics[i].Source < providerMetrics[j].Source
+	})
+
+	return addRunMetrics{
+		Timestamp:  start.UTC().Format(time.RFC3339),
+		DurationMs: time.Since(start).Milliseconds(),
+		Resources:  generated,
+		Warnings:   warnings,
+		Providers:  providerMetrics,
+	}
+}
+
+// appendAddMetrics appends the record to the file named by
+// TF_ADD_METRICS_FILE as one compact JSON object per line, so repeated runs
+// accumulate a newline-delimited log that line-oriented consumers such as jq
+// can process without parsing the whole file.
+func appendAddMetrics(path string, rec addRunMetrics) error {
+	src, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(src, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
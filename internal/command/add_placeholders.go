@@ -0,0 +1,40 @@
+package command
+
+import (
+	"github.com/zclconf/go-cty/cty"
+)
+
+// typePlaceholderValues converts the raw add_placeholders mapping from the
+// CLI configuration into cty values keyed by primitive type name, ready to
+// hand to the add view. The mapping was validated when the CLI configuration
+// loaded, so entries of an unexpected shape are simply skipped here. Returns
+// nil when there is nothing to apply.
+func typePlaceholderValues(raw map[string]interface{}) map[string]cty.Value {
+	if len(raw) == 0 {
+		return nil
+	}
+	ret := make(map[string]cty.Value)
+	for name, value := range raw {
+		switch name {
+		case "string":
+			if s, ok := value.(string); ok {
+				ret[name] = cty.StringVal(s)
+			}
+		case "number":
+			switch n := value.(type) {
+			case int:
+				ret[name] = cty.NumberIntVal(int64(n))
+			case float64:
+				ret[name] = cty.NumberFloatVal(n)
+			}
+		case "bool":
+			if b, ok := value.(bool); ok {
+				ret[name] = cty.BoolVal(b)
+			}
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
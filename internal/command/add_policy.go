@@ -0,0 +1,254 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// genPolicy is a single policy document loaded from a -policy-dir directory,
+// evaluated against the JSON representation of a generated configuration
+// before it is written.
+//
+// This is a deliberately small, self-contained rule format in the spirit of
+// OPA and Sentinel policies: each document carries an enforcement level and a
+// set of rules constraining attribute values. We don't link a full policy
+// engine into Terraform for this; organizations with existing Rego or
+// Sentinel policies can express the generation-time subset of them in this
+// format.
+type genPolicy struct {
+	// Name identifies the policy in violation messages. If empty, the
+	// filename is used.
+	Name string `json:"name"`
+
+	// Enforcement is either "warning" (report violations but continue) or
+	// "error" (violations block writing the result with -out). It defaults
+	// to "error".
+	Enforcement string `json:"enforcement"`
+
+	// Rules are the individual constraints checked for this policy.
+	Rules []genPolicyRule `json:"rules"`
+}
+
+// genPolicyRule constrains a single attribute in the generated configuration.
+type genPolicyRule struct {
+	// Attribute is a dot-separated path to the attribute being constrained,
+	// e.g. "encrypted" or "server_side_encryption.enabled".
+	Attribute string `json:"attribute"`
+
+	// Equals is the value the attribute must have, if it is present in the
+	// generated configuration at all.
+	Equals interface{} `json:"equals"`
+}
+
+const (
+	genPolicyWarning = "warning"
+	genPolicyError   = "error"
+)
+
+// loadGenPolicies reads all .json policy documents from the given directory.
+func loadGenPolicies(dir string) ([]genPolicy, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read policy directory",
+			err.Error(),
+		))
+		return nil, diags
+	}
+
+	var policies []genPolicy
+	var skipped []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if filepath.Ext(entry.Name()) != ".json" {
+			skipped = append(skipped, entry.Name())
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		src, err := os.ReadFile(path)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to read policy file",
+				err.Error(),
+			))
+			continue
+		}
+		var policy genPolicy
+		if err := json.Unmarshal(src, &policy); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid policy file",
+				fmt.Sprintf("The policy file %q is not a valid policy document: %s.", path, err),
+			))
+			continue
+		}
+		if policy.Name == "" {
+			policy.Name = entry.Name()
+		}
+		switch policy.Enforcement {
+		case "":
+			policy.Enforcement = genPolicyError
+		case genPolicyWarning, genPolicyError:
+			// OK
+		default:
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid policy file",
+				fmt.Sprintf("The policy file %q has invalid enforcement level %q: must be %q or %q.", path, policy.Enforcement, genPolicyWarning, genPolicyError),
+			))
+			continue
+		}
+		policies = append(policies, policy)
+	}
+
+	// Files in other formats are called out rather than skipped silently, so
+	// someone pointing -policy-dir at a directory of Rego or Sentinel
+	// policies finds out that only the JSON rule format is evaluated instead
+	// of having every generated configuration reported as clean.
+	if len(skipped) > 0 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Ignored non-JSON files in policy directory",
+			fmt.Sprintf("The policy directory %q contains %s, which \"terraform add\" does not evaluate: only the JSON rule format documented for -policy-dir is supported, not policy languages such as Rego or Sentinel.", dir, strings.Join(skipped, ", ")),
+		))
+	}
+	if len(policies) == 0 && !diags.HasErrors() {
+		detail := fmt.Sprintf("The policy directory %q contains no JSON policy documents, so no policy could be enforced.", dir)
+		if len(skipped) > 0 {
+			detail = fmt.Sprintf("The policy directory %q contains no JSON policy documents, only %s, so no policy could be enforced.", dir, strings.Join(skipped, ", "))
+		}
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"No policies in policy directory",
+			detail,
+		))
+	}
+
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies, diags
+}
+
+// checkGenPolicies evaluates the given policies against the value that the
+// generated configuration would contain, using its JSON representation. The
+// returned diagnostics contain one warning or error per violated rule.
+func checkGenPolicies(policies []genPolicy, val cty.Value, ty cty.Type) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if len(policies) == 0 {
+		return diags
+	}
+
+	unmarked, _ := val.UnmarkDeep()
+	src, err := ctyjson.Marshal(unmarked, ty)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to encode generated configuration for policy checks",
+			err.Error(),
+		))
+		return diags
+	}
+	var obj interface{}
+	if err := json.Unmarshal(src, &obj); err != nil {
+		// Should never happen, since we produced the JSON just above.
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to decode generated configuration for policy checks",
+			err.Error(),
+		))
+		return diags
+	}
+
+	for _, policy := range policies {
+		severity := tfdiags.Error
+		if policy.Enforcement == genPolicyWarning {
+			severity = tfdiags.Warning
+		}
+		for _, rule := range policy.Rules {
+			for _, got := range lookupJSONPathValues(obj, rule.Attribute) {
+				if got == nil {
+					// Rules constrain only values which are actually present;
+					// absent or null attributes are left to the user.
+					continue
+				}
+				if !jsonValueEqual(got, rule.Equals) {
+					diags = diags.Append(tfdiags.Sourceless(
+						severity,
+						"Generated configuration violates policy",
+						fmt.Sprintf("Policy %q requires %s = %v, but the generated configuration has %v.", policy.Name, rule.Attribute, rule.Equals, got),
+					))
+				}
+			}
+		}
+	}
+
+	return diags
+}
+
+// lookupJSONPathValues walks a decoded JSON document along a dot-separated
+// attribute path, descending into objects and returning every value present
+// at the path. An array fans the remaining path out across all of its
+// elements, so a rule can constrain an attribute inside a repeated block
+// without spelling out indices — and a rule violated by any one element is a
+// violation, not just one violated by the first.
+func lookupJSONPathValues(obj interface{}, path string) []interface{} {
+	current := []interface{}{obj}
+	for _, step := range strings.Split(path, ".") {
+		var next []interface{}
+		for _, elem := range current {
+			next = append(next, descendJSONStep(elem, step)...)
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// descendJSONStep resolves one path step against a value: a key lookup in an
+// object, or that same lookup in every element of an array, however deeply
+// arrays nest.
+func descendJSONStep(v interface{}, step string) []interface{} {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		next, ok := tv[step]
+		if !ok {
+			return nil
+		}
+		return []interface{}{next}
+	case []interface{}:
+		var out []interface{}
+		for _, elem := range tv {
+			out = append(out, descendJSONStep(elem, step)...)
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// jsonValueEqual compares two decoded JSON values for equality, normalizing
+// via re-encoding so that e.g. numeric representations compare consistently.
+func jsonValueEqual(a, b interface{}) bool {
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return string(aj) == string(bj)
+}
@@ -0,0 +1,308 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/terraform"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// The -schema-override file maps provider source addresses to schema
+// documents which shadow what the installed provider reports, so that
+// provider developers can generate configuration against an in-development
+// schema before a build of the provider ships it. The documents use the same
+// shape as the per-provider objects of "terraform providers schema -json",
+// so an override file can be bootstrapped from that command's output.
+
+// schemaOverrideProvider is the per-provider document in a -schema-override
+// file.
+type schemaOverrideProvider struct {
+	ResourceSchemas   map[string]*schemaOverrideSchema `json:"resource_schemas"`
+	DataSourceSchemas map[string]*schemaOverrideSchema `json:"data_source_schemas"`
+}
+
+type schemaOverrideSchema struct {
+	Version uint64               `json:"version"`
+	Block   *schemaOverrideBlock `json:"block"`
+}
+
+type schemaOverrideBlock struct {
+	Attributes map[string]*schemaOverrideAttribute `json:"attributes"`
+	BlockTypes map[string]*schemaOverrideBlockType `json:"block_types"`
+}
+
+type schemaOverrideAttribute struct {
+	Type        json.RawMessage           `json:"type"`
+	NestedType  *schemaOverrideNestedType `json:"nested_type"`
+	Description string                    `json:"description"`
+	Required    bool                      `json:"required"`
+	Optional    bool                      `json:"optional"`
+	Computed    bool                      `json:"computed"`
+	Sensitive   bool                      `json:"sensitive"`
+}
+
+type schemaOverrideNestedType struct {
+	Attributes  map[string]*schemaOverrideAttribute `json:"attributes"`
+	NestingMode string                              `json:"nesting_mode"`
+}
+
+type schemaOverrideBlockType struct {
+	NestingMode string               `json:"nesting_mode"`
+	Block       *schemaOverrideBlock `json:"block"`
+	MinItems    int                  `json:"min_items"`
+	MaxItems    int                  `json:"max_items"`
+
+	// Examples maps attribute names of this block to realistic example
+	// values taken from the provider's documentation, an optional metadata
+	// extension some providers publish alongside their schemas. Generated
+	// nested blocks prefer these over zero placeholders.
+	Examples map[string]json.RawMessage `json:"examples"`
+}
+
+// applySchemaOverrides reads the given -schema-override file and shadows the
+// schemas it describes over the ones the installed providers reported. A
+// provider which is not installed at all gains a schema entry, so that
+// generation can be tested before the provider build exists. The second
+// result holds any per-block example values the document's metadata
+// declares, keyed by block type name and attribute name, for the view to
+// render in place of zero placeholders.
+func applySchemaOverrides(schemas *terraform.Schemas, path string) (map[string]map[string]cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read schema override file",
+			err.Error(),
+		))
+		return nil, diags
+	}
+
+	var overrides map[string]*schemaOverrideProvider
+	if err := json.Unmarshal(src, &overrides); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid schema override file",
+			fmt.Sprintf("The file %q is not a valid schema override document: %s.", path, err),
+		))
+		return nil, diags
+	}
+
+	blockExamples := make(map[string]map[string]cty.Value)
+
+	for addrStr, override := range overrides {
+		provider, addrDiags := addrs.ParseProviderSourceString(addrStr)
+		if addrDiags.HasErrors() {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid schema override file",
+				fmt.Sprintf("The file %q overrides schemas for %q, which is not a valid provider source address.", path, addrStr),
+			))
+			return nil, diags
+		}
+
+		ps := schemas.Providers[provider]
+		if ps == nil {
+			ps = &terraform.ProviderSchema{}
+			schemas.Providers[provider] = ps
+		}
+		if ps.ResourceTypes == nil {
+			ps.ResourceTypes = make(map[string]*configschema.Block)
+		}
+		if ps.DataSources == nil {
+			ps.DataSources = make(map[string]*configschema.Block)
+		}
+		if ps.ResourceTypeSchemaVersions == nil {
+			ps.ResourceTypeSchemaVersions = make(map[string]uint64)
+		}
+
+		for name, schema := range override.ResourceSchemas {
+			block, err := decodeSchemaOverrideBlock(schema.Block)
+			if err == nil {
+				err = collectSchemaOverrideExamples(schema.Block, block, blockExamples)
+			}
+			if err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Invalid schema override file",
+					fmt.Sprintf("The schema for resource type %q in %q is invalid: %s.", name, path, err),
+				))
+				return nil, diags
+			}
+			ps.ResourceTypes[name] = block
+			ps.ResourceTypeSchemaVersions[name] = schema.Version
+		}
+		for name, schema := range override.DataSourceSchemas {
+			block, err := decodeSchemaOverrideBlock(schema.Block)
+			if err == nil {
+				err = collectSchemaOverrideExamples(schema.Block, block, blockExamples)
+			}
+			if err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Invalid schema override file",
+					fmt.Sprintf("The schema for data source %q in %q is invalid: %s.", name, path, err),
+				))
+				return nil, diags
+			}
+			ps.DataSources[name] = block
+		}
+	}
+
+	return blockExamples, diags
+}
+
+// collectSchemaOverrideExamples gathers the per-block example values a
+// schema document's metadata declares into the given map, keyed by block
+// type name and then attribute name, checking each value against the
+// attribute's declared type. Block type names are collected across nesting
+// levels into one flat map, matching how the renderer looks them up when a
+// nested block template is written.
+func collectSchemaOverrideExamples(b *schemaOverrideBlock, decoded *configschema.Block, into map[string]map[string]cty.Value) error {
+	if b == nil || decoded == nil {
+		return nil
+	}
+	for name, blockType := range b.BlockTypes {
+		decodedBlock, ok := decoded.BlockTypes[name]
+		if !ok {
+			continue
+		}
+		for attrName, raw := range blockType.Examples {
+			attrS, ok := decodedBlock.Attributes[attrName]
+			if !ok {
+				return fmt.Errorf("block type %q: example value for undeclared attribute %q", name, attrName)
+			}
+			ty, err := ctyjson.ImpliedType([]byte(raw))
+			if err != nil {
+				return fmt.Errorf("block type %q: example value for %q: %s", name, attrName, err)
+			}
+			val, err := ctyjson.Unmarshal([]byte(raw), ty)
+			if err != nil {
+				return fmt.Errorf("block type %q: example value for %q: %s", name, attrName, err)
+			}
+			converted, err := convert.Convert(val, attrS.Type)
+			if err != nil {
+				return fmt.Errorf("block type %q: example value for %q does not conform to the attribute's type: %s", name, attrName, err)
+			}
+			vals := into[name]
+			if vals == nil {
+				vals = make(map[string]cty.Value)
+				into[name] = vals
+			}
+			vals[attrName] = converted
+		}
+		if err := collectSchemaOverrideExamples(blockType.Block, &decodedBlock.Block, into); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeSchemaOverrideBlock converts the JSON representation of a block
+// schema back into the internal form, reversing the mapping that
+// command/jsonprovider applies when marshalling.
+func decodeSchemaOverrideBlock(b *schemaOverrideBlock) (*configschema.Block, error) {
+	ret := &configschema.Block{}
+	if b == nil {
+		return ret, nil
+	}
+
+	if len(b.Attributes) > 0 {
+		ret.Attributes = make(map[string]*configschema.Attribute, len(b.Attributes))
+		for name, attr := range b.Attributes {
+			decoded, err := decodeSchemaOverrideAttribute(attr)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %q: %s", name, err)
+			}
+			ret.Attributes[name] = decoded
+		}
+	}
+
+	if len(b.BlockTypes) > 0 {
+		ret.BlockTypes = make(map[string]*configschema.NestedBlock, len(b.BlockTypes))
+		for name, blockType := range b.BlockTypes {
+			nesting, err := decodeSchemaOverrideNesting(blockType.NestingMode)
+			if err != nil {
+				return nil, fmt.Errorf("block type %q: %s", name, err)
+			}
+			nested, err := decodeSchemaOverrideBlock(blockType.Block)
+			if err != nil {
+				return nil, fmt.Errorf("block type %q: %s", name, err)
+			}
+			ret.BlockTypes[name] = &configschema.NestedBlock{
+				Block:    *nested,
+				Nesting:  nesting,
+				MinItems: blockType.MinItems,
+				MaxItems: blockType.MaxItems,
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+func decodeSchemaOverrideAttribute(attr *schemaOverrideAttribute) (*configschema.Attribute, error) {
+	ret := &configschema.Attribute{
+		Description: attr.Description,
+		Required:    attr.Required,
+		Optional:    attr.Optional,
+		Computed:    attr.Computed,
+		Sensitive:   attr.Sensitive,
+	}
+
+	switch {
+	case attr.NestedType != nil:
+		nesting, err := decodeSchemaOverrideNesting(attr.NestedType.NestingMode)
+		if err != nil {
+			return nil, err
+		}
+		obj := &configschema.Object{
+			Nesting:    nesting,
+			Attributes: make(map[string]*configschema.Attribute, len(attr.NestedType.Attributes)),
+		}
+		for name, nestedAttr := range attr.NestedType.Attributes {
+			decoded, err := decodeSchemaOverrideAttribute(nestedAttr)
+			if err != nil {
+				return nil, fmt.Errorf("nested attribute %q: %s", name, err)
+			}
+			obj.Attributes[name] = decoded
+		}
+		ret.NestedType = obj
+	case len(attr.Type) > 0:
+		ty, err := ctyjson.UnmarshalType(attr.Type)
+		if err != nil {
+			return nil, err
+		}
+		ret.Type = ty
+	default:
+		return nil, fmt.Errorf("has neither \"type\" nor \"nested_type\"")
+	}
+
+	return ret, nil
+}
+
+func decodeSchemaOverrideNesting(mode string) (configschema.NestingMode, error) {
+	switch mode {
+	case "single":
+		return configschema.NestingSingle, nil
+	case "group":
+		return configschema.NestingGroup, nil
+	case "list":
+		return configschema.NestingList, nil
+	case "set":
+		return configschema.NestingSet, nil
+	case "map":
+		return configschema.NestingMap, nil
+	default:
+		return configschema.NestingSingle, fmt.Errorf("unsupported nesting mode %q", mode)
+	}
+}
@@ -0,0 +1,5085 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/getproviders"
+	"github.com/hashicorp/terraform/internal/providers"
+	"github.com/hashicorp/terraform/internal/states"
+	"github.com/hashicorp/terraform/internal/terminal"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+	"github.com/hashicorp/terraform/version"
+	"github.com/mitchellh/cli"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// simple test cases with a simple resource schema
+func TestAdd_basic(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true, Description: "the ami to use"},
+						"value": {Type: cty.String, Required: true, Description: "a value of a thing"},
+					},
+				},
+			},
+		},
+	}
+
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("basic", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+		expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new" {
+  value = null # REQUIRED string
+}
+`
+
+		if !cmp.Equal(output.Stdout(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+		}
+	})
+
+	t.Run("inputs json", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-inputs-json", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+		expected := `[
+  {
+    "name": "ami",
+    "type": "string",
+    "required": false,
+    "description": "the ami to use",
+    "sensitive": false
+  },
+  {
+    "name": "id",
+    "type": "string",
+    "required": false,
+    "sensitive": false
+  },
+  {
+    "name": "value",
+    "type": "string",
+    "required": true,
+    "description": "a value of a thing",
+    "sensitive": false
+  }
+]
+`
+
+		if !cmp.Equal(output.Stdout(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+		}
+	})
+
+	t.Run("inputs json compact", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-inputs-json", "-compact-json", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+		expected := `{"name":"ami","type":"string","required":false,"description":"the ami to use","sensitive":false}
+{"name":"id","type":"string","required":false,"sensitive":false}
+{"name":"value","type":"string","required":true,"description":"a value of a thing","sensitive":false}
+`
+
+		if !cmp.Equal(output.Stdout(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+		}
+	})
+
+	t.Run("provenance header", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+		want := fmt.Sprintf("# generated by \"terraform add\" v%s on %s from schema\n", version.String(), time.Now().Format("2006-01-02"))
+		if !strings.Contains(output.Stdout(), want) {
+			t.Fatalf("missing provenance marker %q in output:\n%s", want, output.Stdout())
+		}
+	})
+
+	t.Run("basic to file", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		outPath := "add.tf"
+		args := []string{"-no-provenance", fmt.Sprintf("-out=%s", outPath), "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+		expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new" {
+  value = null # REQUIRED string
+}
+`
+		result, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		// While the entire directory will get removed once the whole test suite
+		// is done, we remove this lest it gets in the way of another (not yet
+		// written) test.
+		os.Remove(outPath)
+
+		if !cmp.Equal(expected, string(result)) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, string(result)))
+		}
+	})
+
+	t.Run("basic to existing file", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		outPath := "add.tf"
+		args := []string{"-no-provenance", fmt.Sprintf("-out=%s", outPath), "test_instance.new"}
+		c.Run(args)
+		args = []string{"-no-provenance", fmt.Sprintf("-out=%s", outPath), "test_instance.new2"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+		expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new" {
+  value = null # REQUIRED string
+}
+# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new2" {
+  value = null # REQUIRED string
+}
+`
+		result, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		// While the entire directory will get removed once the whole test suite
+		// is done, we remove this lest it gets in the way of another (not yet
+		// written) test.
+		os.Remove(outPath)
+
+		if !cmp.Equal(expected, string(result)) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, string(result)))
+		}
+	})
+
+	t.Run("optionals", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-optional", "test_instance.new"}
+		code := c.Run(args)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+		output := done(t)
+		expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new" {
+  ami   = null # OPTIONAL string (e.g. data.aws_ami.selected.id)
+  id    = null # OPTIONAL/COMPUTED string
+  value = null # REQUIRED string
+}
+`
+
+		if !cmp.Equal(output.Stdout(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+		}
+	})
+
+	t.Run("alternate provider for resource", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-provider=provider[\"registry.terraform.io/happycorp/test\"].alias", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		// The provider happycorp/test has a localname "othertest" in the provider configuration.
+		expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new" {
+  provider = othertest.alias
+
+  value = null # REQUIRED string
+}
+`
+
+		if !cmp.Equal(output.Stdout(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+		}
+	})
+
+	t.Run("resource exists error", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		outPath := "add.tf"
+		args := []string{"-no-provenance", fmt.Sprintf("-out=%s", outPath), "test_instance.exists"}
+		code := c.Run(args)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		output := done(t)
+		if !strings.Contains(output.Stderr(), "The resource test_instance.exists is already in this configuration") {
+			t.Fatalf("missing expected error message: %s", output.Stderr())
+		}
+	})
+
+	t.Run("output existing resource to stdout", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "test_instance.exists"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+		expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "exists" {
+  value = null # REQUIRED string
+}
+`
+
+		if !cmp.Equal(output.Stdout(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+		}
+	})
+
+	t.Run("provider not in configuration", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "toast_instance.new"}
+		code := c.Run(args)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		output := done(t)
+		if !strings.Contains(output.Stderr(), "No schema found for provider registry.terraform.io/hashicorp/toast.") {
+			t.Fatalf("missing expected error message: %s", output.Stderr())
+		}
+	})
+
+	t.Run("no schema for resource", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "test_pet.meow"}
+		code := c.Run(args)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		output := done(t)
+		if !strings.Contains(output.Stderr(), "No resource schema found for test_pet.") {
+			t.Fatalf("missing expected error message: %s", output.Stderr())
+		}
+	})
+
+	t.Run("misspelled resource type", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "test_instanc.new"}
+		code := c.Run(args)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+
+		output := done(t)
+		if !strings.Contains(output.Stderr(), `Did you mean "test_instance"?`) {
+			t.Fatalf("missing expected suggestion: %s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_undeclaredProvider(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/undeclared"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		Provider: providers.Schema{
+			// A provider with a mandatory nested block in its own
+			// configuration, in the manner of azurerm's features block.
+			Block: &configschema.Block{
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"features": {
+						Nesting:  configschema.NestingSingle,
+						MinItems: 1,
+						MaxItems: 1,
+					},
+				},
+			},
+		},
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: &testingOverrides{
+				Providers: map[addrs.Provider]providers.Factory{
+					addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+				},
+			},
+			View: view,
+		},
+	}
+	args := []string{"-no-provenance", "test_instance.new"}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		fmt.Println(output.Stderr())
+		t.Fatalf("wrong exit status. Got %d, want 0", code)
+	}
+
+	// The configuration should still be generated despite the warning.
+	if !strings.Contains(output.Stdout(), `resource "test_instance" "new" {`) {
+		t.Errorf("missing generated resource block in output:\n%s", output.Stdout())
+	}
+	if !strings.Contains(output.Stdout(), "Provider not declared in required_providers") {
+		t.Errorf("missing undeclared provider warning in output:\n%s", output.Stdout())
+	}
+
+	// The undeclared provider is also scaffolded, including the mandatory
+	// features block its configuration schema declares, and the scaffold
+	// comes before the resource block.
+	for _, want := range []string{
+		"required_providers {",
+		`source = "hashicorp/test"`,
+		"features {",
+	} {
+		if !strings.Contains(output.Stdout(), want) {
+			t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+		}
+	}
+	if strings.Index(output.Stdout(), "required_providers {") > strings.Index(output.Stdout(), `resource "test_instance"`) {
+		t.Errorf("provider scaffold should come before the resource block:\n%s", output.Stdout())
+	}
+}
+
+// When writing to a file, the required_providers scaffold for an undeclared
+// provider is opt-in: without -fix the warning carries the exact declaration
+// to paste, and with -fix it is appended to the -out file.
+func TestAdd_fixRequiredProviders(t *testing.T) {
+	setup := func(t *testing.T) (*AddCommand, func(*testing.T) *terminal.TestOutput) {
+		p := testProvider()
+		p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+			ResourceTypes: map[string]providers.Schema{
+				"test_instance": {
+					Block: &configschema.Block{
+						Attributes: map[string]*configschema.Attribute{
+							"value": {Type: cty.String, Required: true},
+						},
+					},
+				},
+			},
+		}
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: &testingOverrides{
+					Providers: map[addrs.Provider]providers.Factory{
+						addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+					},
+				},
+				View: view,
+			},
+		}
+		return c, done
+	}
+
+	t.Run("without -fix the warning carries the declaration", func(t *testing.T) {
+		td := tempDir(t)
+		testCopyDir(t, testFixturePath("add/undeclared"), td)
+		defer os.RemoveAll(td)
+		defer testChdir(t, td)()
+
+		c, done := setup(t)
+		outPath := "generated.tf"
+		args := []string{"-out", outPath, "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		all := output.All()
+		for _, want := range []string{
+			"Provider not declared in required_providers",
+			`source = "hashicorp/test"`,
+			"Re-run with -fix",
+		} {
+			if !strings.Contains(all, want) {
+				t.Errorf("missing %q in output:\n%s", want, all)
+			}
+		}
+
+		content, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		if strings.Contains(string(content), "required_providers") {
+			t.Errorf("file contains the provider scaffold without -fix:\n%s", string(content))
+		}
+		if !strings.Contains(string(content), `resource "test_instance" "new" {`) {
+			t.Errorf("missing generated resource block in file:\n%s", string(content))
+		}
+	})
+
+	t.Run("-fix appends the declaration to the file", func(t *testing.T) {
+		td := tempDir(t)
+		testCopyDir(t, testFixturePath("add/undeclared"), td)
+		defer os.RemoveAll(td)
+		defer testChdir(t, td)()
+
+		c, done := setup(t)
+		outPath := "generated.tf"
+		args := []string{"-fix", "-out", outPath, "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		content, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		for _, want := range []string{
+			"required_providers {",
+			`source = "hashicorp/test"`,
+			`resource "test_instance" "new" {`,
+		} {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("missing %q in file:\n%s", want, string(content))
+			}
+		}
+		if strings.Index(string(content), "required_providers {") > strings.Index(string(content), `resource "test_instance"`) {
+			t.Errorf("provider scaffold should come before the resource block:\n%s", string(content))
+		}
+	})
+}
+
+// With TF_ADD_METRICS_FILE set, each run appends one JSON record describing
+// what it generated and which provider schemas it consulted.
+func TestAdd_metricsFile(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	metricsPath := filepath.Join(td, "metrics.ndjson")
+	if err := os.Setenv(addMetricsFileEnvName, metricsPath); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(addMetricsFileEnvName)
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	// Two runs should accumulate two records, one JSON object per line.
+	for i := 0; i < 2; i++ {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+	}
+
+	src, err := os.ReadFile(metricsPath)
+	if err != nil {
+		t.Fatalf("failed to read metrics file: %s", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(src), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("wrong number of metrics records %d; want 2:\n%s", len(lines), string(src))
+	}
+
+	for _, line := range lines {
+		var rec struct {
+			Timestamp  string `json:"timestamp"`
+			DurationMs int64  `json:"duration_ms"`
+			Resources  int    `json:"resources_generated"`
+			Warnings   int    `json:"warnings"`
+			Providers  []struct {
+				Source          string `json:"source"`
+				Version         string `json:"version"`
+				ResourceSchemas int    `json:"resource_schemas"`
+			} `json:"providers"`
+		}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid metrics record %q: %s", line, err)
+		}
+		if rec.Timestamp == "" {
+			t.Errorf("metrics record has no timestamp: %s", line)
+		}
+		if got, want := rec.Resources, 1; got != want {
+			t.Errorf("wrong resource count %d; want %d", got, want)
+		}
+		if len(rec.Providers) != 1 {
+			t.Fatalf("wrong provider count %d; want 1:\n%s", len(rec.Providers), line)
+		}
+		if got, want := rec.Providers[0].Source, "hashicorp/test"; got != want {
+			t.Errorf("wrong provider source %q; want %q", got, want)
+		}
+		if got, want := rec.Providers[0].ResourceSchemas, 1; got != want {
+			t.Errorf("wrong schema size %d; want %d", got, want)
+		}
+	}
+}
+
+// A typo in a resource type's prefix makes Terraform assume a provider the
+// module never declared; the warning should then point at the providers the
+// module does declare and suggest the closest prefix.
+func TestAdd_undeclaredProviderSuggestion(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: &testingOverrides{
+				Providers: map[addrs.Provider]providers.Factory{
+					addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+					addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+				},
+			},
+			View: view,
+		},
+	}
+	args := []string{"toast_instance.new"}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("wrong exit status. Got %d, want 1", code)
+	}
+
+	all := output.Stdout() + output.Stderr()
+	for _, want := range []string{
+		"Provider not declared in required_providers",
+		`othertest ("othertest_*")`,
+		`test ("test_*")`,
+		// Diagnostics are word-wrapped for the terminal, so only match a
+		// fragment which fits on one line.
+		`starting with "test_"?`,
+		"Missing schema for provider",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("missing %q in output:\n%s", want, all)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/module"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// a simple hashicorp/test provider, and a more complex happycorp/test provider
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	happycorp := testProvider()
+	happycorp.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true, Description: "the ami to use"},
+						"value": {Type: cty.String, Required: true, Description: "a value of a thing"},
+						"disks": {
+							NestedType: &configschema.Object{
+								Nesting: configschema.NestingList,
+								Attributes: map[string]*configschema.Attribute{
+									"size":        {Type: cty.String, Optional: true},
+									"mount_point": {Type: cty.String, Required: true},
+								},
+							},
+							Optional: true,
+						},
+					},
+					BlockTypes: map[string]*configschema.NestedBlock{
+						"network_interface": {
+							Nesting:  configschema.NestingList,
+							MinItems: 1,
+							Block: configschema.Block{
+								Attributes: map[string]*configschema.Attribute{
+									"device_index": {Type: cty.String, Optional: true},
+									"description":  {Type: cty.String, Optional: true},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	providerSource, psClose := newMockProviderSource(t, map[string][]string{
+		"registry.terraform.io/happycorp/test": {"1.0.0"},
+		"registry.terraform.io/hashicorp/test": {"1.0.0"},
+	})
+	defer psClose()
+
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(happycorp),
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+		},
+	}
+
+	// the test fixture uses a module, so we need to run init.
+	m := Meta{
+		testingOverrides: overrides,
+		ProviderSource:   providerSource,
+		Ui:               new(cli.MockUi),
+	}
+
+	init := &InitCommand{
+		Meta: m,
+	}
+
+	code := init.Run([]string{})
+	if code != 0 {
+		t.Fatal("init failed")
+	}
+
+	t.Run("optional", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-optional", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new" {
+  ami = null           # OPTIONAL string (e.g. data.aws_ami.selected.id)
+  disks = [{           # OPTIONAL list(object({ mount_point = string, size = string }))
+    mount_point = null # REQUIRED string
+    size        = null # OPTIONAL string
+  }]
+  id    = null          # OPTIONAL/COMPUTED string
+  value = null          # REQUIRED string
+  network_interface {   # REQUIRED block
+    description  = null # OPTIONAL string
+    device_index = null # OPTIONAL string
+  }
+}
+`
+
+		if !cmp.Equal(output.Stdout(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+		}
+
+	})
+
+	t.Run("chooses correct provider for root module", func(t *testing.T) {
+		// in the root module of this test fixture, "test" is the local name for "happycorp/test"
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new" {
+  value = null         # REQUIRED string
+  network_interface {} # REQUIRED block
+}
+`
+
+		if !cmp.Equal(output.Stdout(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+		}
+	})
+
+	t.Run("chooses correct provider for child module", func(t *testing.T) {
+		// in the child module of this test fixture, "test" is a default "hashicorp/test" provider
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "module.child.test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new" {
+  id = null # REQUIRED string
+}
+`
+
+		if !cmp.Equal(output.Stdout(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+		}
+	})
+
+	t.Run("chooses correct provider for an unknown module", func(t *testing.T) {
+		// it's weird but ok to use a new/unknown module name; terraform will
+		// fall back on default providers (unless a -provider argument is
+		// supplied)
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "module.madeup.test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new" {
+  id = null # REQUIRED string
+}
+`
+
+		if !cmp.Equal(output.Stdout(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+		}
+	})
+}
+
+func TestAdd_passedProviders(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/module-providers"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	providerSource, psClose := newMockProviderSource(t, map[string][]string{
+		"registry.terraform.io/hashicorp/test": {"1.0.0"},
+	})
+	defer psClose()
+
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+		},
+	}
+
+	// the test fixture uses a module, so we need to run init.
+	m := Meta{
+		testingOverrides: overrides,
+		ProviderSource:   providerSource,
+		Ui:               new(cli.MockUi),
+	}
+
+	init := &InitCommand{
+		Meta: m,
+	}
+
+	code := init.Run([]string{})
+	if code != 0 {
+		t.Fatal("init failed")
+	}
+
+	t.Run("child module resource names the caller's configuration", func(t *testing.T) {
+		// the fixture's module call passes providers = { test = test.secondary },
+		// so the generated block should say which credentials will apply.
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "module.child.test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new" {
+  # provider test is passed in by the calling module as test.secondary
+  id = null # REQUIRED string
+}
+`
+
+		if !cmp.Equal(output.Stdout(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+		}
+	})
+
+	t.Run("root module resource gets no comment", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		if strings.Contains(output.Stdout(), "passed in by the calling module") {
+			t.Fatalf("unexpected passed-provider comment in root module output:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("suppressed with -no-comments", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-comments", "module.child.test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		if strings.Contains(output.Stdout(), "passed in by the calling module") {
+			t.Fatalf("unexpected passed-provider comment with -no-comments:\n%s", output.Stdout())
+		}
+	})
+}
+
+// A child module declaring configuration_aliases expects the caller to pass
+// those aliased configurations in, so generation into that module references
+// the declared alias instead of the default configuration.
+func TestAdd_configurationAliases(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/configuration-aliases"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	providerSource, psClose := newMockProviderSource(t, map[string][]string{
+		"registry.terraform.io/hashicorp/test": {"1.0.0"},
+	})
+	defer psClose()
+
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+		},
+	}
+
+	// the test fixture uses a module, so we need to run init.
+	m := Meta{
+		testingOverrides: overrides,
+		ProviderSource:   providerSource,
+		Ui:               new(cli.MockUi),
+	}
+
+	init := &InitCommand{
+		Meta: m,
+	}
+
+	code := init.Run([]string{})
+	if code != 0 {
+		t.Fatal("init failed")
+	}
+
+	t.Run("child module resource references the declared alias", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "module.child.test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		if !strings.Contains(output.Stdout(), "provider = test.special") {
+			t.Fatalf("generated block does not reference the configuration alias:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("root module resource keeps the default configuration", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		if strings.Contains(output.Stdout(), "provider =") {
+			t.Fatalf("unexpected provider meta-argument in root module output:\n%s", output.Stdout())
+		}
+	})
+}
+
+func TestAdd_policy(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// write some state
+	testState := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "new",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				// Two server_side_encryption elements, of which only the
+				// second violates the rule checked below: a rule must apply
+				// to every element of a repeated block, not just the first.
+				AttrsJSON:    []byte("{\"id\":\"bar\",\"ami\":\"ami-123456\",\"value\":\"bloop\",\"server_side_encryption\":[{\"enabled\":true},{\"enabled\":false}]}"),
+				Status:       states.ObjectReady,
+				Dependencies: []addrs.ConfigResource{},
+			},
+			mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`),
+		)
+	})
+	f, err := os.Create("terraform.tfstate")
+	if err != nil {
+		t.Fatalf("failed to create temporary state file: %s", err)
+	}
+	defer f.Close()
+	err = writeStateForTesting(testState, f)
+	if err != nil {
+		t.Fatalf("failed to write state file: %s", err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true},
+						"value": {Type: cty.String, Required: true},
+						"server_side_encryption": {
+							Type: cty.List(cty.Object(map[string]cty.Type{
+								"enabled": cty.Bool,
+							})),
+							Optional: true,
+						},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	policyDir := filepath.Join(td, "policy")
+	if err := os.Mkdir(policyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writePolicy := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(policyDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("violation blocks -out", func(t *testing.T) {
+		writePolicy("ami.json", `{"enforcement":"error","rules":[{"attribute":"ami","equals":"ami-approved"}]}`)
+		defer os.Remove(filepath.Join(policyDir, "ami.json"))
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-from-state", "-policy-dir=" + policyDir, "-out=add.tf", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Generated configuration violates policy") {
+			t.Fatalf("missing expected error message: %s", output.Stderr())
+		}
+		if _, err := os.Stat("add.tf"); !os.IsNotExist(err) {
+			t.Fatal("output file should not have been written")
+		}
+	})
+
+	t.Run("warning still writes", func(t *testing.T) {
+		writePolicy("ami.json", `{"enforcement":"warning","rules":[{"attribute":"ami","equals":"ami-approved"}]}`)
+		defer os.Remove(filepath.Join(policyDir, "ami.json"))
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-from-state", "-policy-dir=" + policyDir, "-out=add.tf", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+		// Warnings are printed to stdout alongside the generated output.
+		if !strings.Contains(output.Stdout(), "Generated configuration violates policy") {
+			t.Fatalf("missing expected warning message: %s", output.Stdout())
+		}
+		if _, err := os.Stat("add.tf"); err != nil {
+			t.Fatalf("output file should have been written: %s", err)
+		}
+		os.Remove("add.tf")
+	})
+
+	t.Run("violation in a later repeated block element", func(t *testing.T) {
+		// The first server_side_encryption element complies; only the second
+		// violates. A compliant first element must not mask the violation.
+		writePolicy("sse.json", `{"enforcement":"error","rules":[{"attribute":"server_side_encryption.enabled","equals":true}]}`)
+		defer os.Remove(filepath.Join(policyDir, "sse.json"))
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-from-state", "-policy-dir=" + policyDir, "-out=add.tf", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "requires server_side_encryption.enabled = true") {
+			t.Fatalf("missing expected error message: %s", output.Stderr())
+		}
+		if _, err := os.Stat("add.tf"); !os.IsNotExist(err) {
+			t.Fatal("output file should not have been written")
+		}
+	})
+
+	t.Run("empty policy directory", func(t *testing.T) {
+		// A directory with nothing to enforce must be an error, not a run
+		// which reports the generated configuration as clean.
+		emptyDir := t.TempDir()
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-from-state", "-policy-dir=" + emptyDir, "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "No policies in policy directory") {
+			t.Fatalf("missing expected error message: %s", output.Stderr())
+		}
+	})
+
+	t.Run("rego policies are not evaluated", func(t *testing.T) {
+		// Only the JSON rule format is evaluated; a directory of Rego
+		// policies must fail with a message naming the skipped files rather
+		// than silently enforcing nothing.
+		regoDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(regoDir, "encryption.rego"), []byte("package terraform\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-from-state", "-policy-dir=" + regoDir, "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "No policies in policy directory") {
+			t.Fatalf("missing expected error message: %s", output.Stderr())
+		}
+		if !strings.Contains(output.Stderr(), "encryption.rego") {
+			t.Fatalf("skipped file is not named in the output: %s", output.Stderr())
+		}
+	})
+
+	t.Run("satisfied policy", func(t *testing.T) {
+		writePolicy("ami.json", `{"enforcement":"error","rules":[{"attribute":"ami","equals":"ami-123456"}]}`)
+		defer os.Remove(filepath.Join(policyDir, "ami.json"))
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-from-state", "-policy-dir=" + policyDir, "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+	})
+}
+
+func TestAdd_uninitialized(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	t.Run("friendly init advice", func(t *testing.T) {
+		// Without testingOverrides the providers must come from the data
+		// directory, which does not exist because the working directory has
+		// never been initialized.
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				View: view,
+			},
+		}
+		code := c.Run([]string{"test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), `please run "terraform init"`) {
+			t.Fatalf("missing init advice in output:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("auto-init", func(t *testing.T) {
+		p := testProvider()
+		p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+			ResourceTypes: map[string]providers.Schema{
+				"test_instance": {
+					Block: &configschema.Block{
+						Attributes: map[string]*configschema.Attribute{
+							"value": {Type: cty.String, Required: true},
+						},
+					},
+				},
+			},
+		}
+		overrides := &testingOverrides{
+			Providers: map[addrs.Provider]providers.Factory{
+				addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+				addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+			},
+		}
+		providerSource, psClose := newMockProviderSource(t, map[string][]string{
+			"hashicorp/test": {"1.0.0"},
+			"happycorp/test": {"1.0.0"},
+		})
+		defer psClose()
+
+		ui := new(cli.MockUi)
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				Ui:               ui,
+				View:             view,
+				testingOverrides: overrides,
+				ProviderSource:   providerSource,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-auto-init", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0\nstderr:\n%s\nui:\n%s", code, output.Stderr(), ui.ErrorWriter.String())
+		}
+		if !strings.Contains(output.Stdout(), `resource "test_instance" "new" {`) {
+			t.Fatalf("missing generated block in output:\n%s", output.Stdout())
+		}
+		if _, err := os.Stat(filepath.Join(td, ".terraform")); err != nil {
+			t.Fatalf("data directory was not initialized: %s", err)
+		}
+		// The installation progress from init's event hooks must reach the
+		// progress stream through the view, not stdout, where it would
+		// interleave with the generated configuration.
+		if !strings.Contains(output.Stderr(), "Initializing provider plugins...") {
+			t.Fatalf("missing provider installation progress on stderr:\n%s", output.Stderr())
+		}
+		if !strings.Contains(output.Stderr(), "Installing hashicorp/test v1.0.0...") {
+			t.Fatalf("missing provider download progress on stderr:\n%s", output.Stderr())
+		}
+		if strings.Contains(output.Stdout(), "Initializing") {
+			t.Fatalf("initialization progress leaked into stdout:\n%s", output.Stdout())
+		}
+	})
+}
+
+// A directory with no configuration files at all cannot initialize a backend,
+// so add falls back to a schema-only mode there, generating from the schema
+// of the provider named with -provider.
+func TestAdd_zeroConfig(t *testing.T) {
+	td := tempDir(t)
+	os.MkdirAll(td, 0755)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("generates from schema", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-provider=provider[\"registry.terraform.io/hashicorp/test\"]", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0\nstderr:\n%s", code, output.Stderr())
+		}
+
+		// A brand new project has no required_providers block either, so the
+		// provider scaffold comes before the resource block.
+		for _, want := range []string{
+			"required_providers {",
+			`source = "hashicorp/test"`,
+			`resource "test_instance" "new" {`,
+			"value = null # REQUIRED string",
+		} {
+			if !strings.Contains(output.Stdout(), want) {
+				t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+			}
+		}
+		if strings.Index(output.Stdout(), "required_providers {") > strings.Index(output.Stdout(), `resource "test_instance"`) {
+			t.Errorf("provider scaffold should come before the resource block:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("provider required", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "name the provider explicitly") {
+			t.Fatalf("missing -provider advice in output:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("state options rejected", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-from-state", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "no existing configuration or state") {
+			t.Fatalf("wrong error message:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("plugin not available", func(t *testing.T) {
+		// Without testingOverrides there is no lock file to select plugins
+		// from, so the explicitly named provider cannot be found.
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				View: view,
+			},
+		}
+		args := []string{"-provider=provider[\"registry.terraform.io/hashicorp/test\"]", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Provider plugin not available") {
+			t.Fatalf("wrong error message:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_merge(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("reports missing required arguments", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		// The fixture declares test_instance.exists with an empty body, so
+		// the schema's required "value" argument must be reported.
+		code := c.Run([]string{"-merge", "test_instance.exists"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		for _, want := range []string{
+			"test_instance.exists is already declared at",
+			"required arguments are missing",
+			"#  value = null # REQUIRED string",
+		} {
+			if !strings.Contains(output.Stdout(), want) {
+				t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+			}
+		}
+		if strings.Contains(output.Stdout(), `resource "test_instance" "exists" {`) {
+			t.Errorf("unexpected duplicate resource block in output:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("new resource generates normally", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-merge", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		if !strings.Contains(output.Stdout(), `resource "test_instance" "new" {`) {
+			t.Fatalf("missing generated block in output:\n%s", output.Stdout())
+		}
+	})
+}
+
+func TestAdd_audit(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("table", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-audit"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0\nstderr:\n%s", code, output.Stderr())
+		}
+		if !strings.Contains(output.Stdout(), "RESOURCE") {
+			t.Errorf("missing table header in output:\n%s", output.Stdout())
+		}
+		// test_instance.exists sets nothing, so its required attribute and
+		// both settable optional attributes are reported.
+		if !strings.Contains(output.Stdout(), "test_instance.exists  value             ami, id") {
+			t.Errorf("missing report row in output:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-audit", "-audit-format=json"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0\nstderr:\n%s", code, output.Stderr())
+		}
+		var report []struct {
+			Address         string   `json:"address"`
+			MissingRequired []string `json:"missing_required"`
+			UnsetOptional   []string `json:"unset_optional"`
+		}
+		if err := json.Unmarshal([]byte(output.Stdout()), &report); err != nil {
+			t.Fatalf("invalid JSON output: %s\n%s", err, output.Stdout())
+		}
+		if len(report) != 1 {
+			t.Fatalf("wrong number of report rows %d; want 1\n%s", len(report), output.Stdout())
+		}
+		if report[0].Address != "test_instance.exists" {
+			t.Errorf("wrong address %q; want %q", report[0].Address, "test_instance.exists")
+		}
+		if !cmp.Equal(report[0].MissingRequired, []string{"value"}) {
+			t.Errorf("wrong missing_required: %s", cmp.Diff([]string{"value"}, report[0].MissingRequired))
+		}
+		if !cmp.Equal(report[0].UnsetOptional, []string{"ami", "id"}) {
+			t.Errorf("wrong unset_optional: %s", cmp.Diff([]string{"ami", "id"}, report[0].UnsetOptional))
+		}
+	})
+}
+
+func TestAdd_from_state_ordering(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	instAddr := func(name string) addrs.AbsResourceInstance {
+		return addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_instance",
+			Name: name,
+		}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+	}
+	configAddr := func(name string) addrs.ConfigResource {
+		return addrs.ConfigResource{
+			Module: addrs.RootModule,
+			Resource: addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_instance",
+				Name: name,
+			},
+		}
+	}
+
+	// "web" depends on "net", which depends on "base"; requesting them in
+	// the opposite order must still generate base, then net, then web.
+	testState := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(instAddr("base"), &states.ResourceInstanceObjectSrc{
+			AttrsJSON: []byte(`{"id":"b","value":"base"}`),
+			Status:    states.ObjectReady,
+		}, mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`))
+		s.SetResourceInstanceCurrent(instAddr("net"), &states.ResourceInstanceObjectSrc{
+			AttrsJSON:    []byte(`{"id":"n","value":"net"}`),
+			Status:       states.ObjectReady,
+			Dependencies: []addrs.ConfigResource{configAddr("base")},
+		}, mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`))
+		s.SetResourceInstanceCurrent(instAddr("web"), &states.ResourceInstanceObjectSrc{
+			AttrsJSON:    []byte(`{"id":"w","value":"web"}`),
+			Status:       states.ObjectReady,
+			Dependencies: []addrs.ConfigResource{configAddr("net")},
+		}, mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`))
+	})
+	f, err := os.Create("terraform.tfstate")
+	if err != nil {
+		t.Fatalf("failed to create temporary state file: %s", err)
+	}
+	defer f.Close()
+	if err := writeStateForTesting(testState, f); err != nil {
+		t.Fatalf("failed to write state file: %s", err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: overrides,
+			View:             view,
+		},
+	}
+	code := c.Run([]string{"-no-provenance", "-from-state", "test_instance.web", "test_instance.base", "test_instance.net"})
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+	}
+
+	stdout := output.Stdout()
+	base := strings.Index(stdout, `resource "test_instance" "base" {`)
+	net := strings.Index(stdout, `resource "test_instance" "net" {`)
+	web := strings.Index(stdout, `resource "test_instance" "web" {`)
+	if base == -1 || net == -1 || web == -1 {
+		t.Fatalf("missing generated blocks in output:\n%s", stdout)
+	}
+	if !(base < net && net < web) {
+		t.Fatalf("blocks not in dependency order (base=%d, net=%d, web=%d):\n%s", base, net, web, stdout)
+	}
+}
+
+func TestAdd_childModuleResolution(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/module"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// In this fixture the root module maps the local name "test" to
+	// happycorp/test while the child module maps it to hashicorp/test, so
+	// resolving against the wrong module would pick the wrong schema.
+	happycorpProvider := testProvider()
+	happycorpProvider.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	hashicorpProvider := testProvider()
+	hashicorpProvider.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(happycorpProvider),
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(hashicorpProvider),
+		},
+	}
+
+	// the test fixture uses a module, so we need to run init.
+	providerSource, psClose := newMockProviderSource(t, map[string][]string{
+		"registry.terraform.io/happycorp/test": {"1.0.0"},
+		"registry.terraform.io/hashicorp/test": {"1.0.0"},
+	})
+	defer psClose()
+	init := &InitCommand{
+		Meta: Meta{
+			testingOverrides: overrides,
+			ProviderSource:   providerSource,
+			Ui:               new(cli.MockUi),
+		},
+	}
+	if code := init.Run([]string{}); code != 0 {
+		t.Fatal("init failed")
+	}
+
+	t.Run("detects existing resource in child module", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-out=generated.tf", "module.child.test_instance.exists"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1:\n%s", code, output.Stdout())
+		}
+		if !strings.Contains(output.Stderr(), "Resource already in configuration") {
+			t.Errorf("missing error in output:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("uses child module provider requirements", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-out=generated.tf", "module.child.test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+
+		generated, err := os.ReadFile("generated.tf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The child module's "test" is hashicorp/test, whose schema requires
+		// "id"; the root's happycorp/test requires "value" instead.
+		if !strings.Contains(string(generated), "id = null") {
+			t.Errorf("missing child provider's required argument:\n%s", generated)
+		}
+		if strings.Contains(string(generated), "value") {
+			t.Errorf("generated with the root module's provider schema:\n%s", generated)
+		}
+	})
+}
+
+func TestAdd_asData(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	testState := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "new",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				AttrsJSON:    []byte(`{"id":"i-0abc123","ami":"ami-123456","value":"bloop"}`),
+				Status:       states.ObjectReady,
+				Dependencies: []addrs.ConfigResource{},
+			},
+			mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`),
+		)
+	})
+	f, err := os.Create("terraform.tfstate")
+	if err != nil {
+		t.Fatalf("failed to create temporary state file: %s", err)
+	}
+	defer f.Close()
+	if err := writeStateForTesting(testState, f); err != nil {
+		t.Fatalf("failed to write state file: %s", err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+		DataSources: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true},
+						"ami":   {Type: cty.String, Computed: true},
+						"value": {Type: cty.String, Computed: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("generates a data block", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-from-state", "-as-data", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+
+		stdout := output.Stdout()
+		if !strings.Contains(stdout, `data "test_instance" "new" {`) {
+			t.Errorf("missing data block in output:\n%s", stdout)
+		}
+		if !strings.Contains(stdout, `id = "i-0abc123"`) {
+			t.Errorf("missing identifying lookup argument in output:\n%s", stdout)
+		}
+		// Only the identifying attribute is used as a lookup argument.
+		if strings.Contains(stdout, "ami") || strings.Contains(stdout, `resource "`) {
+			t.Errorf("unexpected content in output:\n%s", stdout)
+		}
+	})
+
+	t.Run("no matching data source", func(t *testing.T) {
+		p.GetProviderSchemaResponse.DataSources = nil
+		defer func() {
+			p.GetProviderSchemaResponse.DataSources = map[string]providers.Schema{}
+		}()
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-from-state", "-as-data", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1:\n%s", code, output.Stdout())
+		}
+		if !strings.Contains(output.Stderr(), "No matching data source") {
+			t.Errorf("missing error in output:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_workspaceNameSuggestion(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/workspace"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":   {Type: cty.String, Optional: true, Computed: true},
+						"name": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+		},
+	}
+
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: overrides,
+			View:             view,
+		},
+	}
+	code := c.Run([]string{"-no-provenance", "test_instance.web"})
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+	}
+
+	// The existing resource names itself "${terraform.workspace}-exists", so
+	// the placeholder's example should follow the same convention.
+	want := `name = null # REQUIRED string (e.g. "${terraform.workspace}-web")`
+	if !strings.Contains(output.Stdout(), want) {
+		t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+	}
+}
+
+func TestAdd_forEach(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	instAddr := func(key string) addrs.AbsResourceInstance {
+		return addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_instance",
+			Name: "web",
+		}.Instance(addrs.StringKey(key)).Absolute(addrs.RootModuleInstance)
+	}
+
+	testState := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(instAddr("one"), &states.ResourceInstanceObjectSrc{
+			AttrsJSON: []byte(`{"id":"1","ami":"ami-123456","value":"one"}`),
+			Status:    states.ObjectReady,
+		}, mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`))
+		s.SetResourceInstanceCurrent(instAddr("two"), &states.ResourceInstanceObjectSrc{
+			AttrsJSON: []byte(`{"id":"2","ami":"ami-123456","value":"two"}`),
+			Status:    states.ObjectReady,
+		}, mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`))
+	})
+	f, err := os.Create("terraform.tfstate")
+	if err != nil {
+		t.Fatalf("failed to create temporary state file: %s", err)
+	}
+	defer f.Close()
+	if err := writeStateForTesting(testState, f); err != nil {
+		t.Fatalf("failed to write state file: %s", err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: overrides,
+			View:             view,
+		},
+	}
+	code := c.Run([]string{"-no-provenance", "-from-state", `test_instance.web["one"]`, `test_instance.web["two"]`})
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+	}
+
+	stdout := output.Stdout()
+	for _, want := range []string{
+		"for_each = local.test_instance_web_instances",
+		"each.value.value",
+		`ami   = "ami-123456"`,
+		"test_instance_web_instances = {",
+		`value = "one"`,
+		`value = "two"`,
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("missing %q in output:\n%s", want, stdout)
+		}
+	}
+	// Both instances must fold into a single block.
+	if got, want := strings.Count(stdout, `resource "test_instance" "web"`), 1; got != want {
+		t.Errorf("wrong number of resource blocks: got %d, want %d\n%s", got, want, stdout)
+	}
+}
+
+func TestAdd_wildcard(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	instAddr := func(name string) addrs.AbsResourceInstance {
+		return addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_instance",
+			Name: name,
+		}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+	}
+
+	testState := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(instAddr("one"), &states.ResourceInstanceObjectSrc{
+			AttrsJSON: []byte(`{"id":"1","value":"one"}`),
+			Status:    states.ObjectReady,
+		}, mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`))
+		s.SetResourceInstanceCurrent(instAddr("two"), &states.ResourceInstanceObjectSrc{
+			AttrsJSON: []byte(`{"id":"2","value":"two"}`),
+			Status:    states.ObjectReady,
+		}, mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`))
+	})
+	f, err := os.Create("terraform.tfstate")
+	if err != nil {
+		t.Fatalf("failed to create temporary state file: %s", err)
+	}
+	defer f.Close()
+	if err := writeStateForTesting(testState, f); err != nil {
+		t.Fatalf("failed to write state file: %s", err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("all instances of a type", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-from-state", "-auto-approve", "test_instance.*"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		for _, want := range []string{
+			`resource "test_instance" "one" {`,
+			`resource "test_instance" "two" {`,
+		} {
+			if !strings.Contains(output.Stdout(), want) {
+				t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+			}
+		}
+	})
+
+	t.Run("check summary inside a cloud run", func(t *testing.T) {
+		if err := os.Setenv(tfcRunIDEnvName, "run-CZcmD7eagjhyX0vN"); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Unsetenv(tfcRunIDEnvName)
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-from-state", "-auto-approve", "test_instance.*"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+
+		src, err := os.ReadFile(filepath.Join(DefaultDataDir, addCheckSummaryFilename))
+		if err != nil {
+			t.Fatalf("failed to read check summary: %s", err)
+		}
+		var doc struct {
+			Data struct {
+				Type       string `json:"type"`
+				Attributes struct {
+					RunID     string   `json:"run-id"`
+					Status    string   `json:"status"`
+					Resources int      `json:"resources-generated"`
+					Files     []string `json:"files-written"`
+					Warnings  int      `json:"warnings"`
+				} `json:"attributes"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(src, &doc); err != nil {
+			t.Fatalf("invalid check summary JSON: %s", err)
+		}
+		if got, want := doc.Data.Type, "add-check-summaries"; got != want {
+			t.Errorf("wrong document type %q; want %q", got, want)
+		}
+		if got, want := doc.Data.Attributes.RunID, "run-CZcmD7eagjhyX0vN"; got != want {
+			t.Errorf("wrong run ID %q; want %q", got, want)
+		}
+		if got, want := doc.Data.Attributes.Resources, 2; got != want {
+			t.Errorf("wrong resource count %d; want %d", got, want)
+		}
+		if got, want := doc.Data.Attributes.Status, "passed"; got != want {
+			t.Errorf("wrong status %q; want %q", got, want)
+		}
+	})
+
+	t.Run("approval required without -auto-approve", func(t *testing.T) {
+		// Input is always disabled under test, so the prompt degrades to an
+		// error advising -auto-approve, as it does in automation.
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-from-state", "test_instance.*"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Approval required for bulk generation") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+		if !strings.Contains(output.Stderr(), "select 2 resources") {
+			t.Errorf("missing resource count in stderr:\n%s", output.Stderr())
+		}
+		if strings.Contains(output.Stdout(), "resource \"test_instance\"") {
+			t.Errorf("configuration was generated without approval:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("no matching resources", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-from-state", "test_thing.*"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Unknown resource") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("wildcard requires -from-state", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"test_instance.*"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Wildcard address requires -from-state") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_diffConfig(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// write some state for the resource which already exists in the
+	// configuration, with attributes its (empty) block does not set
+	testState := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "exists",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				AttrsJSON:    []byte("{\"id\":\"bar\",\"ami\":\"ami-123456\",\"value\":\"bloop\"}"),
+				Status:       states.ObjectReady,
+				Dependencies: []addrs.ConfigResource{},
+			},
+			mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`),
+		)
+	})
+	f, err := os.Create("terraform.tfstate")
+	if err != nil {
+		t.Fatalf("failed to create temporary state file: %s", err)
+	}
+	defer f.Close()
+	err = writeStateForTesting(testState, f)
+	if err != nil {
+		t.Fatalf("failed to write state file: %s", err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true, Description: "the ami to use"},
+						"value": {Type: cty.String, Required: true, Description: "a value of a thing"},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("reports attributes missing from configuration", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-diff-config", "test_instance.exists"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			fmt.Println(output.Stderr())
+			t.Fatalf("wrong exit status. Got %d, want 0", code)
+		}
+
+		for _, want := range []string{
+			"# test_instance.exists is declared at main.tf:12,1",
+			"# The following attributes are set in state but missing from that configuration:",
+			"#  ami = \"ami-123456\"",
+			"#  value = \"bloop\"",
+		} {
+			if !strings.Contains(output.Stdout(), want) {
+				t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+			}
+		}
+		// id is optional+computed, so its state value is not drift.
+		if strings.Contains(output.Stdout(), "id") {
+			t.Errorf("unexpected optional+computed attribute in output:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("resource not in configuration", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-diff-config", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Resource not in configuration") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_redact(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// write some state
+	testState := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "new",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				AttrsJSON:    []byte(`{"id":"bar","ami":"ami-123456","password":"hunter2"}`),
+				Status:       states.ObjectReady,
+				Dependencies: []addrs.ConfigResource{},
+			},
+			mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`),
+		)
+	})
+	f, err := os.Create("terraform.tfstate")
+	if err != nil {
+		t.Fatalf("failed to create temporary state file: %s", err)
+	}
+	defer f.Close()
+	if err := writeStateForTesting(testState, f); err != nil {
+		t.Fatalf("failed to write state file: %s", err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":       {Type: cty.String, Optional: true, Computed: true},
+						"ami":      {Type: cty.String, Optional: true},
+						"password": {Type: cty.String, Optional: true, Sensitive: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+	profiles := map[string][]string{
+		"pci": {"password", "*secret*"},
+	}
+
+	t.Run("profile applied", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides:  overrides,
+				RedactionProfiles: profiles,
+				View:              view,
+			},
+		}
+		args := []string{"-no-provenance", "-from-state", "-redact=pci", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		if !strings.Contains(output.Stdout(), "password = var.password") {
+			t.Errorf("missing variable reference in output:\n%s", output.Stdout())
+		}
+		if !strings.Contains(output.Stdout(), `variable "password" {`) {
+			t.Errorf("missing variable declaration in output:\n%s", output.Stdout())
+		}
+		if !strings.Contains(output.Stdout(), "sensitive = true") {
+			t.Errorf("missing sensitive marker in output:\n%s", output.Stdout())
+		}
+		if strings.Contains(output.Stdout(), "hunter2") {
+			t.Errorf("redacted value leaked into output:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides:  overrides,
+				RedactionProfiles: profiles,
+				View:              view,
+			},
+		}
+		args := []string{"-from-state", "-redact=hipaa", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Unknown redaction profile") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+		if !strings.Contains(output.Stderr(), "pci") {
+			t.Errorf("error should list the configured profiles:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_fromExistingResource(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/clone"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	t.Run("clone with meta-arguments", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				View: view,
+			},
+		}
+		args := []string{"-no-provenance", "-from-existing-resource=test_instance.exists", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		for _, want := range []string{
+			`resource "test_instance" "new" {`,
+			`ami = "ami-123456"`,
+			`depends_on = [test_instance.base]`,
+			`create_before_destroy = true`,
+		} {
+			if !strings.Contains(output.Stdout(), want) {
+				t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+			}
+		}
+	})
+
+	t.Run("source not in configuration", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				View: view,
+			},
+		}
+		args := []string{"-from-existing-resource=test_instance.boop", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Resource not in configuration") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				View: view,
+			},
+		}
+		args := []string{"-from-existing-resource=test_instance.exists", "test_thing.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Resource type mismatch") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_clone(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/clone"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":  {Type: cty.String, Computed: true},
+						"ami": {Type: cty.String, Optional: true},
+					},
+				},
+			},
+		},
+	}
+
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("clone preserves source text", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-clone", "test_instance.exists", "test_instance.copy"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		for _, want := range []string{
+			`resource "test_instance" "copy" {`,
+			"# pinned to the golden image",
+			`ami = "ami-123456"`,
+			`depends_on = [test_instance.base]`,
+			`create_before_destroy = true`,
+		} {
+			if !strings.Contains(output.Stdout(), want) {
+				t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+			}
+		}
+		// The computed-only id attribute cannot be set in configuration, so
+		// it must be stripped from the copy.
+		if strings.Contains(output.Stdout(), "i-0abc123") {
+			t.Errorf("computed-only attribute survived the clone:\n%s", output.Stdout())
+		}
+		if strings.Contains(output.Stdout(), `"exists"`) {
+			t.Errorf("clone was not renamed:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("source not in configuration", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				View: view,
+			},
+		}
+		args := []string{"-clone", "test_instance.boop", "test_instance.copy"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Resource not in configuration") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				View: view,
+			},
+		}
+		args := []string{"-clone", "test_instance.exists", "test_thing.copy"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Resource type mismatch") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_hcl2json(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/clone"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":  {Type: cty.String, Computed: true},
+						"ami": {Type: cty.String, Optional: true},
+					},
+				},
+			},
+		},
+	}
+
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("prints the JSON syntax representation", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-hcl2json", "test_instance.exists"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+
+		var doc struct {
+			Resource map[string]map[string]struct {
+				AMI       string                   `json:"ami"`
+				ID        string                   `json:"id"`
+				DependsOn []string                 `json:"depends_on"`
+				Lifecycle []map[string]interface{} `json:"lifecycle"`
+			} `json:"resource"`
+		}
+		if err := json.Unmarshal([]byte(output.Stdout()), &doc); err != nil {
+			t.Fatalf("output is not valid JSON: %s\n%s", err, output.Stdout())
+		}
+		got, ok := doc.Resource["test_instance"]["exists"]
+		if !ok {
+			t.Fatalf("missing resource object in output:\n%s", output.Stdout())
+		}
+		if got.AMI != "ami-123456" {
+			t.Errorf("wrong ami. Got %q, want %q", got.AMI, "ami-123456")
+		}
+		if len(got.DependsOn) != 1 || got.DependsOn[0] != "test_instance.base" {
+			t.Errorf("wrong depends_on. Got %#v, want [test_instance.base]", got.DependsOn)
+		}
+		if len(got.Lifecycle) != 1 || got.Lifecycle[0]["create_before_destroy"] != true {
+			t.Errorf("wrong lifecycle. Got %#v", got.Lifecycle)
+		}
+	})
+
+	t.Run("resource not in configuration", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-hcl2json", "test_instance.boop"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Resource not in configuration") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("json2hcl refuses a native syntax block", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-json2hcl", "test_instance.exists"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Resource is already in native syntax") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_json2hcl(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/convert-json"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"ami":             {Type: cty.String, Optional: true},
+						"security_groups": {Type: cty.List(cty.String), Optional: true},
+					},
+				},
+			},
+		},
+	}
+
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+		},
+	}
+
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: overrides,
+			View:             view,
+		},
+	}
+	args := []string{"-json2hcl", "test_instance.web"}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+	}
+
+	for _, want := range []string{
+		`resource "test_instance" "web" {`,
+		`ami = "ami-123456"`,
+		// The "${...}" template form unwraps back to a bare expression,
+		// while the plain string next to it stays a literal.
+		`security_groups = [test_instance.base.id, "sg-123"]`,
+		"lifecycle {",
+		"create_before_destroy = true",
+	} {
+		if !strings.Contains(output.Stdout(), want) {
+			t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+		}
+	}
+	if strings.Contains(output.Stdout(), "${") {
+		t.Errorf("template wrapper survived the conversion:\n%s", output.Stdout())
+	}
+}
+
+func TestAdd_diagCodes(t *testing.T) {
+	// The flag conflict below fails during argument validation, so no
+	// configuration or provider is needed.
+	t.Run("human output carries the code in brackets", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				View: view,
+			},
+		}
+		args := []string{"-fix", "test_instance.foo"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Incompatible command-line options [TF-ADD-002]") {
+			t.Errorf("missing coded summary in stderr:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("-json-diags renders one JSON object per diagnostic", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				View: view,
+			},
+		}
+		args := []string{"-json-diags", "-fix", "test_instance.foo"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+
+		var diag struct {
+			Severity string `json:"severity"`
+			Summary  string `json:"summary"`
+			Detail   string `json:"detail"`
+			Code     string `json:"code"`
+		}
+		line := strings.TrimSpace(output.Stderr())
+		if err := json.Unmarshal([]byte(line), &diag); err != nil {
+			t.Fatalf("stderr is not a JSON diagnostic: %s\n%s", err, output.Stderr())
+		}
+		if diag.Severity != "error" {
+			t.Errorf("wrong severity %q, want %q", diag.Severity, "error")
+		}
+		// The JSON form keeps the summary text clean; the code is its own
+		// property instead of a bracket suffix.
+		if diag.Summary != "Incompatible command-line options" {
+			t.Errorf("wrong summary %q", diag.Summary)
+		}
+		if diag.Code != "TF-ADD-002" {
+			t.Errorf("wrong code %q, want %q", diag.Code, "TF-ADD-002")
+		}
+		if diag.Detail == "" {
+			t.Errorf("missing detail")
+		}
+	})
+}
+
+// A destination which cannot be written — here a directory standing where
+// An add_placeholders block in the CLI configuration replaces the null
+// placeholder for required attributes of a matching primitive type, so the
+// generated file validates while remaining obviously incomplete.
+func TestAdd_typePlaceholders(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true, Description: "the ami to use"},
+						"value": {Type: cty.String, Required: true, Description: "a value of a thing"},
+					},
+				},
+			},
+		},
+	}
+
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: overrides,
+			View:             view,
+			AddPlaceholders: map[string]interface{}{
+				"string": "CHANGE_ME",
+			},
+		},
+	}
+	args := []string{"-no-provenance", "test_instance.new"}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		fmt.Println(output.Stderr())
+		t.Fatalf("wrong exit status. Got %d, want 0", code)
+	}
+	if !strings.Contains(output.Stdout(), `value = "CHANGE_ME" # REQUIRED string`) {
+		t.Fatalf("expected placeholder for required attribute, got:\n%s", output.Stdout())
+	}
+	// Optional attributes are omitted rather than placeholder-filled, so a
+	// valid configuration stays minimal.
+	if strings.Contains(output.Stdout(), "ami") {
+		t.Fatalf("unexpected optional attribute in output:\n%s", output.Stdout())
+	}
+}
+
+// the -out file should go, but the same applies to a closed stdout pipe —
+// should produce a diagnostic with context and the distinct write-failure
+// exit status.
+func TestAdd_writeFailure(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	if err := os.Mkdir(filepath.Join(td, "generated.tf"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: &testingOverrides{
+				Providers: map[addrs.Provider]providers.Factory{
+					addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+					addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+				},
+			},
+			View: view,
+		},
+	}
+	args := []string{"-out=generated.tf", "test_instance.new"}
+	code := c.Run(args)
+	output := done(t)
+	if code != addExitWriteFailure {
+		t.Fatalf("wrong exit status. Got %d, want %d:\n%s", code, addExitWriteFailure, output.Stderr())
+	}
+	if !strings.Contains(output.Stderr(), "Failed to write generated configuration") {
+		t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+	}
+}
+
+func TestAdd_schemaOverride(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"value": {Type: cty.String, Required: true, Description: "a value of a thing"},
+					},
+				},
+			},
+		},
+	}
+
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	// The in-development schema replaces the required "value" argument with
+	// a required "region" argument.
+	overrideSrc := `{
+  "hashicorp/test": {
+    "resource_schemas": {
+      "test_instance": {
+        "block": {
+          "attributes": {
+            "id":     {"type": "string", "computed": true},
+            "region": {"type": "string", "required": true, "description": "region to deploy into"}
+          }
+        }
+      }
+    }
+  }
+}`
+	if err := os.WriteFile(filepath.Join(td, "schema-override.json"), []byte(overrideSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("override shadows provider schema", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-schema-override=schema-override.json", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		if !strings.Contains(output.Stdout(), "region = null # REQUIRED string") {
+			t.Errorf("missing overridden attribute in output:\n%s", output.Stdout())
+		}
+		if strings.Contains(output.Stdout(), "value") {
+			t.Errorf("attribute from the real provider schema survived the override:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("per-block example values populate nested blocks", func(t *testing.T) {
+		// The ingress block carries example values in its metadata, which
+		// render in place of zero placeholders.
+		exampleSrc := `{
+  "hashicorp/test": {
+    "resource_schemas": {
+      "test_instance": {
+        "block": {
+          "attributes": {
+            "value": {"type": "string", "required": true}
+          },
+          "block_types": {
+            "ingress": {
+              "nesting_mode": "list",
+              "min_items": 1,
+              "block": {
+                "attributes": {
+                  "from_port": {"type": "number", "required": true},
+                  "protocol":  {"type": "string", "required": true},
+                  "self":      {"type": "bool", "optional": true}
+                }
+              },
+              "examples": {
+                "from_port": 443,
+                "protocol": "tcp"
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+		if err := os.WriteFile(filepath.Join(td, "examples.json"), []byte(exampleSrc), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-no-provenance", "-schema-override=examples.json", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		for _, want := range []string{
+			"from_port = 443",
+			`protocol  = "tcp"`,
+		} {
+			if !strings.Contains(output.Stdout(), want) {
+				t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+			}
+		}
+		// Attributes without an example keep the usual placeholder.
+		if !strings.Contains(output.Stdout(), "value = null") {
+			t.Errorf("top-level placeholder missing from output:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("example for an undeclared attribute is rejected", func(t *testing.T) {
+		badSrc := `{
+  "hashicorp/test": {
+    "resource_schemas": {
+      "test_instance": {
+        "block": {
+          "attributes": {
+            "value": {"type": "string", "required": true}
+          },
+          "block_types": {
+            "ingress": {
+              "nesting_mode": "list",
+              "block": {
+                "attributes": {
+                  "from_port": {"type": "number", "required": true}
+                }
+              },
+              "examples": {
+                "to_port": 443
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+		if err := os.WriteFile(filepath.Join(td, "bad-examples.json"), []byte(badSrc), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-schema-override=bad-examples.json", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		// Diagnostics are word-wrapped for the terminal, so only match a
+		// fragment which fits on one line.
+		if !strings.Contains(output.Stderr(), "example value for undeclared attribute") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("invalid override file", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(td, "broken.json"), []byte("{"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		args := []string{"-schema-override=broken.json", "test_instance.new"}
+		code := c.Run(args)
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Invalid schema override file") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_moduleOutputValues(t *testing.T) {
+	// Note that only the root module's output values survive a round trip
+	// through a state file, so this index is only populated when the loaded
+	// state carries child module outputs; see states/statefile.
+	state := states.NewState()
+	child := state.EnsureModule(addrs.RootModuleInstance.Child("network", addrs.NoKey))
+	child.SetOutputValue("subnet_id", cty.StringVal("subnet-123"), false)
+	child.SetOutputValue("secret", cty.StringVal("hunter2"), true)
+	child.SetOutputValue("missing", cty.NullVal(cty.String), false)
+	grandchild := state.EnsureModule(addrs.RootModuleInstance.Child("network", addrs.NoKey).Child("nested", addrs.NoKey))
+	grandchild.SetOutputValue("deep", cty.StringVal("nope"), false)
+	root := state.EnsureModule(addrs.RootModuleInstance)
+	root.SetOutputValue("root_out", cty.StringVal("nope"), false)
+
+	got := moduleOutputValues(state)
+	want := map[string]cty.Value{
+		"module.network.subnet_id": cty.StringVal("subnet-123"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("wrong index: %#v", got)
+	}
+	for expr, val := range want {
+		if gotVal, ok := got[expr]; !ok || !gotVal.RawEquals(val) {
+			t.Errorf("wrong value for %s: %#v", expr, got[expr])
+		}
+	}
+}
+
+// Verify that the command resolves the configuration from the current
+// working directory, which is how the global -chdir option takes effect:
+// main.go switches the whole process into the target directory before the
+// command runs, so the config root may be an arbitrary nested path.
+func TestAdd_chdir(t *testing.T) {
+	td := tempDir(t)
+	configDir := filepath.Join(td, "envs", "prod")
+	if err := os.MkdirAll(filepath.Dir(configDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	testCopyDir(t, testFixturePath("add/basic"), configDir)
+	defer os.RemoveAll(td)
+	defer testChdir(t, configDir)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: overrides,
+			View:             view,
+		},
+	}
+	code := c.Run([]string{"-no-provenance", "-out=add.tf", "test_instance.new"})
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+	}
+
+	// The output file must land in the directory we switched into, next to
+	// the configuration, not anywhere relative to the original cwd.
+	result, err := os.ReadFile(filepath.Join(configDir, "add.tf"))
+	if err != nil {
+		t.Fatalf("error reading result file: %s", err)
+	}
+	if !strings.Contains(string(result), `resource "test_instance" "new" {`) {
+		t.Fatalf("missing generated block in result:\n%s", result)
+	}
+}
+
+func TestAdd_excluded(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("matching pattern refuses generation", func(t *testing.T) {
+		if err := os.WriteFile(genExcludeFilename, []byte("# owned by the platform repo\ntest_*\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(genExcludeFilename)
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Resource excluded from generation") {
+			t.Fatalf("missing exclusion diagnostic in output:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("non-matching pattern generates normally", func(t *testing.T) {
+		if err := os.WriteFile(genExcludeFilename, []byte("aws_iam_*\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(genExcludeFilename)
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		if !strings.Contains(output.Stdout(), `resource "test_instance" "new" {`) {
+			t.Fatalf("missing generated block in output:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("invalid pattern is an error", func(t *testing.T) {
+		if err := os.WriteFile(genExcludeFilename, []byte("test_[\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(genExcludeFilename)
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Invalid generation exclude pattern") {
+			t.Fatalf("missing pattern diagnostic in output:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_from_state(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// write some state
+	testState := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "new",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				AttrsJSON:    []byte("{\"id\":\"bar\",\"ami\":\"ami-123456\",\"disks\":[{\"mount_point\":\"diska\",\"size\":null}],\"value\":\"bloop\"}"),
+				Status:       states.ObjectReady,
+				Dependencies: []addrs.ConfigResource{},
+			},
+			mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`),
+		)
+	})
+	f, err := os.Create("terraform.tfstate")
+	if err != nil {
+		t.Fatalf("failed to create temporary state file: %s", err)
+	}
+	defer f.Close()
+	err = writeStateForTesting(testState, f)
+	if err != nil {
+		t.Fatalf("failed to write state file: %s", err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true, Description: "the ami to use"},
+						"value": {Type: cty.String, Required: true, Description: "a value of a thing"},
+						"disks": {
+							NestedType: &configschema.Object{
+								Nesting: configschema.NestingList,
+								Attributes: map[string]*configschema.Attribute{
+									"size":        {Type: cty.String, Optional: true},
+									"mount_point": {Type: cty.String, Required: true},
+								},
+							},
+							Optional: true,
+						},
+					},
+					BlockTypes: map[string]*configschema.NestedBlock{
+						"network_interface": {
+							Nesting:  configschema.NestingList,
+							MinItems: 1,
+							Block: configschema.Block{
+								Attributes: map[string]*configschema.Attribute{
+									"device_index": {Type: cty.String, Optional: true},
+									"description":  {Type: cty.String, Optional: true},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: overrides,
+			View:             view,
+		},
+	}
+
+	args := []string{"-no-provenance", "-from-state", "test_instance.new"}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		fmt.Println(output.Stderr())
+		t.Fatalf("wrong exit status. Got %d, want 0", code)
+	}
+
+	expected := `# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+resource "test_instance" "new" {
+  ami = "ami-123456"
+  disks = [
+    {
+      mount_point = "diska"
+      size        = null
+    },
+  ]
+  value = "bloop"
+}
+`
+
+	if !cmp.Equal(output.Stdout(), expected) {
+		t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output.Stdout()))
+	}
+
+	if _, err := os.Stat(filepath.Join(td, ".terraform.tfstate.lock.info")); !os.IsNotExist(err) {
+		t.Fatal("state left locked after add")
+	}
+}
+
+// An address that is not in the state at all must be reported as an error,
+// not crash the command when the main loop reaches the missing resource.
+func TestAdd_from_state_missingResource(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// write some state containing only test_instance.new
+	testState := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "new",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				AttrsJSON:    []byte("{\"id\":\"bar\",\"ami\":\"ami-123456\",\"value\":\"bloop\"}"),
+				Status:       states.ObjectReady,
+				Dependencies: []addrs.ConfigResource{},
+			},
+			mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`),
+		)
+	})
+	f, err := os.Create("terraform.tfstate")
+	if err != nil {
+		t.Fatalf("failed to create temporary state file: %s", err)
+	}
+	defer f.Close()
+	err = writeStateForTesting(testState, f)
+	if err != nil {
+		t.Fatalf("failed to write state file: %s", err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: overrides,
+			View:             view,
+		},
+	}
+
+	args := []string{"-no-provenance", "-from-state", "test_instance.missing"}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("wrong exit status. Got %d, want 1", code)
+	}
+	if !strings.Contains(output.Stderr(), "No state for resource") {
+		t.Fatalf("missing expected error message:\n%s", output.Stderr())
+	}
+	if !strings.Contains(output.Stderr(), "test_instance.missing") {
+		t.Fatalf("error message does not name the missing address:\n%s", output.Stderr())
+	}
+}
+
+func TestAdd_stateSerial(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// write some state; writeStateForTesting always records serial 0
+	testState := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "test_instance",
+				Name: "new",
+			}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{
+				AttrsJSON: []byte(`{"id":"bar","value":"bloop"}`),
+				Status:    states.ObjectReady,
+			},
+			mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`),
+		)
+	})
+	f, err := os.Create("terraform.tfstate")
+	if err != nil {
+		t.Fatalf("failed to create temporary state file: %s", err)
+	}
+	defer f.Close()
+	if err := writeStateForTesting(testState, f); err != nil {
+		t.Fatalf("failed to write state file: %s", err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("matching serial", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-from-state", "-state-serial=0", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		if !strings.Contains(output.Stdout(), `resource "test_instance" "new" {`) {
+			t.Errorf("missing generated block in output:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("mismatched serial", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-from-state", "-state-serial=5", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "State serial mismatch") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+		if !strings.Contains(output.Stderr(), "has serial 0") || !strings.Contains(output.Stderr(), "requires serial 5") {
+			t.Errorf("missing serial detail in stderr:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("requires -from-state or -diff-config", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-state-serial=0", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Incompatible command-line options") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_stateReadOnce(t *testing.T) {
+	// getState reuses the first snapshot for the rest of the run, so that
+	// multi-address invocations read the backend state once rather than once
+	// per address. With the cache populated the backend must never be
+	// consulted again, which the nil backend here would otherwise turn into
+	// a crash.
+	cached := states.BuildState(func(s *states.SyncState) {})
+	c := &AddCommand{stateCache: cached}
+
+	got, diags := c.getState(nil)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+	if got != cached {
+		t.Fatal("got a different state than the cached snapshot")
+	}
+}
+
+func TestAdd_schemaUpgrade(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// The provider's current schema is version 2, where the old "disk_gb"
+	// attribute has been renamed to "size".
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Version: 2,
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"value": {Type: cty.String, Required: true},
+						"size":  {Type: cty.Number, Optional: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	writeState := func(t *testing.T, schemaVersion uint64, attrsJSON string) {
+		t.Helper()
+		testState := states.BuildState(func(s *states.SyncState) {
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_instance",
+					Name: "new",
+				}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+				&states.ResourceInstanceObjectSrc{
+					SchemaVersion: schemaVersion,
+					AttrsJSON:     []byte(attrsJSON),
+					Status:        states.ObjectReady,
+				},
+				mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`),
+			)
+		})
+		f, err := os.Create("terraform.tfstate")
+		if err != nil {
+			t.Fatalf("failed to create temporary state file: %s", err)
+		}
+		defer f.Close()
+		if err := writeStateForTesting(testState, f); err != nil {
+			t.Fatalf("failed to write state file: %s", err)
+		}
+	}
+
+	t.Run("older state is upgraded before rendering", func(t *testing.T) {
+		writeState(t, 1, `{"id":"bar","value":"bloop","disk_gb":50}`)
+		p.UpgradeResourceStateCalled = false
+		p.UpgradeResourceStateFn = func(req providers.UpgradeResourceStateRequest) providers.UpgradeResourceStateResponse {
+			if req.Version != 1 {
+				t.Errorf("wrong version in upgrade request: got %d, want 1", req.Version)
+			}
+			return providers.UpgradeResourceStateResponse{
+				UpgradedState: cty.ObjectVal(map[string]cty.Value{
+					"id":    cty.StringVal("bar"),
+					"value": cty.StringVal("bloop"),
+					"size":  cty.NumberIntVal(50),
+				}),
+			}
+		}
+		defer func() { p.UpgradeResourceStateFn = nil }()
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-from-state", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		if !p.UpgradeResourceStateCalled {
+			t.Error("UpgradeResourceState was never called")
+		}
+		for _, want := range []string{`value = "bloop"`, "size  = 50"} {
+			if !strings.Contains(output.Stdout(), want) {
+				t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+			}
+		}
+	})
+
+	t.Run("state newer than the provider", func(t *testing.T) {
+		writeState(t, 5, `{"id":"bar","value":"bloop","size":50}`)
+		p.UpgradeResourceStateCalled = false
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-from-state", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "newer provider version") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+		if p.UpgradeResourceStateCalled {
+			t.Error("UpgradeResourceState should not be called when the state is newer than the provider")
+		}
+	})
+
+	t.Run("upgrade failure is reported", func(t *testing.T) {
+		writeState(t, 1, `{"id":"bar","value":"bloop","disk_gb":50}`)
+		p.UpgradeResourceStateFn = func(req providers.UpgradeResourceStateRequest) providers.UpgradeResourceStateResponse {
+			var resp providers.UpgradeResourceStateResponse
+			resp.Diagnostics = resp.Diagnostics.Append(errors.New("unsupported state version"))
+			return resp
+		}
+		defer func() { p.UpgradeResourceStateFn = nil }()
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-from-state", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "unsupported state version") {
+			t.Errorf("missing provider error in stderr:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_schemaFetchThrottled(t *testing.T) {
+	throttled := map[string]bool{
+		"ThrottlingException: Rate exceeded":           true,
+		"too many requests":                            true,
+		"the API returned status 429":                  true,
+		"rate limit exceeded for schema reads":         true,
+		"connection refused":                           false,
+		"the plugin crashed during GetProviderSchema":  false,
+		"resource type not supported by this provider": false,
+	}
+	for text, want := range throttled {
+		var diags tfdiags.Diagnostics
+		diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "Failed to read provider schema", text))
+		if got := schemaFetchThrottled(diags); got != want {
+			t.Errorf("schemaFetchThrottled(%q) = %t, want %t", text, got, want)
+		}
+	}
+}
+
+func TestAdd_backendScaffold(t *testing.T) {
+	t.Run("known backend type", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				View: view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-type=backend", "s3"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		stdout := output.Stdout()
+		if !strings.Contains(stdout, "terraform {") || !strings.Contains(stdout, `backend "s3" {`) {
+			t.Errorf("missing backend block in output:\n%s", stdout)
+		}
+		for _, attr := range []string{"bucket", "key"} {
+			if !strings.Contains(stdout, attr) {
+				t.Errorf("missing required attribute %q in output:\n%s", attr, stdout)
+			}
+		}
+	})
+
+	t.Run("unknown backend type", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				View: view,
+			},
+		}
+		code := c.Run([]string{"-type=backend", "floppydisk"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Unsupported backend type") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_valuesStdin(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true},
+						"count": {Type: cty.Number, Optional: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("fills placeholders", func(t *testing.T) {
+		defer testStdinPipe(t, strings.NewReader("ami=ami-123 value=foo"))()
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-optional", "-values-stdin", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		for _, want := range []string{`= "ami-123"`, `= "foo"`} {
+			if !strings.Contains(output.Stdout(), want) {
+				t.Errorf("missing %s in output:\n%s", want, output.Stdout())
+			}
+		}
+	})
+
+	t.Run("expression values are written verbatim", func(t *testing.T) {
+		defer testStdinPipe(t, strings.NewReader("ami=@expr:aws_instance.other.id value=foo"))()
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-optional", "-values-stdin", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		if !strings.Contains(output.Stdout(), "= aws_instance.other.id") {
+			t.Errorf("missing verbatim expression in output:\n%s", output.Stdout())
+		}
+		if strings.Contains(output.Stdout(), `"@expr:`) || strings.Contains(output.Stdout(), `"aws_instance.other.id"`) {
+			t.Errorf("expression was quoted instead of written verbatim:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("invalid expression value", func(t *testing.T) {
+		defer testStdinPipe(t, strings.NewReader("ami=@expr:count."))()
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-optional", "-values-stdin", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Invalid attribute expression") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("value of the wrong type", func(t *testing.T) {
+		defer testStdinPipe(t, strings.NewReader("count=many value=foo"))()
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-optional", "-values-stdin", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Invalid attribute value") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("unknown attribute", func(t *testing.T) {
+		defer testStdinPipe(t, strings.NewReader("flavor=large"))()
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-values-stdin", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Unknown attribute") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("malformed pair", func(t *testing.T) {
+		defer testStdinPipe(t, strings.NewReader("ami ami-123"))()
+
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-values-stdin", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Invalid value on standard input") {
+			t.Errorf("missing expected error in stderr:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_testFixture(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: overrides,
+			View:             view,
+		},
+	}
+	code := c.Run([]string{"-no-provenance", "-type=test", "test_instance.main"})
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+	}
+
+	stdout := output.Stdout()
+	for _, want := range []string{
+		`source = "terraform.io/builtin/test"`,
+		`provider "test" {`,
+		"variable \"value\" {\n  type = string\n}",
+		"resource \"test_instance\" \"main\" {\n  value = var.value\n}",
+		`resource "test_assertions" "main" {`,
+		`component = "main"`,
+		"got         = test_instance.main.value",
+		"want        = var.value",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("missing %q in output:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestAdd_path(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":  {Type: cty.String, Optional: true, Computed: true},
+						"ami": {Type: cty.String, Required: true},
+					},
+					BlockTypes: map[string]*configschema.NestedBlock{
+						"network_interface": {
+							Nesting: configschema.NestingList,
+							Block: configschema.Block{
+								Attributes: map[string]*configschema.Attribute{
+									"subnet_id":   {Type: cty.String, Required: true},
+									"description": {Type: cty.String, Optional: true},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("renders just the named nested block", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-path=network_interface", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+
+		stdout := output.Stdout()
+		if !strings.Contains(stdout, "network_interface {") {
+			t.Errorf("missing network_interface block in output:\n%s", stdout)
+		}
+		if !strings.Contains(stdout, "subnet_id = null") {
+			t.Errorf("missing required nested argument in output:\n%s", stdout)
+		}
+		if strings.Contains(stdout, "description") {
+			t.Errorf("optional nested argument rendered without -optional:\n%s", stdout)
+		}
+		if strings.Contains(stdout, `resource "test_instance"`) {
+			t.Errorf("unexpected resource block wrapper in output:\n%s", stdout)
+		}
+	})
+
+	t.Run("descends into a nested block", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-path=network_interface.subnet_id", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+
+		stdout := output.Stdout()
+		if !strings.Contains(stdout, "subnet_id = null") {
+			t.Errorf("missing attribute in output:\n%s", stdout)
+		}
+		if strings.Contains(stdout, "network_interface {") {
+			t.Errorf("unexpected enclosing block in output:\n%s", stdout)
+		}
+	})
+
+	t.Run("unknown path segment suggests the closest name", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-path=network_interfaces", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1:\n%s", code, output.Stdout())
+		}
+		// The renderer may wrap the message, so check the two pieces of
+		// the suggestion separately.
+		stderr := output.Stderr()
+		if !strings.Contains(stderr, "Did you mean") || !strings.Contains(stderr, `"network_interface"`) {
+			t.Errorf("missing suggestion in error output:\n%s", stderr)
+		}
+	})
+
+	t.Run("cannot descend into a plain attribute", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-path=ami.nope", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1:\n%s", code, output.Stdout())
+		}
+		if !strings.Contains(output.Stderr(), "it is a plain attribute") {
+			t.Errorf("missing plain-attribute error in output:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAdd_providerAliasAmbiguity(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/provider-aliases"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":  {Type: cty.String, Optional: true, Computed: true},
+						"ami": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("multiple aliases without a choice is an error", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-provider=provider[\"registry.terraform.io/hashicorp/test\"]", "test_instance.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1:\n%s", code, output.Stdout())
+		}
+
+		stderr := output.Stderr()
+		for _, want := range []string{
+			"Ambiguous provider configuration",
+			"test.east",
+			"test.west",
+			"-provider-alias=default",
+		} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("missing %q in error output:\n%s", want, stderr)
+			}
+		}
+	})
+
+	t.Run("-provider-alias picks one of the aliases", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-provider=provider[\"registry.terraform.io/hashicorp/test\"]", "-provider-alias=east", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		if !strings.Contains(output.Stdout(), "provider = test.east") {
+			t.Errorf("missing provider meta-argument in output:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("-provider-alias=default keeps the default configuration", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-provider=provider[\"registry.terraform.io/hashicorp/test\"]", "-provider-alias=default", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0:\n%s", code, output.Stderr())
+		}
+		if strings.Contains(output.Stdout(), "provider =") {
+			t.Errorf("unexpected provider meta-argument in output:\n%s", output.Stdout())
+		}
+	})
+}
+
+func TestAdd_outIdempotent(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"ami":   {Type: cty.String, Optional: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+	run := func(t *testing.T, args ...string) (int, string) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run(args)
+		return code, done(t).Stderr()
+	}
+
+	code, stderr := run(t, "-out=generated.tf", "test_instance.new")
+	if code != 0 {
+		t.Fatalf("first run failed with status %d:\n%s", code, stderr)
+	}
+	written, err := os.ReadFile("generated.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An identical rerun must change nothing and say so.
+	code, stderr = run(t, "-out=generated.tf", "test_instance.new")
+	if code != 0 {
+		t.Fatalf("identical rerun failed with status %d:\n%s", code, stderr)
+	}
+	if !strings.Contains(stderr, "up to date") {
+		t.Errorf("missing up-to-date notice in stderr:\n%s", stderr)
+	}
+	after, err := os.ReadFile("generated.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(written) {
+		t.Errorf("rerun modified the output file:\ngot:\n%s\nwant:\n%s", after, written)
+	}
+
+	// A rerun which would generate a different block reports drift instead
+	// of appending a duplicate.
+	code, stderr = run(t, "-out=generated.tf", "-optional", "test_instance.new")
+	if code != 2 {
+		t.Fatalf("wrong exit status for drifted rerun. Got %d, want 2:\n%s", code, stderr)
+	}
+	if !strings.Contains(stderr, "Existing block differs from generated configuration") {
+		t.Errorf("missing drift error in stderr:\n%s", stderr)
+	}
+	if !strings.Contains(stderr, "+") || !strings.Contains(stderr, "ami") {
+		t.Errorf("missing diff detail in stderr:\n%s", stderr)
+	}
+	after, err = os.ReadFile("generated.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(written) {
+		t.Errorf("drifted rerun modified the output file:\ngot:\n%s\nwant:\n%s", after, written)
+	}
+}
+
+// A provider which fails to report its schema normally fails context creation
+// entirely; when the broken provider is not the one the requested resource
+// belongs to, add fetches the target provider's schema directly and reports
+// the failure as a warning instead.
+func TestAdd_brokenUnrelatedProvider(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/broken-provider"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	// The fallback only applies to an initialized working directory; an
+	// uninitialized one gets init advice instead.
+	if err := os.MkdirAll(filepath.Join(td, ".terraform"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	broken := testProvider()
+	brokenResp := &providers.GetProviderSchemaResponse{}
+	brokenResp.Diagnostics = brokenResp.Diagnostics.Append(errors.New("the broken provider is broken"))
+	broken.GetProviderSchemaResponse = brokenResp
+
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):   providers.FactoryFixed(p),
+			addrs.NewDefaultProvider("broken"): providers.FactoryFixed(broken),
+		},
+	}
+
+	t.Run("unrelated provider broken", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "test_instance.new"})
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("wrong exit status. Got %d, want 0\nstderr:\n%s", code, output.Stderr())
+		}
+		if !strings.Contains(output.Stdout(), `resource "test_instance" "new" {`) {
+			t.Fatalf("missing generated block in output:\n%s", output.Stdout())
+		}
+		if !strings.Contains(output.Stdout(), "Some provider schemas could not be loaded") {
+			t.Fatalf("missing schema failure warning in output:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("target provider broken", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"broken_thing.new"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1", code)
+		}
+		if !strings.Contains(output.Stderr(), "Could not load plugin") {
+			t.Fatalf("missing plugin error in output:\n%s", output.Stderr())
+		}
+	})
+}
+
+// When the dependency lock file records which provider version is installed,
+// the required_providers scaffold for an undeclared provider pins that
+// version as a floor, since the generated configuration follows the installed
+// schema and older releases may not support it.
+func TestAdd_versionFloor(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/undeclared"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	lock := `provider "registry.terraform.io/hashicorp/test" {
+  version = "2.1.0"
+}
+`
+	if err := os.WriteFile(filepath.Join(td, ".terraform.lock.hcl"), []byte(lock), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	view, done := testView(t)
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: &testingOverrides{
+				Providers: map[addrs.Provider]providers.Factory{
+					addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+				},
+			},
+			View: view,
+		},
+	}
+	code := c.Run([]string{"-no-provenance", "test_instance.new"})
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("wrong exit status. Got %d, want 0\nstderr:\n%s", code, output.Stderr())
+	}
+
+	for _, want := range []string{
+		`source = "hashicorp/test"`,
+		`version = ">= 2.1.0"`,
+	} {
+		if !strings.Contains(output.Stdout(), want) {
+			t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+		}
+	}
+}
+
+// The full schema of each provider is fetched from its plugin at most once
+// per run; later lookups for the same provider are served from the indexed
+// cache rather than another GetProviderSchema round-trip.
+func TestAdd_schemaCache(t *testing.T) {
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	c := &AddCommand{
+		Meta: Meta{
+			testingOverrides: &testingOverrides{
+				Providers: map[addrs.Provider]providers.Factory{
+					addrs.NewDefaultProvider("test"): providers.FactoryFixed(p),
+				},
+			},
+		},
+	}
+
+	provider := addrs.NewDefaultProvider("test")
+	first, diags := c.zeroConfigSchemas(provider, nil)
+	if diags.HasErrors() {
+		t.Fatal(diags.Err())
+	}
+	if !p.GetProviderSchemaCalled {
+		t.Fatal("first lookup did not reach the provider")
+	}
+
+	p.GetProviderSchemaCalled = false
+	second, diags := c.zeroConfigSchemas(provider, nil)
+	if diags.HasErrors() {
+		t.Fatal(diags.Err())
+	}
+	if p.GetProviderSchemaCalled {
+		t.Error("second lookup reached the provider instead of the cache")
+	}
+	if second.Providers[provider] != first.Providers[provider] {
+		t.Error("cached lookup returned a different schema object")
+	}
+}
+
+// When the dependency lock file does not cover a provider, its schema is
+// served by the newest locally cached package whose version satisfies the
+// configuration's constraint, not whichever package happens to be found
+// first.
+func TestAdd_cachedVersionSelection(t *testing.T) {
+	td := tempDir(t)
+	platform := getproviders.CurrentPlatform.String()
+	for _, v := range []string{"1.4.0", "2.3.0"} {
+		dir := filepath.Join(td, ".terraform", "providers", "registry.terraform.io", "hashicorp", "test", v, platform)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	provider := addrs.NewDefaultProvider("test")
+
+	t.Run("unconstrained selects the newest", func(t *testing.T) {
+		c := &AddCommand{}
+		entry := c.selectCachedProvider(provider, nil)
+		if entry == nil {
+			t.Fatal("no cache entry selected")
+		}
+		if got, want := entry.Version.String(), "2.3.0"; got != want {
+			t.Errorf("selected version %s; want %s", got, want)
+		}
+	})
+
+	t.Run("constraint steers the selection", func(t *testing.T) {
+		c := &AddCommand{}
+		entry := c.selectCachedProvider(provider, getproviders.MustParseVersionConstraints("~> 1.0"))
+		if entry == nil {
+			t.Fatal("no cache entry selected")
+		}
+		if got, want := entry.Version.String(), "1.4.0"; got != want {
+			t.Errorf("selected version %s; want %s", got, want)
+		}
+	})
+
+	t.Run("unsatisfiable constraint selects nothing", func(t *testing.T) {
+		c := &AddCommand{}
+		if entry := c.selectCachedProvider(provider, getproviders.MustParseVersionConstraints("< 1.0.0")); entry != nil {
+			t.Errorf("selected version %s; want none", entry.Version)
+		}
+	})
+}
+
+// A provider which is not installed in the working directory can still serve
+// schemas from the shared global plugin cache, so quick scaffolding works in
+// a directory that has never been initialized. The working directory's own
+// cache is preferred when it has a suitable package.
+func TestAdd_globalCacheFallback(t *testing.T) {
+	td := tempDir(t)
+	globalCache := filepath.Join(td, "plugin-cache")
+	platform := getproviders.CurrentPlatform.String()
+	if err := os.MkdirAll(filepath.Join(globalCache, "registry.terraform.io", "hashicorp", "test", "2.3.0", platform), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	provider := addrs.NewDefaultProvider("test")
+
+	t.Run("global cache serves an uninstalled provider", func(t *testing.T) {
+		c := &AddCommand{Meta: Meta{PluginCacheDir: globalCache}}
+		entry := c.selectCachedProvider(provider, nil)
+		if entry == nil {
+			t.Fatal("no cache entry selected")
+		}
+		if got, want := entry.Version.String(), "2.3.0"; got != want {
+			t.Errorf("selected version %s; want %s", got, want)
+		}
+	})
+
+	t.Run("no global cache configured selects nothing", func(t *testing.T) {
+		c := &AddCommand{}
+		if entry := c.selectCachedProvider(provider, nil); entry != nil {
+			t.Errorf("selected version %s; want none", entry.Version)
+		}
+	})
+
+	t.Run("local cache wins over the global cache", func(t *testing.T) {
+		localDir := filepath.Join(td, ".terraform", "providers", "registry.terraform.io", "hashicorp", "test", "1.4.0", platform)
+		if err := os.MkdirAll(localDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(filepath.Join(td, ".terraform"))
+
+		c := &AddCommand{Meta: Meta{PluginCacheDir: globalCache}}
+		entry := c.selectCachedProvider(provider, nil)
+		if entry == nil {
+			t.Fatal("no cache entry selected")
+		}
+		if got, want := entry.Version.String(), "1.4.0"; got != want {
+			t.Errorf("selected version %s; want %s", got, want)
+		}
+	})
+}
+
+// In a bulk run, a resource whose schema is missing is skipped with a
+// diagnostic while the rest still generate, and the run exits with the
+// distinct partial-success status; only when every selected resource fails
+// does the run exit with a plain failure.
+func TestAdd_bulkPartialFailure(t *testing.T) {
+	td := tempDir(t)
+	testCopyDir(t, testFixturePath("add/basic"), td)
+	defer os.RemoveAll(td)
+	defer testChdir(t, td)()
+
+	instAddr := func(ty, name string) addrs.AbsResourceInstance {
+		return addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: ty,
+			Name: name,
+		}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+	}
+
+	testState := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(instAddr("test_instance", "one"), &states.ResourceInstanceObjectSrc{
+			AttrsJSON: []byte(`{"id":"1","value":"one"}`),
+			Status:    states.ObjectReady,
+		}, mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`))
+		s.SetResourceInstanceCurrent(instAddr("test_instance", "two"), &states.ResourceInstanceObjectSrc{
+			AttrsJSON: []byte(`{"id":"2","value":"two"}`),
+			Status:    states.ObjectReady,
+		}, mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`))
+		s.SetResourceInstanceCurrent(instAddr("test_gone", "broken"), &states.ResourceInstanceObjectSrc{
+			AttrsJSON: []byte(`{"id":"3"}`),
+			Status:    states.ObjectReady,
+		}, mustProviderConfig(`provider["registry.terraform.io/hashicorp/test"]`))
+	})
+	f, err := os.Create("terraform.tfstate")
+	if err != nil {
+		t.Fatalf("failed to create temporary state file: %s", err)
+	}
+	defer f.Close()
+	if err := writeStateForTesting(testState, f); err != nil {
+		t.Fatalf("failed to write state file: %s", err)
+	}
+
+	// The provider has no schema for test_gone, so that resource cannot
+	// render.
+	p := testProvider()
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":    {Type: cty.String, Optional: true, Computed: true},
+						"value": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+	overrides := &testingOverrides{
+		Providers: map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("test"):                                providers.FactoryFixed(p),
+			addrs.NewProvider("registry.terraform.io", "happycorp", "test"): providers.FactoryFixed(p),
+		},
+	}
+
+	t.Run("some resources fail", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-no-provenance", "-from-state", "-auto-approve", "test_instance.*", "test_gone.*"})
+		output := done(t)
+		if code != addExitPartialFailure {
+			t.Fatalf("wrong exit status. Got %d, want %d\nstderr:\n%s", code, addExitPartialFailure, output.Stderr())
+		}
+		for _, want := range []string{
+			`resource "test_instance" "one" {`,
+			`resource "test_instance" "two" {`,
+		} {
+			if !strings.Contains(output.Stdout(), want) {
+				t.Errorf("missing %q in output:\n%s", want, output.Stdout())
+			}
+		}
+		if !strings.Contains(output.Stderr(), "Missing resource schema from provider") {
+			t.Errorf("missing schema diagnostic in output:\n%s", output.Stderr())
+		}
+	})
+
+	t.Run("every resource fails", func(t *testing.T) {
+		view, done := testView(t)
+		c := &AddCommand{
+			Meta: Meta{
+				testingOverrides: overrides,
+				View:             view,
+			},
+		}
+		code := c.Run([]string{"-from-state", "-auto-approve", "test_gone.*"})
+		output := done(t)
+		if code != 1 {
+			t.Fatalf("wrong exit status. Got %d, want 1\nstderr:\n%s", code, output.Stderr())
+		}
+	})
+}
+
+// indexBenchConfig builds a synthetic configuration tree of chains nested
+// chains modules wide and depth modules deep (so chains*depth modules in
+// all), each declaring resourcesPer managed resources, together with the
+// instance addresses of every resource in it. Building the tree directly
+// keeps the traversal tests and benchmarks independent of fixture loading.
+func indexBenchConfig(chains, depth, resourcesPer int) (*configs.Config, []addrs.AbsResourceInstance) {
+	root := &configs.Config{
+		Module:   &configs.Module{ManagedResources: map[string]*configs.Resource{}},
+		Children: map[string]*configs.Config{},
+	}
+	root.Root = root
+
+	var targets []addrs.AbsResourceInstance
+	for i := 0; i < chains; i++ {
+		parent := root
+		var path addrs.Module
+		for d := 0; d < depth; d++ {
+			name := fmt.Sprintf("mod%03d_%03d", i, d)
+			path = append(path, name)
+			child := &configs.Config{
+				Root:     root,
+				Parent:   parent,
+				Path:     append(addrs.Module{}, path...),
+				Module:   &configs.Module{ManagedResources: map[string]*configs.Resource{}},
+				Children: map[string]*configs.Config{},
+			}
+			parent.Children[name] = child
+			for j := 0; j < resourcesPer; j++ {
+				rs := &configs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_instance",
+					Name: fmt.Sprintf("r%03d", j),
+				}
+				child.Module.ManagedResources[rs.Addr().String()] = rs
+				targets = append(targets, rs.Addr().Instance(addrs.NoKey).Absolute(child.Path.UnkeyedInstanceShim()))
+			}
+			parent = child
+		}
+	}
+	return root, targets
+}
+
+func TestIndexConfigResources(t *testing.T) {
+	config, targets := indexBenchConfig(10, 10, 5)
+	idx := indexConfigResources(config)
+
+	// Every resource must resolve to the same block the per-address tree
+	// walk would find.
+	for _, addr := range targets {
+		want := config.Descendent(addr.Module.Module()).Module.ManagedResources[addr.ContainingResource().Resource.String()]
+		if got := idx.managedResource(addr); got != want {
+			t.Fatalf("wrong block for %s: got %#v, want %#v", addr, got, want)
+		}
+	}
+
+	missingResource := addrs.Resource{Mode: addrs.ManagedResourceMode, Type: "test_instance", Name: "missing"}.
+		Instance(addrs.NoKey).Absolute(addrs.Module{"mod000_000"}.UnkeyedInstanceShim())
+	if got := idx.managedResource(missingResource); got != nil {
+		t.Errorf("unexpected block for an undeclared resource: %#v", got)
+	}
+	missingModule := addrs.Resource{Mode: addrs.ManagedResourceMode, Type: "test_instance", Name: "r000"}.
+		Instance(addrs.NoKey).Absolute(addrs.Module{"madeup"}.UnkeyedInstanceShim())
+	if got := idx.managedResource(missingModule); got != nil {
+		t.Errorf("unexpected block for an undeclared module: %#v", got)
+	}
+
+	if got := idx.module(addrs.Module{"mod004_000"}); got != config.Children["mod004_000"].Module {
+		t.Errorf("wrong module for mod004_000: %#v", got)
+	}
+	if got := idx.module(nil); got != config.Module {
+		t.Errorf("wrong module for the root path: %#v", got)
+	}
+	if got := idx.module(addrs.Module{"madeup"}); got != nil {
+		t.Errorf("unexpected module for an undeclared path: %#v", got)
+	}
+
+	// A missing configuration resolves nothing rather than panicking.
+	empty := indexConfigResources(nil)
+	if got := empty.managedResource(targets[0]); got != nil {
+		t.Errorf("unexpected block from a nil configuration: %#v", got)
+	}
+}
+
+func BenchmarkConfigResourceLookup(b *testing.B) {
+	config, targets := indexBenchConfig(10, 10, 10)
+
+	b.Run("walk per address", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, addr := range targets {
+				modCfg := config.Descendent(addr.Module.Module())
+				if modCfg.Module.ManagedResources[addr.ContainingResource().Resource.String()] == nil {
+					b.Fatalf("lookup failed for %s", addr)
+				}
+			}
+		}
+	})
+
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			idx := indexConfigResources(config)
+			for _, addr := range targets {
+				if idx.managedResource(addr) == nil {
+					b.Fatalf("lookup failed for %s", addr)
+				}
+			}
+		}
+	})
+}
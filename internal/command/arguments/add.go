@@ -0,0 +1,1175 @@
+package arguments
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// Add represents the command-line arguments for the Add command.
+type Add struct {
+	// Addrs specifies which resources to generate configuration for. When
+	// generating from state, multiple resources are ordered by their state
+	// dependencies; otherwise they are generated in the order given.
+	Addrs []addrs.AbsResourceInstance
+
+	// Patterns contains address patterns which can only be resolved against
+	// the state: "TYPE.*" wildcards selecting every resource of a type, and
+	// whole-module addresses selecting a module subtree. Only valid with
+	// FromState.
+	Patterns []string
+
+	// AutoApprove skips the confirmation prompt shown before bulk generation
+	// when Patterns select the resources, so automation can use patterns
+	// without interactive input. Defaults to false.
+	AutoApprove bool
+
+	// BackendType is the backend type to scaffold a terraform { backend }
+	// block for, set with -type=backend. When non-empty the positional
+	// argument names a backend type rather than a resource, and the
+	// scaffold is generated from that backend's own configuration schema.
+	BackendType string
+
+	// AttrProvenance annotates each attribute in -from-state output with the
+	// source of its value: the state, a provider default, or an unset
+	// required placeholder. Defaults to false.
+	AttrProvenance bool
+
+	// TestFixture generates a minimal test suite configuration for each
+	// resource address instead of a bare resource block, for use with
+	// "terraform test". Set with -type=test.
+	TestFixture bool
+
+	// ExternalData is true when the addresses name instances of the
+	// "external" data source (data.external.NAME), the one data source this
+	// command can scaffold: its protocol schema is fixed, so no provider is
+	// consulted.
+	ExternalData bool
+
+	// WithScript selects the language, "sh" or "python", of an executable
+	// stub implementing the external data source protocol, written next to
+	// the configuration named by -out or -out-dir. Only valid with
+	// data.external addresses; empty generates no stub.
+	WithScript string
+
+	// FromState specifies that the configuration should be populated with
+	// values from state.
+	FromState bool
+
+	// AsData generates a data block looking up the object a managed resource
+	// tracks in state, using the provider's data source of the same type
+	// name, instead of a resource block. Requires FromState.
+	AsData bool
+
+	// OutPath contains an optional path to store the generated configuration.
+	OutPath string
+
+	// OutDir contains an optional directory in which generated configuration
+	// is organized into per-group files according to SplitBy.
+	OutDir string
+
+	// SplitBy selects how generated configuration is distributed across
+	// files inside OutDir: "type", "provider" or "module". Defaults to
+	// "type" when OutDir is set.
+	SplitBy string
+
+	// FileHeader holds the contents of the text file named with -file-header,
+	// which are prepended whenever this command creates a brand new
+	// configuration file, so organization file header policies (copyright
+	// notices, codeowners hints) apply to generated files too. Empty when no
+	// header was requested.
+	FileHeader string
+
+	// Fix appends the required_providers declaration for a provider the
+	// module doesn't declare to the file named by OutPath, instead of only
+	// showing the declaration in the warning. Defaults to false.
+	Fix bool
+
+	// After names an existing block in the OutPath file, in the form
+	// `resource "aws_vpc" "main"`, directly after which generated blocks
+	// are inserted, instead of appending them at the end of the file.
+	// Empty appends.
+	After string
+
+	// Position selects a report of where each generated block landed in the
+	// OutPath file — the file name and the start and end lines of the
+	// inserted content — as "human" or "json", so editors invoking the CLI
+	// can scroll to or select the new block. Empty disables the report.
+	Position string
+
+	// MaxLineWidth is the line width beyond which single-line list and
+	// object values are broken across lines. Defaults to 120.
+	MaxLineWidth int
+
+	// Newline selects the line endings used for files written with OutPath
+	// or OutDir: "lf", "crlf", or "auto" to match the file being appended
+	// to (falling back to the platform convention). Defaults to "auto".
+	Newline string
+
+	// Explain reports, as an aside after each generated block, why every
+	// emitted attribute and block is present: required by the schema, a
+	// min_items constraint, requested with -optional, or populated from
+	// state. Defaults to false.
+	Explain bool
+
+	// InputsJSON emits a machine-readable JSON description of the arguments
+	// the resource type accepts, instead of rendering configuration.
+	// Defaults to false.
+	InputsJSON bool
+
+	// CompactJSON emits the -inputs-json description as newline-delimited
+	// compact JSON objects, one per argument, instead of a pretty-printed
+	// array. Defaults to false.
+	CompactJSON bool
+
+	// JSONDiags renders diagnostics as newline-delimited JSON objects on
+	// standard error, each carrying the diagnostic's stable TF-ADD code,
+	// instead of human-readable text, so automation can match on codes
+	// rather than English strings. Defaults to false.
+	JSONDiags bool
+
+	// DiffConfig reports the attributes which are set in state but missing
+	// from the existing resource block in configuration, instead of
+	// generating a new block. Defaults to false.
+	DiffConfig bool
+
+	// Audit reports how every resource block already in the configuration
+	// compares against its provider schema — the required attributes the
+	// block does not set, and the settable attributes the schema declares
+	// but the block leaves unset — instead of generating configuration.
+	// Defaults to false.
+	Audit bool
+
+	// AuditFormat selects the output format of the -audit report: "table"
+	// or "json". Defaults to "table" when Audit is set.
+	AuditFormat string
+
+	// HCL2JSON prints the JSON syntax representation of a resource block
+	// already declared in a native syntax configuration file, instead of
+	// generating a new block. Defaults to false.
+	HCL2JSON bool
+
+	// JSON2HCL is the inverse of HCL2JSON: it prints the native syntax
+	// representation of a resource block already declared in a JSON syntax
+	// configuration file. Defaults to false.
+	JSON2HCL bool
+
+	// AutoInit runs the equivalent of "terraform init" first when the
+	// working directory has not been initialized yet. Defaults to false.
+	AutoInit bool
+
+	// Merge specifies that resources which already exist in the
+	// configuration are not an error; instead the command reports, as
+	// comments, any required arguments the existing block is missing
+	// according to the provider schema. Defaults to false.
+	Merge bool
+
+	// Optional specifies whether or not to include optional attributes in the
+	// generated configuration. Defaults to false.
+	Optional bool
+
+	// ValuesStdin reads attribute values as whitespace-separated key=value
+	// pairs from standard input and uses them instead of placeholders in the
+	// generated configuration. Defaults to false.
+	ValuesStdin bool
+
+	// StdinValues holds the values read from standard input when ValuesStdin
+	// is set. It is not set by ParseAdd; the command populates it after
+	// reading and type-checking the input.
+	StdinValues map[string]string
+
+	// ImportIdentity marks the attributes which conventionally identify the
+	// remote object a resource block describes (id, name, arn) with a
+	// comment, for users generating configuration they intend to
+	// "terraform import" onto: those values must match the real object
+	// exactly. Defaults to false.
+	ImportIdentity bool
+
+	// Infer annotates placeholder attributes whose names match well-known
+	// environment conventions (such as region from AWS_REGION) with the
+	// value found in the environment, as a commented suggestion rather than
+	// a populated default. Defaults to false.
+	Infer bool
+
+	// ExtractVariables replaces placeholder values in the generated
+	// configuration with references to input variables, and declares a
+	// matching variable for each alongside the resource blocks, with a
+	// validation block scaffolded when the provider's documentation of the
+	// attribute makes its constraints inferable. Defaults to false.
+	ExtractVariables bool
+
+	// Migration appends a commented migration runbook after the generated
+	// resource block: the "terraform state mv" command when MovedFrom names
+	// the old address, the "terraform import" command to adopt an unmanaged
+	// object, and the plan check confirming the migration. Defaults to false.
+	Migration bool
+
+	// MovedFrom is the address the resource is being moved from in a
+	// -migration run, set with -moved-from. Nil when the object is being
+	// adopted rather than moved.
+	MovedFrom *addrs.AbsResourceInstance
+
+	// Provider specifies the provider for the target.
+	Provider *addrs.AbsProviderConfig
+
+	// ProviderAlias selects, by alias, which of the module's configurations
+	// of the -provider provider the generated block should reference, when
+	// the module declares several. The special value "default" names the
+	// module's default (unaliased) configuration explicitly. Requires
+	// Provider, and empty when the choice is left to the command.
+	ProviderAlias string
+
+	// ExampleExpressions contains user-supplied overrides for the built-in
+	// table of example expressions suggested alongside well-known attributes,
+	// loaded from the file given with -example-expressions.
+	ExampleExpressions map[string]string
+
+	// PolicyDir is an optional directory of policy documents to evaluate
+	// against the generated configuration before it is written.
+	PolicyDir string
+
+	// SchemaOverridePath is an optional JSON file mapping provider source
+	// addresses to schema documents which shadow what the installed
+	// providers report, for testing generation against in-development
+	// provider schemas.
+	SchemaOverridePath string
+
+	// SecureDefaults pre-populates security-sensitive attributes with
+	// conservative values from a built-in catalog, instead of empty
+	// placeholders. Defaults to false.
+	SecureDefaults bool
+
+	// Quiet suppresses the next-step hints normally printed after the
+	// generated configuration. Defaults to false.
+	Quiet bool
+
+	// WithCheck scaffolds a top-level check block after each generated
+	// resource, with a data block re-reading the object the resource manages
+	// and an assert verifying it, as a starting point for post-deployment
+	// validation. Defaults to false.
+	WithCheck bool
+
+	// SubtreePath names one nested block or attribute of the resource type's
+	// schema, as a dot-separated path such as "network_interface" or
+	// "timeouts.create", and renders just that subtree instead of a whole
+	// resource block, for pasting into an existing block.
+	SubtreePath string
+
+	// Parallelism bounds the number of workers rendering blocks concurrently
+	// during bulk generation. Zero, the default, means one worker per CPU.
+	Parallelism int
+
+	// CheckpointPath is an optional file recording the address of each block
+	// already written during a bulk run, so a run interrupted partway can be
+	// re-run without generating the finished blocks twice. The file is
+	// removed when the run completes.
+	CheckpointPath string
+
+	// NoProvenance suppresses the provenance marker comment normally
+	// emitted above each generated block. Defaults to false.
+	NoProvenance bool
+
+	// NoComments emits the leanest possible block, omitting the
+	// experimental-command note, the provenance marker, and the annotation
+	// comments next to placeholder values, for users who post-process the
+	// output with scripts. Defaults to false.
+	NoComments bool
+
+	// CommentStyle selects how the REQUIRED/OPTIONAL annotations next to
+	// placeholder values are written: "hash" for # comments, "slash" for //
+	// comments, or "none" to omit the annotations while keeping the other
+	// generated comments. Empty means "hash".
+	CommentStyle string
+
+	// FromExisting specifies a resource already declared in the
+	// configuration whose block is copied as the starting point for the
+	// generated block, including meta-arguments such as provider, lifecycle
+	// and depends_on.
+	FromExisting *addrs.AbsResourceInstance
+
+	// Clone copies an existing resource block's HCL verbatim to a new name,
+	// preserving the author's formatting, comments and expressions, with
+	// computed-only attributes stripped. The first positional address is the
+	// block to copy and the second is the address to declare.
+	Clone bool
+
+	// CloneSource is the resource block to copy in Clone mode, split off
+	// from the positional addresses during parsing.
+	CloneSource *addrs.AbsResourceInstance
+
+	// Redact names a redaction profile from the CLI configuration. State
+	// values of attributes matching the profile's patterns are replaced with
+	// variable references, and a matching sensitive variable declaration is
+	// generated alongside. Only valid with FromState.
+	Redact string
+
+	// RedactPatterns holds the patterns of the resolved Redact profile. The
+	// command resolves the profile name against the CLI configuration after
+	// parsing, since the parser has no access to it.
+	RedactPatterns []string
+
+	// Generators are the external generator programs from any add_generator
+	// blocks in the CLI configuration, which post-process or replace the
+	// generated configuration for matching resource types. Like
+	// RedactPatterns they are supplied by the command after parsing rather
+	// than from the command line.
+	Generators []AddGenerator
+
+	// PlaceholderFormat is an optional format string, containing one %s verb
+	// for the attribute name, used instead of null for placeholder values.
+	// This keeps the output compatible with template engines such as Jinja
+	// or cookiecutter, which post-process the generated files.
+	PlaceholderFormat string
+
+	// IncludeOptionalComputed includes attributes that are both optional and
+	// computed when generating from state. By default those are skipped,
+	// since the provider will recompute them anyway. Defaults to false.
+	IncludeOptionalComputed bool
+
+	// StateSerial pins the serial of the state snapshot values may be read
+	// from, guarding against generating from a state which has moved on
+	// since the user inspected it. Nil when no pin was requested. Only
+	// valid with FromState or DiffConfig; use the -state option from the
+	// extended flag set to name an alternate state file, such as a backup.
+	StateSerial *uint64
+
+	// State from the common extended flags.
+	State *State
+
+	// ViewType specifies which output format to use. ViewHuman is currently the
+	// only supported view type.
+	ViewType ViewType
+}
+
+// AddGenerator describes one external generator program from an
+// add_generator block in the CLI configuration: a program run for resource
+// types matching Pattern, which receives the generated configuration on its
+// standard input and whose standard output replaces it.
+type AddGenerator struct {
+	Pattern string
+	Command string
+	Args    []string
+}
+
+func ParseAdd(args []string) (*Add, tfdiags.Diagnostics) {
+	add := &Add{State: &State{}, ViewType: ViewHuman}
+
+	var diags tfdiags.Diagnostics
+	var provider string
+	var examplesPath string
+	var fromExisting string
+	var stateSerial string
+	var blockType string
+	var movedFrom string
+	var fileHeaderPath string
+
+	cmdFlags := extendedFlagSet("add", add.State, nil, nil)
+	cmdFlags.BoolVar(&add.FromState, "from-state", false, "fill attribute values from a resource already managed by terraform")
+	cmdFlags.BoolVar(&add.AutoApprove, "auto-approve", false, "skip the confirmation prompt before bulk generation")
+	cmdFlags.BoolVar(&add.AsData, "as-data", false, "generate a data block looking up the object a managed resource tracks in state")
+	cmdFlags.BoolVar(&add.Optional, "optional", false, "include optional attributes")
+	cmdFlags.BoolVar(&add.Merge, "merge", false, "report missing required arguments for resources already in configuration instead of failing")
+	cmdFlags.BoolVar(&add.AutoInit, "auto-init", false, "initialize the working directory first if necessary")
+	cmdFlags.BoolVar(&add.Explain, "explain", false, "report why each emitted attribute and block is present after the generated configuration")
+	cmdFlags.BoolVar(&add.InputsJSON, "inputs-json", false, "describe the resource type's arguments as JSON instead of rendering configuration")
+	cmdFlags.BoolVar(&add.CompactJSON, "compact-json", false, "emit -inputs-json output as newline-delimited compact JSON instead of a pretty-printed array")
+	cmdFlags.BoolVar(&add.JSONDiags, "json-diags", false, "render diagnostics as newline-delimited JSON objects with stable codes")
+	cmdFlags.BoolVar(&add.DiffConfig, "diff-config", false, "report attributes set in state but missing from the existing configuration")
+	cmdFlags.BoolVar(&add.Audit, "audit", false, "report attributes missing from every resource already in the configuration")
+	cmdFlags.StringVar(&add.AuditFormat, "audit-format", "", "output format for the -audit report: table or json")
+	cmdFlags.BoolVar(&add.HCL2JSON, "hcl2json", false, "print the JSON syntax representation of a resource block already in the configuration")
+	cmdFlags.BoolVar(&add.JSON2HCL, "json2hcl", false, "print the native syntax representation of a resource block declared in a JSON syntax file")
+	cmdFlags.IntVar(&add.MaxLineWidth, "max-line-width", 120, "line width beyond which single-line values are wrapped")
+	cmdFlags.StringVar(&stateSerial, "state-serial", "", "serial the state snapshot must have for values to be read from it")
+	cmdFlags.StringVar(&add.Newline, "newline", "auto", "line endings for generated files: lf, crlf or auto")
+	cmdFlags.StringVar(&add.OutPath, "out", "", "out")
+	cmdFlags.StringVar(&add.OutDir, "out-dir", "", "out directory for split files")
+	cmdFlags.StringVar(&add.SplitBy, "split-by", "", "group output files by type, provider or module")
+	cmdFlags.StringVar(&add.Position, "position", "", "report the file and line range of each block appended to the -out file: human or json")
+	cmdFlags.StringVar(&add.After, "after", "", "insert generated blocks after the named block already in the -out file instead of appending")
+	cmdFlags.BoolVar(&add.Fix, "fix", false, "append the required_providers declaration for an undeclared provider to the -out file")
+	cmdFlags.StringVar(&fileHeaderPath, "file-header", "", "text file whose contents are prepended when a brand new configuration file is created")
+	cmdFlags.StringVar(&provider, "provider", "", "provider")
+	cmdFlags.StringVar(&add.ProviderAlias, "provider-alias", "", "alias of the provider configuration the generated block should reference")
+	cmdFlags.StringVar(&blockType, "type", "", "kind of block to generate: resource (the default) or backend")
+	cmdFlags.StringVar(&examplesPath, "example-expressions", "", "example expression overrides file")
+	cmdFlags.StringVar(&add.PolicyDir, "policy-dir", "", "directory of policy documents to check generated configuration against")
+	cmdFlags.StringVar(&add.SchemaOverridePath, "schema-override", "", "JSON file of provider schemas shadowing what the installed providers report")
+	cmdFlags.StringVar(&add.Redact, "redact", "", "redaction profile from the CLI configuration to apply to values taken from state")
+	cmdFlags.StringVar(&add.PlaceholderFormat, "placeholder-format", "", "format string with one %s verb used instead of null for placeholder values")
+	cmdFlags.StringVar(&fromExisting, "from-existing-resource", "", "address of a resource in configuration to copy as the starting point")
+	cmdFlags.BoolVar(&add.Clone, "clone", false, "copy an existing resource block verbatim to a new name, preserving formatting and comments")
+	cmdFlags.StringVar(&add.WithScript, "with-script", "", "generate an executable stub (sh or python) implementing the external data source protocol")
+	cmdFlags.BoolVar(&add.Migration, "migration", false, "append a commented migration runbook after the generated resource block")
+	cmdFlags.StringVar(&movedFrom, "moved-from", "", "address the resource is being moved from, for the -migration runbook")
+	cmdFlags.BoolVar(&add.SecureDefaults, "secure-defaults", false, "pre-populate security-sensitive attributes with conservative values")
+	cmdFlags.BoolVar(&add.Infer, "infer", false, "suggest values for well-known attributes from the process environment")
+	cmdFlags.BoolVar(&add.ImportIdentity, "import-identity", false, "mark the attributes terraform import matches against the real object")
+	cmdFlags.BoolVar(&add.ValuesStdin, "values-stdin", false, "read attribute values as key=value pairs from standard input")
+	cmdFlags.BoolVar(&add.ExtractVariables, "extract-variables", false, "replace placeholder values with references to generated input variables")
+	cmdFlags.BoolVar(&add.AttrProvenance, "attr-provenance", false, "annotate each attribute written from state with the source of its value")
+	cmdFlags.BoolVar(&add.Quiet, "quiet", false, "suppress next-step hints after the generated configuration")
+	cmdFlags.BoolVar(&add.WithCheck, "with-check", false, "scaffold a check block validating each generated resource")
+	cmdFlags.BoolVar(&add.NoProvenance, "no-provenance", false, "omit the provenance marker comment above the generated block")
+	cmdFlags.BoolVar(&add.NoComments, "no-comments", false, "omit all comments and placeholder annotations from the generated block")
+	cmdFlags.StringVar(&add.CommentStyle, "comment-style", "", "comment style for the REQUIRED/OPTIONAL annotations: hash, slash or none")
+	cmdFlags.BoolVar(&add.IncludeOptionalComputed, "include-optional-computed", false, "include optional+computed attributes when generating from state")
+	cmdFlags.IntVar(&add.Parallelism, "parallelism", 0, "bound the number of concurrent workers rendering blocks during bulk generation")
+	cmdFlags.StringVar(&add.CheckpointPath, "checkpoint", "", "file recording the blocks already written, so an interrupted bulk run can resume")
+	cmdFlags.StringVar(&add.SubtreePath, "path", "", "render just the named nested block or attribute of the schema, for pasting into an existing block")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to parse command-line flags",
+			err.Error(),
+		))
+		return add, diags
+	}
+
+	if examplesPath != "" {
+		src, err := os.ReadFile(examplesPath)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to read example expressions file",
+				err.Error(),
+			))
+			return add, diags
+		}
+		if err := json.Unmarshal(src, &add.ExampleExpressions); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid example expressions file",
+				fmt.Sprintf("The file %q must contain a JSON object mapping attribute names to expression strings: %s.", examplesPath, err),
+			))
+			return add, diags
+		}
+	}
+
+	if fileHeaderPath != "" {
+		if add.OutPath == "" && add.OutDir == "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -file-header option prepends the given file's contents when a brand new configuration file is created, so it requires -out or -out-dir.",
+			))
+			return add, diags
+		}
+		src, err := os.ReadFile(fileHeaderPath)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to read file header file",
+				err.Error(),
+			))
+			return add, diags
+		}
+		add.FileHeader = string(src)
+	}
+
+	switch blockType {
+	case "", "resource":
+		// Resource generation is the default; the addresses are parsed below.
+	case "backend":
+		// Handled once the remaining options have been validated.
+	case "test":
+		// A test fixture is generated per resource address, so the addresses
+		// are parsed below like the default mode.
+		add.TestFixture = true
+	default:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid block type",
+			fmt.Sprintf("The -type option must be \"resource\", \"backend\" or \"test\", not %q.", blockType),
+		))
+		return add, diags
+	}
+
+	if add.TestFixture && (add.FromState || add.DiffConfig || add.Merge || add.Audit || add.InputsJSON || add.Clone || add.AsData || add.ValuesStdin) {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -type=test option generates a test suite fixture from the resource type's schema alone, so it cannot be combined with the modes which read state or existing configuration.",
+		))
+		return add, diags
+	}
+
+	if add.Migration {
+		if add.InputsJSON || add.DiffConfig || add.Audit || add.Merge || add.Clone || add.AsData || add.TestFixture || blockType == "backend" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -migration option appends migration steps to generated resource blocks, so it cannot be combined with the modes which do not generate resource blocks.",
+			))
+			return add, diags
+		}
+		if add.NoComments {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -migration option emits the migration steps as comments, so it cannot be combined with -no-comments.",
+			))
+			return add, diags
+		}
+	}
+
+	if add.SubtreePath != "" && (add.FromState || add.DiffConfig || add.Merge || add.Clone || add.AsData || add.InputsJSON || add.Audit || add.TestFixture || add.HCL2JSON || add.JSON2HCL || add.Migration || add.ExtractVariables || add.OutPath != "" || add.OutDir != "") {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -path option renders a single nested section of the schema on standard output, for pasting into an existing block, so it cannot be combined with the other generation modes or with -out or -out-dir.",
+		))
+		return add, diags
+	}
+
+	if add.Parallelism < 0 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid parallelism",
+			fmt.Sprintf("The -parallelism option must be a positive number of workers, or zero for one worker per CPU, not %d.", add.Parallelism),
+		))
+		return add, diags
+	}
+
+	if add.CheckpointPath != "" && add.OutPath == "" && add.OutDir == "" {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -checkpoint option records each block already written to disk so an interrupted run can resume, so it requires -out or -out-dir.",
+		))
+		return add, diags
+	}
+
+	switch add.CommentStyle {
+	case "", "hash", "slash", "none":
+		// The recognized styles; unset means "hash".
+	default:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid comment style",
+			fmt.Sprintf("The -comment-style option must be \"hash\", \"slash\" or \"none\", not %q.", add.CommentStyle),
+		))
+		return add, diags
+	}
+
+	if add.InputsJSON && (add.FromState || add.OutPath != "" || add.OutDir != "") {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -inputs-json option describes a resource type's arguments on standard output, so it cannot be combined with -from-state, -out or -out-dir.",
+		))
+		return add, diags
+	}
+
+	if add.Explain && (add.InputsJSON || add.Audit || add.DiffConfig || add.Merge || add.HCL2JSON || add.JSON2HCL || add.Clone) {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -explain option reports why each part of a generated block is present, so it cannot be combined with the modes which do not generate configuration from a schema.",
+		))
+		return add, diags
+	}
+
+	if add.ValuesStdin && (add.FromState || add.DiffConfig || add.Audit || add.InputsJSON || add.Clone) {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -values-stdin option fills placeholder values in a generated template, so it cannot be combined with the modes which take values from state or do not generate a template.",
+		))
+		return add, diags
+	}
+
+	if add.ExtractVariables && (add.FromState || add.DiffConfig || add.Audit || add.InputsJSON || add.Clone) {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -extract-variables option replaces placeholder values in a generated template with input variables, so it cannot be combined with the modes which take values from state or do not generate a template. To pull state values into variables, use -from-state with -redact instead.",
+		))
+		return add, diags
+	}
+
+	if add.Audit && (add.FromState || add.Merge || add.DiffConfig || add.InputsJSON || add.Clone || fromExisting != "" || provider != "" || add.OutPath != "" || add.OutDir != "") {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -audit option reports on the resources already in the configuration, so it cannot be combined with the options which generate new configuration.",
+		))
+		return add, diags
+	}
+
+	switch add.AuditFormat {
+	case "":
+		if add.Audit {
+			add.AuditFormat = "table"
+		}
+	case "table", "json":
+		if !add.Audit {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -audit-format option only has meaning together with -audit.",
+			))
+			return add, diags
+		}
+	default:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid audit format",
+			fmt.Sprintf("The -audit-format option must be one of \"table\" or \"json\", not %q.", add.AuditFormat),
+		))
+		return add, diags
+	}
+
+	if add.HCL2JSON && add.JSON2HCL {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"Cannot use both -hcl2json and -json2hcl. Use -hcl2json to convert a native syntax block to JSON syntax, or -json2hcl for the reverse.",
+		))
+		return add, diags
+	}
+	if (add.HCL2JSON || add.JSON2HCL) && (add.FromState || add.Merge || add.DiffConfig || add.Audit || add.InputsJSON || add.Clone || add.TestFixture || add.Migration || add.ValuesStdin || add.ExtractVariables || fromExisting != "" || add.OutPath != "" || add.OutDir != "") {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -hcl2json and -json2hcl options convert a resource block already in the configuration between the native and JSON syntaxes on standard output, so they cannot be combined with the options which generate new configuration.",
+		))
+		return add, diags
+	}
+
+	if add.AsData && !add.FromState {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -as-data option generates a data block looking up the object a managed resource tracks in state, so it requires -from-state.",
+		))
+		return add, diags
+	}
+
+	if stateSerial != "" {
+		if !add.FromState && !add.DiffConfig {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -state-serial option pins the state snapshot values are read from, so it requires -from-state or -diff-config.",
+			))
+			return add, diags
+		}
+		serial, err := strconv.ParseUint(stateSerial, 10, 64)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid state serial",
+				fmt.Sprintf("The -state-serial option must be the non-negative serial number of a state snapshot, not %q.", stateSerial),
+			))
+			return add, diags
+		}
+		add.StateSerial = &serial
+	}
+
+	if add.AttrProvenance && !add.FromState {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -attr-provenance option annotates attributes with the source of the values taken from state, so it requires -from-state.",
+		))
+		return add, diags
+	}
+
+	if add.Redact != "" && !add.FromState {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -redact option replaces attribute values taken from state with variable references, so it requires -from-state.",
+		))
+		return add, diags
+	}
+
+	if add.CompactJSON && !add.InputsJSON {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -compact-json option selects the output style for -inputs-json, so it cannot be used without it.",
+		))
+		return add, diags
+	}
+
+	if add.PlaceholderFormat != "" && strings.Count(add.PlaceholderFormat, "%s") != 1 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid placeholder format",
+			fmt.Sprintf("The -placeholder-format option must contain exactly one %%s verb, which is replaced by the attribute name, not %q.", add.PlaceholderFormat),
+		))
+		return add, diags
+	}
+
+	if add.MaxLineWidth < 1 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid maximum line width",
+			fmt.Sprintf("The -max-line-width option must be a positive number of columns, not %d.", add.MaxLineWidth),
+		))
+		return add, diags
+	}
+
+	switch add.Newline {
+	case "lf", "crlf", "auto":
+		// ok
+	default:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid newline mode",
+			fmt.Sprintf("The -newline option must be one of \"lf\", \"crlf\" or \"auto\", not %q.", add.Newline),
+		))
+		return add, diags
+	}
+
+	switch add.Position {
+	case "":
+		// The position report is opt-in.
+	case "human", "json":
+		if add.OutPath == "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -position option reports where each generated block landed in the -out file, so it requires -out.",
+			))
+			return add, diags
+		}
+	default:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid position format",
+			fmt.Sprintf("The -position option must be one of \"human\" or \"json\", not %q.", add.Position),
+		))
+		return add, diags
+	}
+
+	if add.Fix && add.OutPath == "" {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -fix option appends the required_providers declaration for an undeclared provider to the generated file, so it requires -out.",
+		))
+		return add, diags
+	}
+
+	if add.After != "" {
+		if add.OutPath == "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -after option inserts the generated block after a named block already in the -out file, so it requires -out.",
+			))
+			return add, diags
+		}
+		if _, _, err := ParseBlockAnchor(add.After); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid anchor block",
+				fmt.Sprintf("The -after option names an existing block as a block type followed by its quoted labels, like `resource \"aws_vpc\" \"main\"`: %s.", err),
+			))
+			return add, diags
+		}
+	}
+
+	// Clean the output paths so that both forward slashes and the native
+	// separator work on Windows, and refuse file names that Windows
+	// reserves for devices, which would produce configuration files that
+	// cannot be checked out on that platform.
+	if add.OutPath != "" {
+		add.OutPath = filepath.Clean(add.OutPath)
+		if windowsReservedName(filepath.Base(add.OutPath)) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid output filename",
+				fmt.Sprintf("%q is a reserved filename on Windows and cannot be used with -out.", filepath.Base(add.OutPath)),
+			))
+			return add, diags
+		}
+	}
+	if add.OutDir != "" {
+		add.OutDir = filepath.Clean(add.OutDir)
+		if windowsReservedName(filepath.Base(add.OutDir)) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid output directory",
+				fmt.Sprintf("%q is a reserved filename on Windows and cannot be used with -out-dir.", filepath.Base(add.OutDir)),
+			))
+			return add, diags
+		}
+	}
+
+	if add.DiffConfig && (add.Merge || add.InputsJSON || add.FromState || add.OutPath != "" || add.OutDir != "") {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -diff-config option reports drift between state and configuration on standard output, so it cannot be combined with -merge, -inputs-json, -from-state, -out or -out-dir.",
+		))
+		return add, diags
+	}
+
+	if add.OutPath != "" && add.OutDir != "" {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"Cannot use both -out and -out-dir. Use -out to append to a single file, or -out-dir to organize output into per-group files.",
+		))
+		return add, diags
+	}
+	switch add.SplitBy {
+	case "":
+		if add.OutDir != "" {
+			add.SplitBy = "type"
+		}
+	case "type", "provider", "module":
+		if add.OutDir == "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -split-by option only has meaning together with -out-dir.",
+			))
+			return add, diags
+		}
+	default:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid split mode",
+			fmt.Sprintf("The -split-by option must be one of \"type\", \"provider\" or \"module\", not %q.", add.SplitBy),
+		))
+		return add, diags
+	}
+
+	args = cmdFlags.Args()
+
+	// A backend scaffold is generated from the backend's own configuration
+	// schema, so none of the options which read providers, state or existing
+	// configuration apply to it, and the positional argument is a backend
+	// type name rather than a resource address.
+	if blockType == "backend" {
+		if add.FromState || add.DiffConfig || add.Merge || add.Audit || add.InputsJSON || add.Clone || add.ValuesStdin || fromExisting != "" || provider != "" || add.OutDir != "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -type=backend option generates a terraform block with a backend configuration skeleton, so it cannot be combined with the options which read providers, state or existing configuration, nor with -out-dir or -values-stdin.",
+			))
+			return add, diags
+		}
+		if len(args) != 1 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid backend arguments",
+				"The -type=backend option requires exactly one argument, giving the backend type to generate a configuration skeleton for (for example \"s3\").",
+			))
+			return add, diags
+		}
+		add.BackendType = args[0]
+		return add, diags
+	}
+
+	// The -audit report always covers every resource in the configuration,
+	// so there are no addresses to parse.
+	if add.Audit {
+		if len(args) != 0 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Too many command line arguments",
+				"The -audit option reports on every resource in the configuration, so it takes no address arguments.",
+			))
+		}
+		return add, diags
+	}
+
+	if len(args) < 1 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Too few command line arguments",
+			"Expected at least one positional argument, giving the address of a resource to generate configuration for.",
+		))
+		return add, diags
+	}
+
+	// parse the addresses from the arguments
+	for _, arg := range args {
+		// Wildcard patterns select resources from the state, so they are
+		// kept unparsed here and expanded by the command.
+		if strings.HasSuffix(arg, ".*") {
+			if !add.FromState {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Wildcard address requires -from-state",
+					fmt.Sprintf("The pattern %s selects resources from the state, so it can only be used together with -from-state.", arg),
+				))
+				return add, diags
+			}
+			add.Patterns = append(add.Patterns, arg)
+			continue
+		}
+
+		addr, addrDiags := addrs.ParseAbsResourceInstanceStr(arg)
+		if addrDiags.HasErrors() {
+			// With -from-state a whole-module address selects every resource
+			// in that module subtree, like it does for "terraform state list".
+			if target, targetDiags := addrs.ParseTargetStr(arg); !targetDiags.HasErrors() {
+				if _, ok := target.Subject.(addrs.ModuleInstance); ok {
+					if add.FromState {
+						add.Patterns = append(add.Patterns, arg)
+						continue
+					}
+					// Without state to expand it against, a module address
+					// reads as a request to generate the module call itself,
+					// which this command cannot do yet.
+					diags = diags.Append(tfdiags.Sourceless(
+						tfdiags.Error,
+						"Module calls are not yet supported by terraform add",
+						fmt.Sprintf("The address %s refers to a module call, and this command cannot yet generate module blocks. Give the address of a resource inside the module instead (%s.TYPE.NAME), or use -from-state to generate every resource the state records in that module subtree.", arg, arg),
+					))
+					return add, diags
+				}
+			}
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				fmt.Sprintf("Error parsing resource address: %s", arg),
+				"This command requires that each address argument specifies one resource instance.",
+			))
+			return add, diags
+		}
+		// Data source addresses parse fine, but the generator only writes
+		// resource blocks, so give those a targeted error rather than
+		// emitting a data source's arguments under a "resource" header.
+		if addr.Resource.Resource.Mode != addrs.ManagedResourceMode {
+			// The "external" data source is the one exception: its protocol
+			// schema is fixed, so the command can scaffold it (and, with
+			// -with-script, the program it runs) without consulting any
+			// provider.
+			if addr.Resource.Resource.Type == "external" {
+				add.ExternalData = true
+				add.Addrs = append(add.Addrs, addr)
+				continue
+			}
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Data sources are not yet supported by terraform add",
+				fmt.Sprintf("The address %s refers to a data source, and this command cannot yet generate data blocks from a type name alone. To generate a data block looking up an object already tracked in state, give the managed resource's address together with -from-state -as-data instead.", arg),
+			))
+			return add, diags
+		}
+		add.Addrs = append(add.Addrs, addr)
+	}
+
+	// The confirmation prompt only appears when patterns select the
+	// resources, so -auto-approve has nothing to skip otherwise.
+	if add.AutoApprove && len(add.Patterns) == 0 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incompatible command-line options",
+			"The -auto-approve option skips the confirmation prompt shown before bulk generation, so it only has meaning together with a wildcard or whole-module address pattern.",
+		))
+		return add, diags
+	}
+
+	if add.ExternalData {
+		for _, addr := range add.Addrs {
+			if addr.Resource.Resource.Mode == addrs.ManagedResourceMode {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Incompatible command-line options",
+					"Cannot mix data.external addresses with resource addresses in one run; generate the data sources and the resources separately.",
+				))
+				return add, diags
+			}
+		}
+		if add.FromState || add.DiffConfig || add.Merge || add.InputsJSON || add.Clone || add.TestFixture || add.Migration || add.ValuesStdin || fromExisting != "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"A data.external address scaffolds the external data source from its fixed protocol schema, so it cannot be combined with the modes which read state or existing configuration.",
+			))
+			return add, diags
+		}
+	}
+
+	switch add.WithScript {
+	case "":
+		// No stub is generated unless asked for.
+	case "sh", "python":
+		if !add.ExternalData {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -with-script option generates a program stub for the external data source, so it requires a data.external address.",
+			))
+			return add, diags
+		}
+		if add.OutPath == "" && add.OutDir == "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -with-script option writes the program stub next to the generated configuration, so it requires -out or -out-dir.",
+			))
+			return add, diags
+		}
+	default:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid script language",
+			fmt.Sprintf("The -with-script option must be one of \"sh\" or \"python\", not %q.", add.WithScript),
+		))
+		return add, diags
+	}
+
+	if add.Clone {
+		if add.FromState || add.DiffConfig || add.InputsJSON || add.Merge || fromExisting != "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -clone option copies an existing resource block from the configuration, so it cannot be combined with -from-state, -diff-config, -inputs-json, -merge or -from-existing-resource.",
+			))
+			return add, diags
+		}
+		if len(add.Addrs) != 2 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid clone arguments",
+				"The -clone option requires exactly two addresses: the resource block to copy, followed by the address to declare the copy at.",
+			))
+			return add, diags
+		}
+		add.CloneSource = &add.Addrs[0]
+		add.Addrs = add.Addrs[1:]
+	}
+
+	if movedFrom != "" {
+		if !add.Migration {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -moved-from option names the address a resource is being moved from in the -migration runbook, so it requires -migration.",
+			))
+			return add, diags
+		}
+		if len(add.Patterns) > 0 || len(add.Addrs) != 1 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid migration arguments",
+				"The -moved-from option describes the move of a single resource, so it requires exactly one target address.",
+			))
+			return add, diags
+		}
+
+		addr, addrDiags := addrs.ParseAbsResourceInstanceStr(movedFrom)
+		if addrDiags.HasErrors() {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				fmt.Sprintf("Error parsing resource address: %s", movedFrom),
+				"The -moved-from option requires the address of the resource instance the object is currently managed at.",
+			))
+			return add, diags
+		}
+		if addr.Resource.Resource.Type != add.Addrs[0].Resource.Resource.Type {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Resource type mismatch",
+				fmt.Sprintf("Cannot move %s to %s: \"terraform state mv\" can only move a resource within the same resource type.", addr, add.Addrs[0]),
+			))
+			return add, diags
+		}
+		add.MovedFrom = &addr
+	}
+
+	if fromExisting != "" {
+		if add.FromState || add.DiffConfig || add.InputsJSON || add.Merge {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -from-existing-resource option copies an existing resource block from the configuration, so it cannot be combined with -from-state, -diff-config, -inputs-json or -merge.",
+			))
+			return add, diags
+		}
+
+		addr, addrDiags := addrs.ParseAbsResourceInstanceStr(fromExisting)
+		if addrDiags.HasErrors() {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				fmt.Sprintf("Error parsing resource address: %s", fromExisting),
+				"The -from-existing-resource option requires the address of one resource instance declared in the configuration.",
+			))
+			return add, diags
+		}
+		add.FromExisting = &addr
+	}
+
+	if provider != "" {
+		if add.FromState || add.DiffConfig {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"Cannot use -provider together with -from-state or -diff-config. The provider will be determined from the resource's state.",
+			))
+			return add, diags
+		}
+
+		absProvider, providerDiags := addrs.ParseAbsProviderConfigStr(provider)
+		if providerDiags.HasErrors() {
+			// The diagnostics returned from ParseAbsProviderConfigStr are
+			// not always clear, so we wrap them in a single customized diagnostic.
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				fmt.Sprintf("Invalid provider string: %s", provider),
+				providerDiags.Err().Error(),
+			))
+			return add, diags
+		}
+		add.Provider = &absProvider
+	}
+
+	// The -provider-alias option refines a -provider choice, so it cannot
+	// stand alone, and it cannot fight with an alias already written into
+	// the -provider address itself.
+	if add.ProviderAlias != "" {
+		if add.Provider == nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				"The -provider-alias option selects among the aliased configurations of the provider given with -provider, so it cannot be used on its own.",
+			))
+			return add, diags
+		}
+		if add.Provider.Alias != "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Incompatible command-line options",
+				fmt.Sprintf("The -provider address already names the alias %q, so -provider-alias cannot also be given. Use one or the other.", add.Provider.Alias),
+			))
+			return add, diags
+		}
+	}
+
+	return add, diags
+}
+
+// windowsReservedName reports whether the given file name (without its
+// directory) is one of the names Windows reserves for devices, such as CON
+// or NUL. The reservation applies regardless of case or any file extension,
+// so e.g. "con.tf" is reserved too. We refuse these on every platform so
+// that generated configuration stays portable.
+func windowsReservedName(name string) bool {
+	base := name
+	if idx := strings.IndexByte(base, '.'); idx >= 0 {
+		base = base[:idx]
+	}
+	switch strings.ToUpper(base) {
+	case "CON", "PRN", "AUX", "NUL",
+		"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+		"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9":
+		return true
+	}
+	return false
+}
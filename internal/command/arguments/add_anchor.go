@@ -0,0 +1,46 @@
+package arguments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// ParseBlockAnchor parses the block anchor accepted by "terraform add
+// -after": a block type followed by zero or more quoted labels, like
+// `resource "aws_vpc" "main"`. It returns the block type and labels, or an
+// error describing the first problem found, phrased to read well inside a
+// diagnostic. The views package uses the same parse to locate the anchor
+// block in the output file, so the two cannot disagree about the syntax.
+func ParseBlockAnchor(spec string) (blockType string, labels []string, err error) {
+	rest := strings.TrimSpace(spec)
+	if rest == "" {
+		return "", nil, errors.New("the anchor is empty")
+	}
+
+	blockType = rest
+	if i := strings.IndexAny(rest, " \t"); i >= 0 {
+		blockType = rest[:i]
+		rest = strings.TrimSpace(rest[i:])
+	} else {
+		rest = ""
+	}
+	if !hclsyntax.ValidIdentifier(blockType) {
+		return "", nil, fmt.Errorf("%q is not a valid block type name", blockType)
+	}
+
+	for rest != "" {
+		if rest[0] != '"' {
+			return "", nil, fmt.Errorf("block labels must be quoted, but the anchor continues with %q", rest)
+		}
+		end := strings.Index(rest[1:], `"`)
+		if end < 0 {
+			return "", nil, errors.New("the anchor ends inside a quoted label")
+		}
+		labels = append(labels, rest[1:1+end])
+		rest = strings.TrimSpace(rest[2+end:])
+	}
+	return blockType, labels, nil
+}
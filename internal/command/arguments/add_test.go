@@ -0,0 +1,1118 @@
+package arguments
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+func TestParseAdd(t *testing.T) {
+	tests := map[string]struct {
+		args      []string
+		want      *Add
+		wantError string
+	}{
+		"defaults": {
+			[]string{"test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+		"some flags": {
+			[]string{"-optional=true", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Optional:     true,
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+		"-from-state": {
+			[]string{"-from-state", "module.foo.test_foo.baz"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("module.foo.test_foo.baz")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				FromState:    true,
+			},
+			``,
+		},
+		"-auto-approve with a pattern": {
+			[]string{"-from-state", "-auto-approve", "test_foo.*"},
+			&Add{
+				Patterns:     []string{"test_foo.*"},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				FromState:    true,
+				AutoApprove:  true,
+			},
+			``,
+		},
+
+		"auto-approve without a pattern": {
+			[]string{"-auto-approve", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				AutoApprove:  true,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-infer": {
+			[]string{"-infer", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				Infer:        true,
+			},
+			``,
+		},
+		"-migration": {
+			[]string{"-migration", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				Migration:    true,
+			},
+			``,
+		},
+		"-migration with -moved-from": {
+			[]string{"-migration", "-moved-from=test_foo.old", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				Migration:    true,
+				MovedFrom:    mustResourceInstanceAddrPtr("test_foo.old"),
+			},
+			``,
+		},
+		"-moved-from without -migration": {
+			[]string{"-moved-from=test_foo.old", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+		"-moved-from with a different resource type": {
+			[]string{"-migration", "-moved-from=test_other.old", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				Migration:    true,
+			},
+			`Resource type mismatch`,
+		},
+		"-migration with -no-comments": {
+			[]string{"-migration", "-no-comments", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				Migration:    true,
+				NoComments:   true,
+			},
+			`Incompatible command-line options`,
+		},
+		"-import-identity": {
+			[]string{"-import-identity", "test_foo.bar"},
+			&Add{
+				Addrs:          []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:          &State{Lock: true},
+				Newline:        "auto",
+				MaxLineWidth:   120,
+				ViewType:       ViewHuman,
+				ImportIdentity: true,
+			},
+			``,
+		},
+		"-attr-provenance": {
+			[]string{"-from-state", "-attr-provenance", "test_foo.bar"},
+			&Add{
+				Addrs:          []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:          &State{Lock: true},
+				Newline:        "auto",
+				MaxLineWidth:   120,
+				ViewType:       ViewHuman,
+				FromState:      true,
+				AttrProvenance: true,
+			},
+			``,
+		},
+		"attr-provenance without from-state": {
+			[]string{"-attr-provenance", "test_foo.bar"},
+			&Add{
+				State:          &State{Lock: true},
+				Newline:        "auto",
+				MaxLineWidth:   120,
+				ViewType:       ViewHuman,
+				AttrProvenance: true,
+			},
+			`Incompatible command-line options`,
+		},
+		"-values-stdin": {
+			[]string{"-values-stdin", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				ValuesStdin:  true,
+			},
+			``,
+		},
+		"-values-stdin with -from-state": {
+			[]string{"-values-stdin", "-from-state", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				FromState:    true,
+				ValuesStdin:  true,
+			},
+			`Incompatible command-line options`,
+		},
+		"-type=test": {
+			[]string{"-type=test", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				TestFixture:  true,
+			},
+			``,
+		},
+		"-type=test with -from-state": {
+			[]string{"-type=test", "-from-state", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				FromState:    true,
+				TestFixture:  true,
+			},
+			`Incompatible command-line options`,
+		},
+		"-type=backend": {
+			[]string{"-type=backend", "s3"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				BackendType:  "s3",
+			},
+			``,
+		},
+		"invalid -type": {
+			[]string{"-type=output", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+			},
+			`Invalid block type`,
+		},
+		"-type=backend with two arguments": {
+			[]string{"-type=backend", "s3", "consul"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+			},
+			`Invalid backend arguments`,
+		},
+		"-type=backend with -from-state": {
+			[]string{"-type=backend", "-from-state", "s3"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				FromState:    true,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-provider": {
+			[]string{"-provider=provider[\"example.com/happycorp/test\"]", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				Provider: &addrs.AbsProviderConfig{
+					Provider: addrs.NewProvider("example.com", "happycorp", "test"),
+				},
+			},
+			``,
+		},
+		"-provider-alias": {
+			[]string{"-provider=provider[\"example.com/happycorp/test\"]", "-provider-alias=east", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				Provider: &addrs.AbsProviderConfig{
+					Provider: addrs.NewProvider("example.com", "happycorp", "test"),
+				},
+				ProviderAlias: "east",
+			},
+			``,
+		},
+
+		"-provider-alias without -provider": {
+			[]string{"-provider-alias=east", "test_foo.bar"},
+			&Add{
+				Addrs:         []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:         &State{Lock: true},
+				Newline:       "auto",
+				MaxLineWidth:  120,
+				ViewType:      ViewHuman,
+				ProviderAlias: "east",
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-provider-alias with an aliased -provider address": {
+			[]string{"-provider=provider[\"example.com/happycorp/test\"].west", "-provider-alias=east", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				Provider: &addrs.AbsProviderConfig{
+					Provider: addrs.NewProvider("example.com", "happycorp", "test"),
+					Alias:    "west",
+				},
+				ProviderAlias: "east",
+			},
+			`Incompatible command-line options`,
+		},
+
+		"state options from extended flag set": {
+			[]string{"-state=local.tfstate", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true, StatePath: "local.tfstate"},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-newline": {
+			[]string{"-newline=crlf", "-out=generated.tf", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "crlf",
+				MaxLineWidth: 120,
+				OutPath:      "generated.tf",
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-position": {
+			[]string{"-position=json", "-out=generated.tf", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				OutPath:      "generated.tf",
+				Position:     "json",
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-position without -out": {
+			[]string{"-position=human", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Position:     "human",
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-after": {
+			[]string{`-after=resource "aws_vpc" "main"`, "-out=generated.tf", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				OutPath:      "generated.tf",
+				After:        `resource "aws_vpc" "main"`,
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-after without -out": {
+			[]string{`-after=resource "aws_vpc" "main"`, "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				After:        `resource "aws_vpc" "main"`,
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-after with an unquoted label": {
+			[]string{`-after=resource aws_vpc`, "-out=generated.tf", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				OutPath:      "generated.tf",
+				After:        `resource aws_vpc`,
+				ViewType:     ViewHuman,
+			},
+			`Invalid anchor block`,
+		},
+
+		"-explain": {
+			[]string{"-explain", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Explain:      true,
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-explain with -audit": {
+			[]string{"-explain", "-audit"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Explain:      true,
+				Audit:        true,
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-comment-style": {
+			[]string{"-comment-style=slash", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				CommentStyle: "slash",
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-comment-style with an unknown style": {
+			[]string{"-comment-style=banner", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				CommentStyle: "banner",
+				ViewType:     ViewHuman,
+			},
+			`Invalid comment style`,
+		},
+
+		"-checkpoint": {
+			[]string{"-checkpoint=add.checkpoint", "-out=generated.tf", "test_foo.bar"},
+			&Add{
+				Addrs:          []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:          &State{Lock: true},
+				Newline:        "auto",
+				MaxLineWidth:   120,
+				OutPath:        "generated.tf",
+				CheckpointPath: "add.checkpoint",
+				ViewType:       ViewHuman,
+			},
+			``,
+		},
+
+		"-checkpoint without -out": {
+			[]string{"-checkpoint=add.checkpoint", "test_foo.bar"},
+			&Add{
+				State:          &State{Lock: true},
+				Newline:        "auto",
+				MaxLineWidth:   120,
+				CheckpointPath: "add.checkpoint",
+				ViewType:       ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-parallelism rejects negative workers": {
+			[]string{"-parallelism=-2", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Parallelism:  -2,
+				ViewType:     ViewHuman,
+			},
+			`Invalid parallelism`,
+		},
+
+		"-path": {
+			[]string{"-path=network_interface", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				SubtreePath:  "network_interface",
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-path with -from-state": {
+			[]string{"-path=network_interface", "-from-state", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				SubtreePath:  "network_interface",
+				FromState:    true,
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-path with -out": {
+			[]string{"-path=network_interface", "-out=generated.tf", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				SubtreePath:  "network_interface",
+				OutPath:      "generated.tf",
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-fix": {
+			[]string{"-fix", "-out=generated.tf", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				OutPath:      "generated.tf",
+				Fix:          true,
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-fix without -out": {
+			[]string{"-fix", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Fix:          true,
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-hcl2json": {
+			[]string{"-hcl2json", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				HCL2JSON:     true,
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-hcl2json with -json2hcl": {
+			[]string{"-hcl2json", "-json2hcl", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				HCL2JSON:     true,
+				JSON2HCL:     true,
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-json2hcl with -out": {
+			[]string{"-json2hcl", "-out=generated.tf", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				OutPath:      "generated.tf",
+				JSON2HCL:     true,
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-position with an unknown format": {
+			[]string{"-position=xml", "-out=generated.tf", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				OutPath:      "generated.tf",
+				Position:     "xml",
+				ViewType:     ViewHuman,
+			},
+			`Invalid position format`,
+		},
+
+		"-file-header without -out": {
+			[]string{"-file-header=header.txt", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-extract-variables": {
+			[]string{"-extract-variables", "test_foo.bar"},
+			&Add{
+				Addrs:            []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:            &State{Lock: true},
+				Newline:          "auto",
+				MaxLineWidth:     120,
+				ViewType:         ViewHuman,
+				ExtractVariables: true,
+			},
+			``,
+		},
+
+		"-extract-variables with -from-state": {
+			[]string{"-extract-variables", "-from-state", "test_foo.bar"},
+			&Add{
+				State:            &State{Lock: true},
+				Newline:          "auto",
+				MaxLineWidth:     120,
+				ViewType:         ViewHuman,
+				FromState:        true,
+				ExtractVariables: true,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-compact-json": {
+			[]string{"-inputs-json", "-compact-json", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				InputsJSON:   true,
+				CompactJSON:  true,
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-redact": {
+			[]string{"-from-state", "-redact=pci", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				FromState:    true,
+				Redact:       "pci",
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-from-existing-resource": {
+			[]string{"-from-existing-resource=test_foo.exists", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				FromExisting: func() *addrs.AbsResourceInstance {
+					addr := mustResourceInstanceAddr("test_foo.exists")
+					return &addr
+				}(),
+				ViewType: ViewHuman,
+			},
+			``,
+		},
+
+		"-no-comments": {
+			[]string{"-no-comments", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				NoComments:   true,
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-as-data": {
+			[]string{"-from-state", "-as-data", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				FromState:    true,
+				AsData:       true,
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"as-data without from-state": {
+			[]string{"-as-data", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				AsData:       true,
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"data source address": {
+			[]string{"data.test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+			},
+			`Data sources are not yet supported by terraform add`,
+		},
+
+		"external data source address": {
+			[]string{"data.external.lookup"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("data.external.lookup")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				ExternalData: true,
+			},
+			``,
+		},
+
+		"external data source address mixed with a resource address": {
+			[]string{"data.external.lookup", "test_foo.bar"},
+			&Add{
+				Addrs: []addrs.AbsResourceInstance{
+					mustResourceInstanceAddr("data.external.lookup"),
+					mustResourceInstanceAddr("test_foo.bar"),
+				},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				ExternalData: true,
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-with-script": {
+			[]string{"-with-script=sh", "-out=generated.tf", "data.external.lookup"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("data.external.lookup")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				OutPath:      "generated.tf",
+				ViewType:     ViewHuman,
+				ExternalData: true,
+				WithScript:   "sh",
+			},
+			``,
+		},
+
+		"-with-script without a data.external address": {
+			[]string{"-with-script=sh", "-out=generated.tf", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				OutPath:      "generated.tf",
+				ViewType:     ViewHuman,
+				WithScript:   "sh",
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-with-script without -out": {
+			[]string{"-with-script=python", "data.external.lookup"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("data.external.lookup")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+				ExternalData: true,
+				WithScript:   "python",
+			},
+			`Incompatible command-line options`,
+		},
+
+		"-with-script with an unknown language": {
+			[]string{"-with-script=ruby", "-out=generated.tf", "data.external.lookup"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("data.external.lookup")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				OutPath:      "generated.tf",
+				ViewType:     ViewHuman,
+				ExternalData: true,
+				WithScript:   "ruby",
+			},
+			`Invalid script language`,
+		},
+
+		"module call address without from-state": {
+			[]string{"module.foo"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+			},
+			`Module calls are not yet supported by terraform add`,
+		},
+
+		"-placeholder-format": {
+			[]string{"-placeholder-format={{ %s }}", "test_foo.bar"},
+			&Add{
+				Addrs:             []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:             &State{Lock: true},
+				Newline:           "auto",
+				MaxLineWidth:      120,
+				PlaceholderFormat: "{{ %s }}",
+				ViewType:          ViewHuman,
+			},
+			``,
+		},
+
+		"-with-check": {
+			[]string{"-with-check", "test_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				WithCheck:    true,
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-clone": {
+			[]string{"-clone", "test_foo.exists", "test_foo.copy"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.copy")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Clone:        true,
+				CloneSource: func() *addrs.AbsResourceInstance {
+					addr := mustResourceInstanceAddr("test_foo.exists")
+					return &addr
+				}(),
+				ViewType: ViewHuman,
+			},
+			``,
+		},
+
+		"-audit": {
+			[]string{"-audit"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Audit:        true,
+				AuditFormat:  "table",
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		"-audit-format": {
+			[]string{"-audit", "-audit-format=json"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Audit:        true,
+				AuditFormat:  "json",
+				ViewType:     ViewHuman,
+			},
+			``,
+		},
+
+		// Error cases
+		"invalid max line width": {
+			[]string{"-max-line-width=0", "test_foo.bar"},
+			&Add{
+				State:   &State{Lock: true},
+				Newline: "auto",
+				// MaxLineWidth stays at the invalid given value
+				ViewType: ViewHuman,
+			},
+			`Invalid maximum line width`,
+		},
+		"invalid newline mode": {
+			[]string{"-newline=cr", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "cr",
+				MaxLineWidth: 120,
+				ViewType:     ViewHuman,
+			},
+			`Invalid newline mode`,
+		},
+		"reserved output filename": {
+			[]string{"-out=con.tf", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				OutPath:      "con.tf",
+				ViewType:     ViewHuman,
+			},
+			`Invalid output filename`,
+		},
+		"missing required argument": {
+			nil,
+			&Add{
+				ViewType:     ViewHuman,
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+			},
+			`Too few command line arguments`,
+		},
+		"multiple addresses": {
+			[]string{"-from-state", "resource_foo.bar", "module.foo.resource_foo.baz"},
+			&Add{
+				Addrs: []addrs.AbsResourceInstance{
+					mustResourceInstanceAddr("resource_foo.bar"),
+					mustResourceInstanceAddr("module.foo.resource_foo.baz"),
+				},
+				ViewType:     ViewHuman,
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				FromState:    true,
+			},
+			``,
+		},
+		"invalid target address": {
+			[]string{"definitely-not_a-VALID-resource"},
+			&Add{
+				ViewType:     ViewHuman,
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+			},
+			`Error parsing resource address: definitely-not_a-VALID-resource`,
+		},
+		"invalid provider flag": {
+			[]string{"-provider=/this/isn't/quite/correct", "resource_foo.bar"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("resource_foo.bar")},
+				ViewType:     ViewHuman,
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+			},
+			`Invalid provider string: /this/isn't/quite/correct`,
+		},
+		"redact without from-state": {
+			[]string{"-redact=pci", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Redact:       "pci",
+				ViewType:     ViewHuman,
+			},
+			`Incompatible command-line options`,
+		},
+		"clone wrong address count": {
+			[]string{"-clone", "test_foo.exists"},
+			&Add{
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_foo.exists")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Clone:        true,
+				ViewType:     ViewHuman,
+			},
+			`Invalid clone arguments`,
+		},
+		"invalid placeholder format": {
+			[]string{"-placeholder-format=<TOKEN>", "test_foo.bar"},
+			&Add{
+				State:             &State{Lock: true},
+				Newline:           "auto",
+				MaxLineWidth:      120,
+				PlaceholderFormat: "<TOKEN>",
+				ViewType:          ViewHuman,
+			},
+			`Invalid placeholder format`,
+		},
+		"invalid audit format": {
+			[]string{"-audit", "-audit-format=csv"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Audit:        true,
+				AuditFormat:  "csv",
+				ViewType:     ViewHuman,
+			},
+			`Invalid audit format`,
+		},
+		"audit takes no addresses": {
+			[]string{"-audit", "test_foo.bar"},
+			&Add{
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				Audit:        true,
+				AuditFormat:  "table",
+				ViewType:     ViewHuman,
+			},
+			`Too many command line arguments`,
+		},
+		"incompatible options": {
+			[]string{"-from-state", "-provider=provider[\"example.com/happycorp/test\"]", "test_compute.bar"},
+			&Add{ViewType: ViewHuman,
+				Addrs:        []addrs.AbsResourceInstance{mustResourceInstanceAddr("test_compute.bar")},
+				State:        &State{Lock: true},
+				Newline:      "auto",
+				MaxLineWidth: 120,
+				FromState:    true,
+			},
+			`Incompatible command-line options`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, diags := ParseAdd(test.args)
+			if test.wantError != "" {
+				if len(diags) != 1 {
+					t.Fatalf("got %d diagnostics; want exactly 1\n", len(diags))
+				}
+				if diags[0].Severity() != tfdiags.Error {
+					t.Fatalf("got a warning; want an error\n%s", diags.ErrWithWarnings())
+				}
+				if desc := diags[0].Description(); desc.Summary != test.wantError {
+					t.Fatalf("wrong error\ngot:  %s\nwant: %s", desc.Summary, test.wantError)
+				}
+			} else {
+				if len(diags) != 0 {
+					t.Fatalf("got %d diagnostics; want none\n%s", len(diags), diags.Err().Error())
+				}
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("unexpected result\n%s", diff)
+			}
+		})
+	}
+}
+
+func mustResourceInstanceAddrPtr(s string) *addrs.AbsResourceInstance {
+	addr := mustResourceInstanceAddr(s)
+	return &addr
+}
+
+func mustResourceInstanceAddr(s string) addrs.AbsResourceInstance {
+	addr, diags := addrs.ParseAbsResourceInstanceStr(s)
+	if diags.HasErrors() {
+		panic(diags.Err())
+	}
+	return addr
+}
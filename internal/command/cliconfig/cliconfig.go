@@ -13,6 +13,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/hashicorp/hcl"
@@ -48,6 +49,32 @@ type Config struct {
 	// configuration, but we decode into a slice here so that we can handle
 	// that validation at validation time rather than initial decode time.
 	ProviderInstallation []*ProviderInstallation
+
+	// RedactionProfiles are named sets of attribute name patterns whose
+	// values commands generating configuration from state (such as
+	// "terraform add -redact=NAME") replace with variable references
+	// instead of writing the real values.
+	RedactionProfiles map[string]*ConfigRedactionProfile `hcl:"redaction_profile"`
+
+	// AddGenerators are external generator programs which "terraform add"
+	// runs to post-process or replace the configuration it generates for
+	// resource types matching the block label, which is a pattern such as
+	// "aws_iam_*".
+	AddGenerators map[string]*ConfigAddGenerator `hcl:"add_generator"`
+
+	// AddPlaceholders customizes the value "terraform add" renders for
+	// required attributes with no known value, per primitive type name
+	// ("string", "number" or "bool"), instead of null — for example
+	// string = "CHANGE_ME" — so generated files validate while remaining
+	// obviously incomplete.
+	AddPlaceholders map[string]interface{} `hcl:"add_placeholders"`
+
+	// Generate represents any "generate" blocks in the configuration, with
+	// settings shared by the commands which generate configuration (such
+	// as "terraform add"). Only one of these is allowed across the whole
+	// configuration, but we decode into a slice here so that we can handle
+	// that validation at validation time rather than initial decode time.
+	Generate []*ConfigGenerate `hcl:"generate"`
 }
 
 // ConfigHost is the structure of the "host" nested block within the CLI
@@ -63,6 +90,33 @@ type ConfigCredentialsHelper struct {
 	Args []string `hcl:"args"`
 }
 
+// ConfigRedactionProfile is the structure of the "redaction_profile" nested
+// block within the CLI configuration. Patterns are attribute names, possibly
+// containing "*" wildcards, such as "password" or "*secret*".
+type ConfigRedactionProfile struct {
+	Patterns []string `hcl:"patterns"`
+}
+
+// ConfigAddGenerator is the structure of the "add_generator" nested block
+// within the CLI configuration. The program is run with the generated
+// configuration on its standard input and its standard output replaces the
+// generated configuration, similar to how a credentials_helper program
+// extends credentials handling.
+type ConfigAddGenerator struct {
+	Command string   `hcl:"command"`
+	Args    []string `hcl:"args"`
+}
+
+// ConfigGenerate is the structure of the "generate" nested block within the
+// CLI configuration. Hints controls the next-step suggestions the generating
+// commands print after their output; a nil value leaves the built-in default
+// (enabled) in effect, so setting hints = false is how organizations
+// embedding "terraform add" in automation suppress the suggestions globally
+// rather than passing -quiet everywhere.
+type ConfigGenerate struct {
+	Hints *bool `hcl:"hints"`
+}
+
 // BuiltinConfig is the built-in defaults for the configuration. These
 // can be overridden by user configurations.
 var BuiltinConfig Config
@@ -273,6 +327,76 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		)
 	}
 
+	// Check that all "redaction_profile" blocks have valid patterns.
+	for name, profile := range c.RedactionProfiles {
+		if len(profile.Patterns) == 0 {
+			diags = diags.Append(
+				fmt.Errorf("The redaction_profile %q block must list at least one pattern", name),
+			)
+		}
+		for _, pattern := range profile.Patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				diags = diags.Append(
+					fmt.Errorf("The redaction_profile %q block has an invalid pattern %q: %s", name, pattern, err),
+				)
+			}
+		}
+	}
+
+	// Check that all "add_generator" blocks have valid patterns and name a
+	// program to run.
+	for pattern, gen := range c.AddGenerators {
+		if _, err := path.Match(pattern, ""); err != nil {
+			diags = diags.Append(
+				fmt.Errorf("The add_generator %q block has an invalid resource type pattern: %s", pattern, err),
+			)
+		}
+		if gen.Command == "" {
+			diags = diags.Append(
+				fmt.Errorf("The add_generator %q block must set command to the program to run", pattern),
+			)
+		}
+	}
+
+	// Check that any "add_placeholders" block customizes only the primitive
+	// type names, each with a value of the matching type.
+	for typeName, value := range c.AddPlaceholders {
+		switch typeName {
+		case "string":
+			if _, ok := value.(string); !ok {
+				diags = diags.Append(
+					fmt.Errorf("The add_placeholders value for %q must be a string", typeName),
+				)
+			}
+		case "number":
+			switch value.(type) {
+			case int, float64:
+				// ok
+			default:
+				diags = diags.Append(
+					fmt.Errorf("The add_placeholders value for %q must be a number", typeName),
+				)
+			}
+		case "bool":
+			if _, ok := value.(bool); !ok {
+				diags = diags.Append(
+					fmt.Errorf("The add_placeholders value for %q must be a bool", typeName),
+				)
+			}
+		default:
+			diags = diags.Append(
+				fmt.Errorf("The add_placeholders block customizes the placeholder for each primitive type; %q is not one of \"string\", \"number\" or \"bool\"", typeName),
+			)
+		}
+	}
+
+	// Should have zero or one "generate" blocks
+	if len(c.Generate) > 1 {
+		diags = diags.Append(
+			fmt.Errorf("No more than one generate block may be specified"),
+		)
+	}
+
 	if c.PluginCacheDir != "" {
 		_, err := os.Stat(c.PluginCacheDir)
 		if err != nil {
@@ -355,9 +479,57 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.ProviderInstallation = append(result.ProviderInstallation, c2.ProviderInstallation...)
 	}
 
+	if (len(c.RedactionProfiles) + len(c2.RedactionProfiles)) > 0 {
+		result.RedactionProfiles = make(map[string]*ConfigRedactionProfile)
+		for name, profile := range c.RedactionProfiles {
+			result.RedactionProfiles[name] = profile
+		}
+		for name, profile := range c2.RedactionProfiles {
+			result.RedactionProfiles[name] = profile
+		}
+	}
+
+	if (len(c.AddGenerators) + len(c2.AddGenerators)) > 0 {
+		result.AddGenerators = make(map[string]*ConfigAddGenerator)
+		for pattern, gen := range c.AddGenerators {
+			result.AddGenerators[pattern] = gen
+		}
+		for pattern, gen := range c2.AddGenerators {
+			result.AddGenerators[pattern] = gen
+		}
+	}
+
+	if (len(c.AddPlaceholders) + len(c2.AddPlaceholders)) > 0 {
+		result.AddPlaceholders = make(map[string]interface{})
+		for typeName, value := range c.AddPlaceholders {
+			result.AddPlaceholders[typeName] = value
+		}
+		for typeName, value := range c2.AddPlaceholders {
+			result.AddPlaceholders[typeName] = value
+		}
+	}
+
+	if (len(c.Generate) + len(c2.Generate)) > 0 {
+		result.Generate = append(result.Generate, c.Generate...)
+		result.Generate = append(result.Generate, c2.Generate...)
+	}
+
 	return &result
 }
 
+// GenerateHints returns whether the commands which generate configuration
+// should print their next-step hints, from any "generate" block in the
+// configuration. The default, with no such block or no hints setting in it,
+// is to print them.
+func (c *Config) GenerateHints() bool {
+	for _, gen := range c.Generate {
+		if gen.Hints != nil {
+			return *gen.Hints
+		}
+	}
+	return true
+}
+
 func cliConfigFile() (string, error) {
 	mustExist := true
 
@@ -104,6 +104,91 @@ func TestLoadConfig_credentials(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_redactionProfiles(t *testing.T) {
+	got, diags := loadConfigFile(filepath.Join(fixtureDir, "redaction-profiles"))
+	if len(diags) != 0 {
+		t.Fatalf("%s", diags.Err())
+	}
+
+	want := &Config{
+		RedactionProfiles: map[string]*ConfigRedactionProfile{
+			"pci": {
+				Patterns: []string{"password", "*secret*", "private_key"},
+			},
+			"internal": {
+				Patterns: []string{"*token*"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
+
+func TestLoadConfig_addGenerators(t *testing.T) {
+	got, diags := loadConfigFile(filepath.Join(fixtureDir, "add-generators"))
+	if len(diags) != 0 {
+		t.Fatalf("%s", diags.Err())
+	}
+
+	want := &Config{
+		AddGenerators: map[string]*ConfigAddGenerator{
+			"aws_iam_*": {
+				Command: "/usr/local/bin/tf-gen-iam",
+				Args:    []string{"--wrap"},
+			},
+			"aws_s3_bucket": {
+				Command: "tf-gen-s3",
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
+
+func TestLoadConfig_addPlaceholders(t *testing.T) {
+	got, diags := loadConfigFile(filepath.Join(fixtureDir, "add-placeholders"))
+	if len(diags) != 0 {
+		t.Fatalf("%s", diags.Err())
+	}
+
+	want := &Config{
+		AddPlaceholders: map[string]interface{}{
+			"string": "CHANGE_ME",
+			"number": 0,
+			"bool":   false,
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+}
+
+func TestLoadConfig_generate(t *testing.T) {
+	got, diags := loadConfigFile(filepath.Join(fixtureDir, "generate"))
+	if len(diags) != 0 {
+		t.Fatalf("%s", diags.Err())
+	}
+
+	hints := false
+	want := &Config{
+		Generate: []*ConfigGenerate{
+			{Hints: &hints},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+	if got.GenerateHints() {
+		t.Errorf("GenerateHints returned true; want false")
+	}
+}
+
 func TestConfigValidate(t *testing.T) {
 	tests := map[string]struct {
 		Config    *Config
@@ -170,6 +255,97 @@ func TestConfigValidate(t *testing.T) {
 			},
 			1, // no more than one credentials_helper block allowed
 		},
+		"redaction profile good": {
+			&Config{
+				RedactionProfiles: map[string]*ConfigRedactionProfile{
+					"pci": {Patterns: []string{"password", "*secret*"}},
+				},
+			},
+			0,
+		},
+		"redaction profile with no patterns": {
+			&Config{
+				RedactionProfiles: map[string]*ConfigRedactionProfile{
+					"pci": {},
+				},
+			},
+			1, // redaction_profile block must list at least one pattern
+		},
+		"redaction profile with bad pattern": {
+			&Config{
+				RedactionProfiles: map[string]*ConfigRedactionProfile{
+					"pci": {Patterns: []string{"[password"}},
+				},
+			},
+			1, // redaction_profile block has an invalid pattern
+		},
+		"add generator good": {
+			&Config{
+				AddGenerators: map[string]*ConfigAddGenerator{
+					"aws_iam_*": {Command: "tf-gen-iam"},
+				},
+			},
+			0,
+		},
+		"add generator with no command": {
+			&Config{
+				AddGenerators: map[string]*ConfigAddGenerator{
+					"aws_iam_*": {},
+				},
+			},
+			1, // add_generator block must set command
+		},
+		"add generator with bad pattern": {
+			&Config{
+				AddGenerators: map[string]*ConfigAddGenerator{
+					"[aws_iam": {Command: "tf-gen-iam"},
+				},
+			},
+			1, // add_generator block has an invalid pattern
+		},
+		"add placeholders good": {
+			&Config{
+				AddPlaceholders: map[string]interface{}{
+					"string": "CHANGE_ME",
+					"number": 0,
+					"bool":   false,
+				},
+			},
+			0,
+		},
+		"add placeholders with unknown type name": {
+			&Config{
+				AddPlaceholders: map[string]interface{}{
+					"list": "nope",
+				},
+			},
+			1, // add_placeholders covers only the primitive type names
+		},
+		"add placeholders with mismatched value": {
+			&Config{
+				AddPlaceholders: map[string]interface{}{
+					"number": "zero",
+				},
+			},
+			1, // add_placeholders value for "number" must be a number
+		},
+		"generate good": {
+			&Config{
+				Generate: []*ConfigGenerate{
+					{},
+				},
+			},
+			0,
+		},
+		"generate with two blocks": {
+			&Config{
+				Generate: []*ConfigGenerate{
+					{},
+					{},
+				},
+			},
+			1, // no more than one generate block
+		},
 		"provider_installation good none": {
 			&Config{
 				ProviderInstallation: nil,
@@ -0,0 +1,65 @@
+package e2etest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/internal/e2e"
+	"github.com/hashicorp/terraform/internal/getproviders"
+)
+
+// TestAdd runs "terraform add" against a real provider plugin executing over
+// the plugin protocol, rather than an in-process mock, so that schema
+// marshalling and protocol negotiation are exercised too.
+func TestAdd(t *testing.T) {
+	t.Parallel()
+
+	tf := e2e.NewBinary(terraformBin, "testdata/add-resource")
+	defer tf.Close()
+
+	// Build the simple provider and make it available in a local plugin
+	// cache directory, the same way the provider protocol tests do.
+	simpleProvider := filepath.Join(tf.WorkDir(), "terraform-provider-simple")
+	simpleProviderExe := e2e.GoBuild("github.com/hashicorp/terraform/internal/provider-simple/main", simpleProvider)
+
+	platform := getproviders.CurrentPlatform.String()
+	hashiDir := "cache/registry.terraform.io/hashicorp/"
+	if err := os.MkdirAll(tf.Path(hashiDir, "simple/0.0.1/", platform), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(simpleProviderExe, tf.Path(hashiDir, "simple/0.0.1/", platform, "terraform-provider-simple")); err != nil {
+		t.Fatal(err)
+	}
+
+	//// INIT
+	_, stderr, err := tf.Run("init", "-plugin-dir=cache")
+	if err != nil {
+		t.Fatalf("unexpected init error: %s\nstderr:\n%s", err, stderr)
+	}
+
+	//// ADD
+	stdout, stderr, err := tf.Run("add", "-optional", "simple_resource.example")
+	if err != nil {
+		t.Fatalf("unexpected add error: %s\nstderr:\n%s", err, stderr)
+	}
+
+	if !strings.Contains(stdout, `resource "simple_resource" "example" {`) {
+		t.Errorf("missing resource block in output:\n%s", stdout)
+	}
+	// The provider's schema declares "value" as an optional string; its
+	// arrival here proves the schema made the round trip over the protocol.
+	if !strings.Contains(stdout, "value = null # OPTIONAL string") {
+		t.Errorf("missing optional argument in output:\n%s", stdout)
+	}
+
+	//// ADD (default: the simple resource requires nothing at all)
+	stdout, stderr, err = tf.Run("add", "simple_resource.example")
+	if err != nil {
+		t.Fatalf("unexpected add error: %s\nstderr:\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, "# (no required attributes)") {
+		t.Errorf("missing empty-body comment in output:\n%s", stdout)
+	}
+}
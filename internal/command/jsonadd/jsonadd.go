@@ -0,0 +1,130 @@
+// Package jsonadd exposes the configuration generation engine behind
+// "terraform add" as a machine-readable API, for editor integrations such
+// as terraform-ls. The request and result types marshal to the JSON shapes
+// used by the commands JSON-RPC layer that terraform-ls vendors from this
+// repository, so an "insert resource block" code action gets position-aware
+// insertion data (target file and byte offset) rather than raw text only.
+//
+// This is an internal API: it may still change between releases, but
+// changes to the JSON field names or their meaning should be treated as
+// breaking for terraform-ls and made deliberately.
+package jsonadd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/command/jsonprovider"
+	"github.com/hashicorp/terraform/internal/command/views"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// GenerateRequest describes one resource block to generate and where the
+// result is intended to be inserted.
+type GenerateRequest struct {
+	// Address is the resource to generate, in resource addressing syntax.
+	Address string `json:"address"`
+
+	// ProviderLocalName and ProviderAlias select the provider configuration
+	// the block should reference. An empty local name means the provider
+	// implied by the resource type's prefix.
+	ProviderLocalName string `json:"provider_local_name,omitempty"`
+	ProviderAlias     string `json:"provider_alias,omitempty"`
+
+	// Optional includes optional arguments in the template, matching the
+	// -optional command line option.
+	Optional bool `json:"optional"`
+
+	// Dir is the module directory the insertion targets.
+	Dir string `json:"dir"`
+
+	// TargetFile optionally names the file within Dir the block should be
+	// added to. When empty, the file is chosen the same way the command's
+	// -out-dir mode groups blocks by resource type.
+	TargetFile string `json:"target_file,omitempty"`
+}
+
+// Insertion is a position-aware result: the file the rendered block should
+// be inserted into, the byte offset of the insertion point, and the text to
+// insert there.
+type Insertion struct {
+	// Path is the file to insert into. It may not exist yet, in which case
+	// Offset is zero and the caller should create it.
+	Path string `json:"path"`
+
+	// Offset is the byte offset within Path at which Text should be
+	// inserted: always the end of the file's current contents.
+	Offset int64 `json:"offset"`
+
+	// Text is the rendered configuration block, including a separating
+	// blank line when the target file already has content.
+	Text string `json:"text"`
+
+	// ConfigHCL is the rendered configuration block alone, without the
+	// insertion framing added to Text, ready to paste as-is. Simple
+	// consumers such as chat bots or web UIs can show this directly
+	// without re-implementing HCL rendering.
+	ConfigHCL string `json:"config_hcl"`
+
+	// Schema is a structured description of the generated block's
+	// arguments, in the same shape as the "block" objects within
+	// "terraform providers schema -json" output, for consumers which need
+	// structure rather than (or as well as) the rendered text.
+	Schema json.RawMessage `json:"schema"`
+}
+
+// Generate renders a configuration block for the requested resource using
+// the given schema, and computes where it should be inserted.
+func Generate(req GenerateRequest, schema *configschema.Block) (*Insertion, error) {
+	addr, diags := addrs.ParseAbsResourceInstanceStr(req.Address)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("invalid resource address %q: %s", req.Address, diags.Err())
+	}
+
+	pc := addrs.LocalProviderConfig{
+		LocalName: req.ProviderLocalName,
+		Alias:     req.ProviderAlias,
+	}
+	if pc.LocalName == "" {
+		pc.LocalName = addr.Resource.Resource.ImpliedProvider()
+	}
+
+	rendered, err := views.RenderResource(addr, schema, pc, cty.NilVal, req.Optional)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaJSON, err := jsonprovider.MarshalBlock(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	name := req.TargetFile
+	if name == "" {
+		name = views.GeneratedFileName("type", addr, pc)
+	}
+	path := filepath.Join(req.Dir, name)
+
+	var offset int64
+	text := string(rendered) + "\n"
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+		if offset > 0 {
+			text = "\n" + text
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return &Insertion{
+		Path:      path,
+		Offset:    offset,
+		Text:      text,
+		ConfigHCL: string(rendered),
+		Schema:    schemaJSON,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package jsonadd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestGenerate(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+			"acl":    {Type: cty.String, Optional: true},
+		},
+	}
+
+	t.Run("new file", func(t *testing.T) {
+		dir := t.TempDir()
+		ins, err := Generate(GenerateRequest{
+			Address: "aws_s3_bucket.example",
+			Dir:     dir,
+		}, schema)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if want := filepath.Join(dir, "s3.tf"); ins.Path != want {
+			t.Errorf("wrong path %q; want %q", ins.Path, want)
+		}
+		if ins.Offset != 0 {
+			t.Errorf("wrong offset %d; want 0", ins.Offset)
+		}
+		if !strings.Contains(ins.Text, `resource "aws_s3_bucket" "example" {`) {
+			t.Errorf("missing resource block in text:\n%s", ins.Text)
+		}
+		if !strings.Contains(ins.Text, "bucket = null") {
+			t.Errorf("missing required argument in text:\n%s", ins.Text)
+		}
+		if strings.Contains(ins.Text, "acl") {
+			t.Errorf("unexpected optional argument in text:\n%s", ins.Text)
+		}
+		if strings.Contains(ins.Text, "# NOTE") {
+			t.Errorf("unexpected experimental note in text:\n%s", ins.Text)
+		}
+
+		// ConfigHCL is the block without the insertion framing, ready to
+		// paste as-is.
+		if want := strings.TrimSuffix(ins.Text, "\n"); ins.ConfigHCL != want {
+			t.Errorf("wrong config_hcl:\ngot:  %q\nwant: %q", ins.ConfigHCL, want)
+		}
+
+		// Schema embeds the block's structure in providers schema -json shape.
+		var schemaObj struct {
+			Attributes map[string]struct {
+				Required bool `json:"required"`
+				Optional bool `json:"optional"`
+			} `json:"attributes"`
+		}
+		if err := json.Unmarshal(ins.Schema, &schemaObj); err != nil {
+			t.Fatalf("invalid schema JSON: %s", err)
+		}
+		if !schemaObj.Attributes["bucket"].Required {
+			t.Errorf("bucket not marked required in schema JSON:\n%s", ins.Schema)
+		}
+		if !schemaObj.Attributes["acl"].Optional {
+			t.Errorf("acl not marked optional in schema JSON:\n%s", ins.Schema)
+		}
+	})
+
+	t.Run("existing file", func(t *testing.T) {
+		dir := t.TempDir()
+		existing := "resource \"aws_s3_bucket\" \"other\" {}\n"
+		if err := os.WriteFile(filepath.Join(dir, "buckets.tf"), []byte(existing), 0600); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		ins, err := Generate(GenerateRequest{
+			Address:    "aws_s3_bucket.example",
+			Dir:        dir,
+			TargetFile: "buckets.tf",
+			Optional:   true,
+		}, schema)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if want := filepath.Join(dir, "buckets.tf"); ins.Path != want {
+			t.Errorf("wrong path %q; want %q", ins.Path, want)
+		}
+		if want := int64(len(existing)); ins.Offset != want {
+			t.Errorf("wrong offset %d; want %d", ins.Offset, want)
+		}
+		if !strings.HasPrefix(ins.Text, "\n") {
+			t.Errorf("missing separating newline in text:\n%q", ins.Text)
+		}
+		if !strings.Contains(ins.Text, "acl") {
+			t.Errorf("missing optional argument in text:\n%s", ins.Text)
+		}
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		_, err := Generate(GenerateRequest{Address: "not!valid"}, schema)
+		if err == nil {
+			t.Fatal("expected error for invalid address")
+		}
+	})
+}
@@ -1,9 +1,21 @@
 package jsonprovider
 
 import (
+	"encoding/json"
+
 	"github.com/hashicorp/terraform/internal/configs/configschema"
 )
 
+// MarshalBlock returns the JSON encoding of a single block schema, in the
+// same shape as the "block" objects within "terraform providers schema -json"
+// output. This is for other packages which embed one block's schema in their
+// own output, such as jsonadd. The attribute and block type maps marshal with
+// their keys in sorted order (encoding/json guarantees this for maps), so the
+// encoding is deterministic and safe for consumers that diff it between runs.
+func MarshalBlock(configBlock *configschema.Block) ([]byte, error) {
+	return json.Marshal(marshalBlock(configBlock))
+}
+
 type block struct {
 	Attributes      map[string]*attribute `json:"attributes,omitempty"`
 	BlockTypes      map[string]*blockType `json:"block_types,omitempty"`
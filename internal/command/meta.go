@@ -99,6 +99,31 @@ type Meta struct {
 	// into the given directory.
 	PluginCacheDir string
 
+	// RedactionProfiles are the named sets of attribute name patterns from
+	// any redaction_profile blocks in the CLI configuration, for commands
+	// which generate configuration from state values (see "terraform add
+	// -redact").
+	RedactionProfiles map[string][]string
+
+	// AddGenerators are the external generator programs from any
+	// add_generator blocks in the CLI configuration, which "terraform add"
+	// runs to post-process or replace the configuration it generates for
+	// matching resource types. Ordered by pattern so generation is
+	// deterministic.
+	AddGenerators []arguments.AddGenerator
+
+	// AddPlaceholders maps primitive type names ("string", "number" or
+	// "bool") to the value "terraform add" renders for required attributes
+	// of that type instead of null, from any add_placeholders block in the
+	// CLI configuration.
+	AddPlaceholders map[string]interface{}
+
+	// SuppressAddHints disables the next-step hints "terraform add" prints
+	// after generated configuration, from a generate block in the CLI
+	// configuration or the TF_GENERATE_HINTS environment variable, for
+	// automation where the output streams must carry only configuration.
+	SuppressAddHints bool
+
 	// ProviderSource allows determining the available versions of a provider
 	// and determines where a distribution package for a particular
 	// provider version can be obtained.
@@ -260,6 +260,19 @@ func TestStateList_modules(t *testing.T) {
 		}
 	})
 
+	t.Run("wildcard resource type in module", func(t *testing.T) {
+		ui.OutputWriter.Reset()
+		args := []string{"module.nest.test_instance.*"}
+		if code := c.Run(args); code != 0 {
+			t.Fatalf("bad: %d", code)
+		}
+		expected := "module.nest.test_instance.nest\n"
+		actual := ui.OutputWriter.String()
+		if actual != expected {
+			t.Fatalf("Expected:\n%q\n\nTo equal: %q", actual, expected)
+		}
+	})
+
 	t.Run("completely nonexistent module", func(t *testing.T) {
 		// finally get the state for a module with an index
 		ui.OutputWriter.Reset()
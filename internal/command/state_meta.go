@@ -3,6 +3,7 @@ package command
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform/internal/addrs"
@@ -164,6 +165,64 @@ func (c *StateMeta) lookupResourceInstanceAddr(state *states.State, allowMissing
 	return ret, diags
 }
 
+// lookupWildcardResourceInstances matches all instances of a resource type
+// given as a "TYPE.*" pattern. With no module prefix the pattern matches the
+// type in every module; with one (module.foo.TYPE.*) only resources in that
+// exact module instance match.
+func (c *StateMeta) lookupWildcardResourceInstances(state *states.State, allowMissing bool, addrStr string) ([]addrs.AbsResourceInstance, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	prefix := strings.TrimSuffix(addrStr, ".*")
+	typeName := prefix
+	var moduleAddr addrs.ModuleInstance
+	haveModule := false
+	if strings.HasPrefix(prefix, "module.") {
+		idx := strings.LastIndex(prefix, ".")
+		modStr, lastPart := prefix[:idx], prefix[idx+1:]
+		var modDiags tfdiags.Diagnostics
+		moduleAddr, modDiags = addrs.ParseModuleInstanceStr(modStr)
+		if modDiags.HasErrors() {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				fmt.Sprintf("Invalid address pattern: %s", addrStr),
+				"A wildcard pattern consists of a resource type followed by \".*\", optionally preceded by a module address.",
+			))
+			return nil, diags
+		}
+		typeName = lastPart
+		haveModule = true
+	}
+
+	var ret []addrs.AbsResourceInstance
+	for _, ms := range state.Modules {
+		if haveModule && !moduleAddr.Equal(ms.Addr) {
+			continue
+		}
+		for _, rs := range ms.Resources {
+			if rs.Addr.Resource.Mode != addrs.ManagedResourceMode {
+				continue
+			}
+			if rs.Addr.Resource.Type != typeName {
+				continue
+			}
+			ret = append(ret, c.collectResourceInstances(ms.Addr, rs)...)
+		}
+	}
+
+	if len(ret) == 0 && !allowMissing {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Unknown resource",
+			fmt.Sprintf("The current state contains no resources matching %s.", addrStr),
+		))
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Less(ret[j])
+	})
+	return ret, diags
+}
+
 func (c *StateMeta) lookupSingleStateObjectAddr(state *states.State, addrStr string) (addrs.Targetable, tfdiags.Diagnostics) {
 	target, diags := addrs.ParseTargetStr(addrStr)
 	if diags.HasErrors() {
@@ -172,10 +231,25 @@ func (c *StateMeta) lookupSingleStateObjectAddr(state *states.State, addrStr str
 	return target.Subject, diags
 }
 
+// lookupResourceInstanceAddrs resolves the given address strings against the
+// state for read-only callers: "terraform add" and "terraform state list".
+// Only here a trailing ".*" selects every resource of a given type, optionally
+// within one module, e.g. aws_s3_bucket.* or module.foo.aws_s3_bucket.*; such
+// patterns aren't parseable as addresses, so they are expanded against the
+// state instead. The destructive state commands call
+// lookupResourceInstanceAddr directly and deliberately get no wildcard
+// expansion, so that a pattern like test_instance.* fails to parse there
+// rather than silently matching every instance of the type.
 func (c *StateMeta) lookupResourceInstanceAddrs(state *states.State, addrStrs ...string) ([]addrs.AbsResourceInstance, tfdiags.Diagnostics) {
 	var ret []addrs.AbsResourceInstance
 	var diags tfdiags.Diagnostics
 	for _, addrStr := range addrStrs {
+		if strings.HasSuffix(addrStr, ".*") {
+			moreAddrs, moreDiags := c.lookupWildcardResourceInstances(state, false, addrStr)
+			ret = append(ret, moreAddrs...)
+			diags = diags.Append(moreDiags)
+			continue
+		}
 		moreAddrs, moreDiags := c.lookupResourceInstanceAddr(state, false, addrStr)
 		ret = append(ret, moreAddrs...)
 		diags = diags.Append(moreDiags)
@@ -0,0 +1,14 @@
+terraform {
+    required_providers  {
+        test = {
+            source = "hashicorp/test"
+        }
+        othertest = {
+            source = "happycorp/test"
+        }
+    }
+}
+
+resource "test_instance" "exists" {
+    // I exist!
+}
\ No newline at end of file
@@ -0,0 +1,10 @@
+terraform {
+    required_providers  {
+        test = {
+            source = "hashicorp/test"
+        }
+        broken = {
+            source = "hashicorp/broken"
+        }
+    }
+}
@@ -0,0 +1,23 @@
+terraform {
+    required_providers  {
+        test = {
+            source = "hashicorp/test"
+        }
+    }
+}
+
+resource "test_instance" "base" {
+    ami = "ami-654321"
+}
+
+resource "test_instance" "exists" {
+    # pinned to the golden image
+    ami = "ami-123456"
+    id  = "i-0abc123"
+
+    depends_on = [test_instance.base]
+
+    lifecycle {
+        create_before_destroy = true
+    }
+}
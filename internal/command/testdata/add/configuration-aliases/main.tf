@@ -0,0 +1,18 @@
+terraform {
+    required_providers {
+        test = {
+            source = "hashicorp/test"
+        }
+    }
+}
+
+provider "test" {
+    alias = "special"
+}
+
+module "child" {
+    source = "./module"
+    providers = {
+        test.special = test.special
+    }
+}
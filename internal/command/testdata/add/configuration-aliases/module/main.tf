@@ -0,0 +1,8 @@
+terraform {
+    required_providers {
+        test = {
+            source                = "hashicorp/test"
+            configuration_aliases = [test.special]
+        }
+    }
+}
@@ -0,0 +1,9 @@
+terraform {
+    required_providers {
+        test = {
+            source = "hashicorp/test"
+        }
+    }
+}
+
+resource "test_instance" "exists" {}
\ No newline at end of file
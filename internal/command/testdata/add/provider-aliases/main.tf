@@ -0,0 +1,18 @@
+terraform {
+    required_providers  {
+        test = {
+            source = "hashicorp/test"
+        }
+    }
+}
+
+provider "test" {
+}
+
+provider "test" {
+    alias = "east"
+}
+
+provider "test" {
+    alias = "west"
+}
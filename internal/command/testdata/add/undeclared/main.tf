@@ -0,0 +1,5 @@
+// This module deliberately has no required_providers block, so that we can
+// test the warning about providers Terraform has to assume a local name for.
+resource "test_instance" "exists" {
+    // I exist!
+}
@@ -0,0 +1,11 @@
+terraform {
+    required_providers  {
+        test = {
+            source = "hashicorp/test"
+        }
+    }
+}
+
+resource "test_instance" "exists" {
+    name = "${terraform.workspace}-exists"
+}
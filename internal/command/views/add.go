@@ -0,0 +1,1865 @@
+package views
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/command/arguments"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+	"github.com/hashicorp/terraform/version"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// ResourceToGenerate describes one resource block for bulk generation with
+// Resources: the address to declare, the schema to generate from, the local
+// provider configuration to reference, and the decoded state value to
+// populate it with (cty.NilVal for a placeholder template).
+type ResourceToGenerate struct {
+	Addr     addrs.AbsResourceInstance
+	Schema   *configschema.Block
+	Provider addrs.LocalProviderConfig
+	State    cty.Value
+}
+
+// Add is the view interface for the "terraform add" command.
+type Add interface {
+	Resource(addrs.AbsResourceInstance, *configschema.Block, addrs.LocalProviderConfig, cty.Value) error
+	Resources([]ResourceToGenerate) error
+	ResourceFromExisting(addrs.AbsResourceInstance, addrs.AbsResourceInstance, []byte) error
+	ResourceClone(addrs.AbsResourceInstance, addrs.AbsResourceInstance, []byte) error
+	ResourceForEach(addrs.AbsResource, *configschema.Block, addrs.LocalProviderConfig, map[string]cty.Value) error
+	ResourceSubtree(addrs.AbsResourceInstance, *configschema.Block) error
+	DataSource(addrs.AbsResourceInstance, addrs.LocalProviderConfig, map[string]cty.Value) error
+	ExternalDataSource(addr addrs.AbsResourceInstance, script string) error
+	MissingRequired(addrs.AbsResourceInstance, *configschema.Block, addrs.LocalProviderConfig, []string, hcl.Range) error
+	ProviderScaffold(addrs.AbsResourceInstance, addrs.LocalProviderConfig, addrs.Provider, *configschema.Block) error
+	BackendScaffold(typeName string, schema *configschema.Block) error
+	TestFixture(addrs.AbsResourceInstance, *configschema.Block, addrs.LocalProviderConfig, addrs.Provider) error
+	SuggestExample(name, expr string)
+	InstallProgress(message string)
+	SetValues(values map[string]string)
+	SetBlockExamples(examples map[string]map[string]cty.Value)
+	SetTypePlaceholders(placeholders map[string]cty.Value)
+	SetHintsDisabled(disabled bool)
+	SetProviderVersions(versions map[addrs.Provider]string)
+	SetPassedProviders(passed map[string]string)
+	SetOutput(stdout, stderr io.Writer)
+	Summary(warnings int)
+	BulkCancelled()
+	CheckSummary(path, runID string, warnings int) error
+	DiffConfig(addrs.AbsResourceInstance, *configschema.Block, addrs.LocalProviderConfig, cty.Value, []string, hcl.Range) error
+	InputsJSON(*configschema.Block) error
+	Audit([]AuditResult, string) error
+	Converted([]byte) error
+	ModuleOutputs(map[string]cty.Value)
+	Diagnostics(tfdiags.Diagnostics)
+}
+
+// NewAdd returns an initialized Validate implementation. At this time,
+// ViewHuman is the only implemented view type.
+func NewAdd(vt arguments.ViewType, view *View, args *arguments.Add) Add {
+	var inferred map[string]string
+	if args.Infer {
+		inferred = inferredSuggestions()
+	}
+	return &addHuman{
+		view:             view,
+		optional:         args.Optional,
+		outPath:          args.OutPath,
+		outDir:           args.OutDir,
+		splitBy:          args.SplitBy,
+		position:         args.Position,
+		insertAfter:      args.After,
+		fileHeader:       args.FileHeader,
+		extractVariables: args.ExtractVariables,
+		examples:         exampleExpressions(args.ExampleExpressions),
+		inferred:         inferred,
+		secureDefaults:   args.SecureDefaults,
+		quiet:            args.Quiet,
+		explain:          args.Explain,
+		compactJSON:      args.CompactJSON,
+		jsonDiags:        args.JSONDiags,
+		provenance:       !args.NoProvenance && !args.NoComments,
+		attrProvenance:   args.AttrProvenance && !args.NoComments,
+		importIdentity:   args.ImportIdentity && !args.NoComments,
+		migration:        args.Migration,
+		movedFrom:        args.MovedFrom,
+		noComments:       args.NoComments,
+		commentStyle:     args.CommentStyle,
+
+		// The experimental-command note is a comment too.
+		skipExperimentNote: args.NoComments,
+		newline:            args.Newline,
+		maxLineWidth:       args.MaxLineWidth,
+		parallelism:        args.Parallelism,
+		checkpointPath:     args.CheckpointPath,
+		redactPatterns:     args.RedactPatterns,
+		withCheck:          args.WithCheck,
+		placeholderFormat:  args.PlaceholderFormat,
+		generators:         args.Generators,
+
+		includeOptionalComputed: args.IncludeOptionalComputed,
+	}
+}
+
+type addHuman struct {
+	view     *View
+	optional bool
+	outPath  string
+
+	// stdout and stderr, when non-nil, replace the view's terminal streams
+	// as the destinations for generated configuration and progress asides
+	// respectively; see SetOutput and the stdoutWriter/stderrWriter
+	// accessors.
+	stdout io.Writer
+	stderr io.Writer
+
+	// outDir and splitBy organize output into per-group files instead of a
+	// single -out file; see splitFileName for the grouping rules.
+	outDir  string
+	splitBy string
+
+	// position selects a report of where each block appended to the -out
+	// file landed, as "human" or "json"; see reportPosition. Empty disables
+	// the report.
+	position string
+
+	// explain reports, as an aside after each generated block, why every
+	// emitted attribute and block is present; see writeExplanation.
+	explain bool
+
+	// insertAfter names a block already in the -out file, in the -after
+	// anchor form, directly after which generated blocks are spliced in
+	// instead of being appended at the end of the file; see
+	// insertAfterAnchor. Empty appends.
+	insertAfter string
+
+	// fileHeader holds the contents of the -file-header file, prepended
+	// whenever a brand new configuration file is created; see withFileHeader.
+	fileHeader string
+
+	// examples maps well-known attribute names to example expressions
+	// which are shown as commented suggestions next to placeholders.
+	examples map[string]string
+
+	// values maps attribute names to raw string values supplied through
+	// SetValues, which placeholderValue converts to the attribute's type and
+	// uses instead of the empty placeholder.
+	values map[string]string
+
+	// inferred maps attribute names to suggestion comments derived from the
+	// process environment with -infer; see inferredSuggestions. Like
+	// examples, these are shown as comments next to placeholders and never
+	// become the attribute value itself.
+	inferred map[string]string
+
+	// blockExamples maps block type names to per-attribute example values
+	// published in the provider's schema metadata, supplied through
+	// SetBlockExamples. Unlike examples and inferred these become the
+	// rendered value itself, in place of the zero placeholder.
+	blockExamples map[string]map[string]cty.Value
+
+	// typePlaceholders maps primitive type names to the value rendered for
+	// required attributes of that type instead of null, supplied through
+	// SetTypePlaceholders from any add_placeholders block in the CLI
+	// configuration.
+	typePlaceholders map[string]cty.Value
+
+	// secureDefaults pre-populates security-sensitive attributes from the
+	// secure defaults catalog instead of emitting empty placeholders.
+	secureDefaults bool
+
+	// quiet suppresses the next-step hints printed after the generated
+	// configuration.
+	quiet bool
+
+	// hintsDisabled suppresses the same hints globally, from a generate
+	// block in the CLI configuration or the TF_GENERATE_HINTS environment
+	// variable rather than the command line; see SetHintsDisabled.
+	hintsDisabled bool
+
+	// compactJSON emits InputsJSON output as newline-delimited compact JSON
+	// objects, one per argument, instead of a pretty-printed array.
+	compactJSON bool
+
+	// jsonDiags renders diagnostics as newline-delimited JSON objects on
+	// standard error instead of human-readable text; see Diagnostics.
+	jsonDiags bool
+
+	// newline selects the line endings used for generated files: "lf",
+	// "crlf" or "auto"; see normalizeNewlines.
+	newline string
+
+	// maxLineWidth is the line width beyond which single-line collection
+	// values are broken across lines; see wrapLongLines. Zero disables
+	// wrapping.
+	maxLineWidth int
+
+	// provenance emits a structured marker comment above the generated
+	// block recording the Terraform version, date and source of the values,
+	// so later tooling can recognize and manage generated sections.
+	provenance bool
+
+	// includeOptionalComputed includes attributes which are both optional
+	// and computed when generating from state. By default we skip those,
+	// since the provider will recompute them from the other arguments
+	// anyway and they tend to dominate the output.
+	includeOptionalComputed bool
+
+	// redactPatterns are attribute name patterns whose state values are
+	// replaced with variable references; see redactMatch. The variables
+	// referenced are collected in redactedVars during rendering, which may
+	// happen concurrently, and declared by emitRedactedVariables afterwards.
+	// With -extract-variables the same collection also holds the variables
+	// extracted from placeholder attributes.
+	redactPatterns []string
+	redactedVars   map[string]extractedVariable
+	redactedMu     sync.Mutex
+
+	// extractVariables replaces placeholder attribute values with references
+	// to generated input variables, declared alongside the resource blocks
+	// with validation scaffolds where the provider's documentation makes the
+	// allowed values inferable; see writeValidationScaffold.
+	extractVariables bool
+
+	// outputRefs indexes the output values of the state's child module
+	// instances by reference expression; see ModuleOutputs. Values generated
+	// from state which equal one of them are written as the reference.
+	outputRefs []outputRef
+
+	// attrProvenance annotates each attribute written from state with the
+	// source of its value (state, provider default, or an unset required
+	// placeholder), for reviewers of bulk-generated configuration.
+	attrProvenance bool
+
+	// importIdentity marks the attributes which conventionally identify the
+	// remote object (see importIdentityNames), for users generating
+	// configuration they intend to "terraform import" onto: those values
+	// must match the real object exactly.
+	importIdentity bool
+
+	// migration appends a commented migration runbook after each generated
+	// resource block, and movedFrom, when non-nil, is the address the
+	// object is being moved from; see appendMigrationSteps.
+	migration bool
+	movedFrom *addrs.AbsResourceInstance
+
+	// noComments omits the annotation comments next to placeholder values
+	// (and, together with skipExperimentNote and provenance, every other
+	// comment), for users who post-process the output with scripts. The
+	// "# sensitive" markers are kept even then, since without them the
+	// omitted values would be inexplicable.
+	noComments bool
+
+	// commentStyle selects how the REQUIRED/OPTIONAL annotations are written:
+	// "hash" (the default), "slash" for // leaders, or "none" to drop the
+	// annotations while keeping other comments; see annotationComment.
+	commentStyle string
+
+	// parallelism bounds the worker pool rendering blocks in Resources. Zero
+	// or negative means one worker per CPU.
+	parallelism int
+
+	// checkpointPath, when non-empty, names the file recording the blocks a
+	// bulk run has already written, so an interrupted run can resume; see
+	// loadCheckpoint, appendCheckpoint and clearCheckpoint.
+	checkpointPath string
+
+	// withCheck scaffolds a top-level check block after each generated
+	// resource block; see writeCheckBlock.
+	withCheck bool
+
+	// placeholderFormat, when non-empty, is a format string with one %s verb
+	// for the attribute name, used instead of null for placeholder values so
+	// the output can feed template engines such as Jinja or cookiecutter.
+	placeholderFormat string
+
+	// generators are the external generator programs from the CLI
+	// configuration, which post-process or replace the generated
+	// configuration for matching resource types; see runGenerators.
+	generators []arguments.AddGenerator
+
+	// skipExperimentNote omits the experimental-command note above the
+	// generated block, for callers embedding the result somewhere the note
+	// would be noise (such as editor insertions via command/jsonadd).
+	skipExperimentNote bool
+
+	// hintsShown records that the next-step hints were already printed in
+	// this run, so generating several resources only shows them once.
+	hintsShown bool
+
+	// generated counts the resource blocks emitted in this run, and
+	// filesWritten the distinct files they were written to, for the summary
+	// printed by Summary after bulk generation.
+	generated    int
+	filesWritten map[string]struct{}
+
+	// scaffoldedProviders records the provider local names already pinned by
+	// ProviderScaffold in this run, so each provider is scaffolded once.
+	scaffoldedProviders map[string]struct{}
+
+	// providerVersions maps provider addresses to the version installed per
+	// the dependency lock file, supplied through SetProviderVersions.
+	// ProviderScaffold records these as version floors, since the generated
+	// configuration follows the installed provider's schema and older
+	// releases may not support it.
+	providerVersions map[addrs.Provider]string
+
+	// passedProviders maps resource addresses to the caller-side provider
+	// configuration the target module receives through the providers = {}
+	// map on its module call, supplied through SetPassedProviders. The
+	// render step names it in a comment, since which credentials apply is
+	// not visible from the child module alone.
+	passedProviders map[string]string
+}
+
+func (v *addHuman) Resource(addr addrs.AbsResourceInstance, schema *configschema.Block, pc addrs.LocalProviderConfig, stateVal cty.Value) error {
+	formatted, err := v.render(addr, schema, pc, stateVal)
+	if err != nil {
+		return err
+	}
+	formatted, err = v.runGenerators(addr, formatted)
+	if err != nil {
+		return err
+	}
+	if stateVal.RawEquals(cty.NilVal) && !v.migration {
+		formatted = v.appendImportSuggestion(formatted, addr, schema)
+	}
+	// The migration runbook subsumes the plain import suggestion above.
+	if v.migration {
+		formatted = v.appendMigrationSteps(formatted, addr)
+	}
+
+	if err := v.emit(addr, pc, formatted); err != nil {
+		return err
+	}
+	v.generated++
+	if v.explain {
+		v.writeExplanation(addr, schema, stateVal)
+	}
+	if err := v.emitRedactedVariables(); err != nil {
+		return err
+	}
+
+	v.writeHints(addr, !stateVal.RawEquals(cty.NilVal))
+	return nil
+}
+
+// Resources renders several resource blocks concurrently and then emits them
+// in the order given. Rendering is CPU-bound for large objects decoded from
+// state, so a worker pool gives a significant speedup for bulk generation,
+// while the ordered emit phase keeps the output deterministic.
+func (v *addHuman) Resources(rs []ResourceToGenerate) error {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	// Addresses recorded in the checkpoint file had their blocks written by
+	// an earlier interrupted run, so they are skipped rather than written
+	// twice.
+	resumed, err := v.loadCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	workers := v.parallelism
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(rs) {
+		workers = len(rs)
+	}
+
+	formatted := make([][]byte, len(rs))
+	errs := make([]error, len(rs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r := rs[i]
+				if resumed[r.Addr.String()] {
+					continue
+				}
+				formatted[i], errs[i] = v.render(r.Addr, r.Schema, r.Provider, r.State)
+			}
+		}()
+	}
+	for i := range rs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, r := range rs {
+		if resumed[r.Addr.String()] {
+			continue
+		}
+		if errs[i] != nil {
+			return errs[i]
+		}
+		out, err := v.runGenerators(r.Addr, formatted[i])
+		if err != nil {
+			return err
+		}
+		if r.State.RawEquals(cty.NilVal) && !v.migration {
+			out = v.appendImportSuggestion(out, r.Addr, r.Schema)
+		}
+		if v.migration {
+			out = v.appendMigrationSteps(out, r.Addr)
+		}
+		if err := v.emit(r.Addr, r.Provider, out); err != nil {
+			return err
+		}
+		v.generated++
+		if v.explain {
+			v.writeExplanation(r.Addr, r.Schema, r.State)
+		}
+		if err := v.appendCheckpoint(r.Addr); err != nil {
+			return err
+		}
+	}
+	if err := v.emitRedactedVariables(); err != nil {
+		return err
+	}
+	if err := v.clearCheckpoint(); err != nil {
+		return err
+	}
+
+	last := rs[len(rs)-1]
+	v.writeHints(last.Addr, !last.State.RawEquals(cty.NilVal))
+	return nil
+}
+
+// ResourceFromExisting emits a resource block for the given address whose
+// content is copied verbatim from an existing resource block in the
+// configuration, including meta-arguments such as provider, lifecycle and
+// depends_on, since a cloned resource usually wants the same operational
+// behavior.
+func (v *addHuman) ResourceFromExisting(addr addrs.AbsResourceInstance, src addrs.AbsResourceInstance, body []byte) error {
+	var buf strings.Builder
+
+	if !v.skipExperimentNote {
+		buf.WriteString(`# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+`)
+	}
+
+	if v.provenance {
+		buf.WriteString(fmt.Sprintf("# generated by \"terraform add\" v%s on %s from configuration of %s\n",
+			version.String(), time.Now().Format("2006-01-02"), src))
+	}
+
+	buf.WriteString(fmt.Sprintf("resource %q %q {", addr.Resource.Resource.Type, addr.Resource.Resource.Name))
+	buf.Write(body)
+	buf.WriteString("}")
+
+	formatted := hclwrite.Format([]byte(buf.String()))
+	if v.maxLineWidth > 0 {
+		formatted = hclwrite.Format(wrapLongLines(formatted, v.maxLineWidth))
+	}
+	formatted, err := v.runGenerators(addr, formatted)
+	if err != nil {
+		return err
+	}
+
+	pc := addrs.NewDefaultLocalProviderConfig(addr.Resource.Resource.ImpliedProvider())
+	if err := v.emit(addr, pc, formatted); err != nil {
+		return err
+	}
+	v.generated++
+
+	v.writeHints(addr, true)
+	return nil
+}
+
+// ResourceClone emits a copy of an existing resource block under a new name.
+// The body is the source block's own text as rewritten by the caller, so
+// unlike ResourceFromExisting no formatting or line wrapping is applied here:
+// the point of clone mode is to preserve the author's formatting, comments
+// and expressions exactly as they were written.
+func (v *addHuman) ResourceClone(addr addrs.AbsResourceInstance, src addrs.AbsResourceInstance, body []byte) error {
+	var buf strings.Builder
+
+	if !v.skipExperimentNote {
+		buf.WriteString(`# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+`)
+	}
+
+	if v.provenance {
+		buf.WriteString(fmt.Sprintf("# generated by \"terraform add\" v%s on %s as a clone of %s\n",
+			version.String(), time.Now().Format("2006-01-02"), src))
+	}
+
+	buf.Write(body)
+
+	out, err := v.runGenerators(addr, []byte(buf.String()))
+	if err != nil {
+		return err
+	}
+
+	pc := addrs.NewDefaultLocalProviderConfig(addr.Resource.Resource.ImpliedProvider())
+	if err := v.emit(addr, pc, out); err != nil {
+		return err
+	}
+	v.generated++
+
+	v.writeHints(addr, true)
+	return nil
+}
+
+// RenderResource renders a single resource block the same way the
+// "terraform add" command would, without emitting it anywhere. It backs the
+// machine-readable generation API in command/jsonadd, which needs the text
+// of the block separately from any output destination. No provenance marker
+// is included, so the result is deterministic.
+func RenderResource(addr addrs.AbsResourceInstance, schema *configschema.Block, pc addrs.LocalProviderConfig, stateVal cty.Value, optional bool) ([]byte, error) {
+	v := &addHuman{optional: optional, skipExperimentNote: true}
+	return v.render(addr, schema, pc, stateVal)
+}
+
+// GeneratedFileName returns the file name the -out-dir split output mode
+// would choose for the given resource under the given -split-by mode.
+func GeneratedFileName(mode string, addr addrs.AbsResourceInstance, pc addrs.LocalProviderConfig) string {
+	return splitFileName(mode, addr, pc)
+}
+
+// render produces the formatted configuration block for a single resource.
+// It only reads from the receiver, so it is safe to call concurrently.
+func (v *addHuman) render(addr addrs.AbsResourceInstance, schema *configschema.Block, pc addrs.LocalProviderConfig, stateVal cty.Value) ([]byte, error) {
+	var buf strings.Builder
+
+	if !v.skipExperimentNote {
+		buf.WriteString(`# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+`)
+	}
+
+	if v.provenance {
+		source := "schema"
+		if !stateVal.RawEquals(cty.NilVal) {
+			source = "state"
+		}
+		buf.WriteString(fmt.Sprintf("# generated by \"terraform add\" v%s on %s from %s\n",
+			version.String(), time.Now().Format("2006-01-02"), source))
+	}
+
+	// Some providers publish documentation for the resource type in the
+	// schema itself. Surface it as comments above the block, so the
+	// generated configuration links back to the provider's own docs and
+	// usage examples.
+	if schema.Description != "" && !v.noComments {
+		writeDescriptionComment(&buf, schema.Description, schema.DescriptionKind)
+	}
+
+	buf.WriteString(fmt.Sprintf("resource %q %q {\n", addr.Resource.Resource.Type, addr.Resource.Resource.Name))
+
+	// When the target module receives this provider through the
+	// providers = {} map on its module call, name the caller's configuration
+	// in a comment: the credentials that will apply are chosen by the
+	// caller, which is easy to miss when reading the child module alone.
+	if passed, ok := v.passedProviders[addr.String()]; ok && !v.noComments {
+		buf.WriteString(fmt.Sprintf("  # provider %s is passed in by the calling module as %s\n", pc.StringCompact(), passed))
+	}
+
+	if pc.LocalName != addr.Resource.Resource.ImpliedProvider() || pc.Alias != "" {
+		buf.WriteString(strings.Repeat(" ", 2))
+		buf.WriteString(fmt.Sprintf("provider = %s\n\n", pc.StringCompact()))
+	}
+
+	if stateVal.RawEquals(cty.NilVal) {
+		// Some resource types (aws_default_vpc, for example) have nothing
+		// required at all. Render the body separately so an empty result can
+		// be replaced with an explanatory comment instead of a bare "{\n}".
+		var bodyBuf strings.Builder
+		if err := v.writeConfigAttributes(&bodyBuf, schema.Attributes, 2); err != nil {
+			return nil, err
+		}
+		if err := v.writeConfigBlocks(&bodyBuf, schema.BlockTypes, 2); err != nil {
+			return nil, err
+		}
+		if bodyBuf.Len() == 0 && !v.noComments {
+			bodyBuf.WriteString("  # (no required attributes)\n")
+		}
+		buf.WriteString(bodyBuf.String())
+	} else {
+		// References to other modules' outputs are only valid from the root
+		// module, where a direct child's output is module.<name>.<output>.
+		var refs []outputRef
+		if addr.Module.IsRoot() {
+			refs = v.outputRefs
+		}
+		if err := v.writeConfigAttributesFromExisting(&buf, stateVal, schema.Attributes, 2, refs); err != nil {
+			return nil, err
+		}
+		if err := v.writeConfigBlocksFromExisting(&buf, stateVal, schema.BlockTypes, 2, refs); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteString("}")
+
+	// With -with-check a check block scaffold follows the resource block,
+	// as a starting point for post-deployment validation.
+	if v.withCheck {
+		buf.WriteString("\n\n")
+		writeCheckBlock(&buf, addr)
+	}
+
+	// The output better be valid HCL which can be parsed and formatted.
+	formatted := hclwrite.Format([]byte(buf.String()))
+
+	// Values decoded from state can produce very long single-line
+	// collections; break those and re-format so the result is stable.
+	if v.maxLineWidth > 0 {
+		formatted = hclwrite.Format(wrapLongLines(formatted, v.maxLineWidth))
+	}
+
+	// A last sanity check before anything is written: if a bug in the
+	// generation above produced configuration that doesn't match the schema,
+	// report it here as an internal error rather than letting the user
+	// discover it on their next plan.
+	if err := lintGeneratedBlock(formatted, schema); err != nil {
+		return nil, err
+	}
+	return formatted, nil
+}
+
+// writeDescriptionComment writes a provider-published description as comment
+// lines. Markdown descriptions often wrap usage examples in code fences; the
+// fence markers themselves are dropped, since everything is a comment here
+// anyway.
+func writeDescriptionComment(buf *strings.Builder, desc string, kind configschema.StringKind) {
+	for _, line := range strings.Split(strings.TrimRight(desc, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case kind == configschema.StringMarkdown && strings.HasPrefix(trimmed, "```"):
+			continue
+		case trimmed == "":
+			buf.WriteString("#\n")
+		default:
+			buf.WriteString("# " + line + "\n")
+		}
+	}
+}
+
+// emit writes an already-formatted chunk of output to whichever destination
+// the arguments selected: a split file inside -out-dir, a single -out file,
+// or standard output.
+func (v *addHuman) emit(addr addrs.AbsResourceInstance, pc addrs.LocalProviderConfig, formatted []byte) error {
+	switch {
+	case v.outDir != "":
+		return v.appendToSplitFile(addr, pc, formatted)
+	case v.outPath == "":
+		_, err := fmt.Fprintln(v.stdoutWriter(), string(formatted))
+		return err
+	default:
+		// The Println call above adds this final newline automatically; we add it manually here.
+		formatted = append(formatted, '\n')
+
+		// The existing contents, if any, determine the line endings used in
+		// "auto" mode.
+		existing, err := os.ReadFile(v.outPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		// A rerun over the same -out file must not duplicate blocks. When
+		// the file already holds a block for this address, an identical
+		// block means there is nothing to do, and a differing one is
+		// reported as drift rather than silently appended, so scripted
+		// pipelines can rerun this command idempotently.
+		if newBlock := findResourceBlock(formatted, addr); newBlock != nil {
+			if oldBlock := findResourceBlock(existing, addr); oldBlock != nil {
+				if string(oldBlock) == string(newBlock) {
+					fmt.Fprintf(v.stderrWriter(), "%s: block in %s is up to date; nothing was written.\n", addr, v.outPath)
+					return nil
+				}
+				return &BlockDriftError{
+					Addr: addr,
+					Path: v.outPath,
+					Diff: blockDiff(oldBlock, newBlock),
+				}
+			}
+		}
+
+		// With -after the block is spliced into the file directly after the
+		// named anchor block, rather than appended at the end.
+		if v.insertAfter != "" {
+			return v.insertAfterAnchor(addr, existing, formatted)
+		}
+
+		formatted = normalizeNewlines(v.withFileHeader(existing, formatted), v.newline, existing)
+
+		f, err := os.OpenFile(v.outPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := f.Write(formatted); err != nil {
+			return err
+		}
+		v.recordFileWritten(v.outPath)
+		if v.position != "" {
+			return v.reportPosition(addr, existing, formatted)
+		}
+		return nil
+	}
+}
+
+// recordFileWritten notes that a file was written to in this run, for the
+// counts reported by Summary.
+func (v *addHuman) recordFileWritten(path string) {
+	if v.filesWritten == nil {
+		v.filesWritten = make(map[string]struct{})
+	}
+	v.filesWritten[path] = struct{}{}
+}
+
+// InputsJSON prints a machine-readable description of the arguments the
+// resource type accepts — what a UI form would need to collect — instead of
+// rendered configuration. Computed-only attributes are not inputs and are
+// omitted. The arguments appear in sorted name order, so the output is
+// deterministic between runs for consumers that diff it.
+func (v *addHuman) InputsJSON(schema *configschema.Block) error {
+	type input struct {
+		Name        string `json:"name"`
+		Type        string `json:"type"`
+		Required    bool   `json:"required"`
+		Description string `json:"description,omitempty"`
+		Sensitive   bool   `json:"sensitive"`
+	}
+
+	keys := make([]string, 0, len(schema.Attributes))
+	for k := range schema.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	inputs := make([]input, 0, len(keys))
+	for _, name := range keys {
+		attrS := schema.Attributes[name]
+		if !attrS.Required && !attrS.Optional {
+			continue
+		}
+		ty := attrS.Type
+		if attrS.NestedType != nil {
+			ty = attrS.NestedType.ImpliedType()
+		}
+		inputs = append(inputs, input{
+			Name:        name,
+			Type:        ty.FriendlyName(),
+			Required:    attrS.Required,
+			Description: attrS.Description,
+			Sensitive:   attrS.Sensitive,
+		})
+	}
+
+	// In compact mode each argument becomes its own line of output, which
+	// is friendlier to line-oriented consumers such as jq.
+	if v.compactJSON {
+		for _, in := range inputs {
+			src, err := json.Marshal(in)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintln(v.stdoutWriter(), string(src)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	src, err := json.MarshalIndent(inputs, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(v.stdoutWriter(), string(src))
+	return err
+}
+
+// MissingRequired reports, in comment form, the required arguments that an
+// existing resource block in configuration lacks according to the provider
+// schema. Comment form keeps the result safe to append to a configuration
+// file for the author to act on, rather than declaring the resource twice.
+func (v *addHuman) MissingRequired(addr addrs.AbsResourceInstance, schema *configschema.Block, pc addrs.LocalProviderConfig, missing []string, declRange hcl.Range) error {
+	var buf strings.Builder
+
+	if len(missing) == 0 {
+		buf.WriteString(fmt.Sprintf("# %s is already declared at %s and has all of\n# the arguments the provider schema requires.\n", addr, declRange))
+		return v.emit(addr, pc, []byte(buf.String()))
+	}
+
+	buf.WriteString(fmt.Sprintf("# %s is already declared at %s.\n", addr, declRange))
+	buf.WriteString("# The following required arguments are missing from that configuration:\n#\n")
+
+	subset := make(map[string]*configschema.Attribute, len(missing))
+	for _, name := range missing {
+		subset[name] = schema.Attributes[name]
+	}
+	var attrBuf strings.Builder
+	if err := v.writeConfigAttributes(&attrBuf, subset, 2); err != nil {
+		return err
+	}
+	for _, line := range strings.SplitAfter(attrBuf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		buf.WriteString("#" + line)
+	}
+
+	return v.emit(addr, pc, []byte(buf.String()))
+}
+
+// DiffConfig reports, in comment form, the attributes which have a value in
+// state but are not written in the existing resource block — configuration
+// drift in the opposite direction of what "terraform plan" detects. The
+// values are rendered the same way -from-state generation would write them,
+// so the report can be pasted into the block directly.
+func (v *addHuman) DiffConfig(addr addrs.AbsResourceInstance, schema *configschema.Block, pc addrs.LocalProviderConfig, stateVal cty.Value, drifted []string, declRange hcl.Range) error {
+	var buf strings.Builder
+
+	if len(drifted) == 0 {
+		buf.WriteString(fmt.Sprintf("# %s is declared at %s; every attribute\n# set in state is also written in that configuration.\n", addr, declRange))
+		return v.emit(addr, pc, []byte(buf.String()))
+	}
+
+	buf.WriteString(fmt.Sprintf("# %s is declared at %s.\n", addr, declRange))
+	buf.WriteString("# The following attributes are set in state but missing from that configuration:\n#\n")
+
+	subset := make(map[string]*configschema.Attribute, len(drifted))
+	for _, name := range drifted {
+		subset[name] = schema.Attributes[name]
+	}
+	var attrBuf strings.Builder
+	if err := v.writeConfigAttributesFromExisting(&attrBuf, stateVal, subset, 2, nil); err != nil {
+		return err
+	}
+	for _, line := range strings.SplitAfter(attrBuf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		buf.WriteString("#" + line)
+	}
+
+	return v.emit(addr, pc, []byte(buf.String()))
+}
+
+// appendImportSuggestion appends a comment after a schema-only skeleton for
+// resource types which support "terraform import", steering the user toward
+// importing an existing object rather than filling in the placeholders and
+// creating a new one. Providers signal import support through their schema:
+// an importable resource type has a computed string "id" attribute, and when
+// the provider documents that attribute the description is repeated here as
+// the import ID format. Types whose schema doesn't document the ID are left
+// alone, since a bare suggestion with an unknown ID format is already part
+// of the next-step hints.
+func (v *addHuman) appendImportSuggestion(formatted []byte, addr addrs.AbsResourceInstance, schema *configschema.Block) []byte {
+	if v.noComments || schema == nil {
+		return formatted
+	}
+	idAttr, ok := schema.Attributes["id"]
+	if !ok || !idAttr.Computed || idAttr.Type != cty.String || idAttr.Description == "" {
+		return formatted
+	}
+
+	var buf strings.Builder
+	buf.Write(formatted)
+	buf.WriteString("\n\n")
+	buf.WriteString(fmt.Sprintf("# %s supports \"terraform import\": instead of creating a new object\n", addr.Resource.Resource.Type))
+	buf.WriteString("# from this template, an existing one can be brought under management with:\n")
+	buf.WriteString(fmt.Sprintf("#   terraform import '%s' ID\n", addr))
+	format := strings.Join(strings.Fields(idAttr.Description), " ")
+	buf.WriteString(fmt.Sprintf("# where ID is: %s", format))
+	return []byte(buf.String())
+}
+
+// writeHints prints next-step suggestions to stderr once a template has been
+// generated: the import command that would bring a matching real object under
+// management, a reminder to initialize if the provider isn't installed yet,
+// and the usual fmt/validate advice. The hints are suppressed with -quiet or
+// globally through SetHintsDisabled, and also when the template itself went
+// to stdout and stderr is not a terminal, so that scripts capturing our
+// output don't collect them.
+func (v *addHuman) writeHints(addr addrs.AbsResourceInstance, fromState bool) {
+	if v.quiet || v.hintsDisabled || v.hintsShown {
+		return
+	}
+	v.hintsShown = true
+	if v.outPath == "" && v.outDir == "" && !v.stderrIsTerminal() {
+		return
+	}
+
+	fmt.Fprintln(v.stderrWriter(), "\nNext steps:")
+	if !fromState {
+		fmt.Fprintf(v.stderrWriter(), "  - Fill in the placeholder values above, or import an existing object into your state with:\n      terraform import '%s' ID\n", addr)
+	}
+	fmt.Fprintln(v.stderrWriter(), `  - If this resource's provider isn't installed yet, run "terraform init".`)
+	fmt.Fprintln(v.stderrWriter(), `  - Run "terraform fmt" to normalize the formatting and "terraform validate" to check the result.`)
+}
+
+// SuggestExample registers an example expression for the named attribute,
+// shown as a comment next to its placeholder like the entries of the built-in
+// table. The command uses this for suggestions derived from the configuration
+// itself, such as workspace-aware naming conventions. Existing entries —
+// built-in or from -example-expressions — are left alone, and the method must
+// not be called once rendering has begun.
+func (v *addHuman) SuggestExample(name, expr string) {
+	if _, ok := v.examples[name]; ok {
+		return
+	}
+	// The table may alias the shared defaults map, so copy before adding.
+	merged := make(map[string]string, len(v.examples)+1)
+	for k, e := range v.examples {
+		merged[k] = e
+	}
+	merged[name] = expr
+	v.examples = merged
+}
+
+// SetValues supplies attribute values, as raw strings keyed by attribute
+// name, to use instead of placeholders when rendering a template. The command
+// uses this for values read from standard input with -values-stdin, after
+// type-checking them against the schemas being generated. Like
+// SuggestExample, this must not be called once rendering has begun.
+func (v *addHuman) SetValues(values map[string]string) {
+	v.values = values
+}
+
+// SetHintsDisabled suppresses the next-step hints the same way -quiet does,
+// but from the CLI configuration or environment rather than the command
+// line, so organizations embedding this command in automation can turn them
+// off globally. Like SetValues, this must not be called once rendering has
+// begun.
+func (v *addHuman) SetHintsDisabled(disabled bool) {
+	v.hintsDisabled = disabled
+}
+
+// SetPassedProviders supplies, per resource address, the caller-side
+// provider configuration the target module receives through the
+// providers = {} map on its module call, so the generated block can name
+// it in a comment. Like SetValues, this must not be called once rendering
+// has begun.
+func (v *addHuman) SetPassedProviders(passed map[string]string) {
+	v.passedProviders = passed
+}
+
+// SetOutput redirects the generated configuration (stdout) and the progress
+// asides (stderr) away from the view's terminal streams, so other commands
+// can embed the generation and collect its output in files or buffers. A nil
+// writer leaves that stream on the terminal. The interactive next-step hints
+// and summary are suppressed for redirected output, which by definition is
+// not a terminal. Like SetValues, this must not be called once rendering has
+// begun.
+func (v *addHuman) SetOutput(stdout, stderr io.Writer) {
+	v.stdout = stdout
+	v.stderr = stderr
+}
+
+// stdoutWriter returns the destination for generated configuration: the
+// writer injected with SetOutput, or the view's terminal stream.
+func (v *addHuman) stdoutWriter() io.Writer {
+	if v.stdout != nil {
+		return v.stdout
+	}
+	return v.view.streams.Stdout.File
+}
+
+// stderrWriter returns the destination for progress asides: the writer
+// injected with SetOutput, or the view's terminal stream.
+func (v *addHuman) stderrWriter() io.Writer {
+	if v.stderr != nil {
+		return v.stderr
+	}
+	return v.view.streams.Stderr.File
+}
+
+// stderrIsTerminal reports whether the progress asides are going to an
+// interactive terminal; a stream redirected with SetOutput never is.
+func (v *addHuman) stderrIsTerminal() bool {
+	return v.stderr == nil && v.view.streams.Stderr.IsTerminal()
+}
+
+// ExprValuePrefix marks a supplied attribute value as a raw HCL expression to
+// emit verbatim, rather than a literal to convert to the attribute's type, so
+// generated configuration can reference other resources from the start:
+// subnet_id=@expr:aws_subnet.main.id writes subnet_id = aws_subnet.main.id.
+const ExprValuePrefix = "@expr:"
+
+// exprValue returns the verbatim expression supplied for the named attribute
+// through SetValues, if its value carries the ExprValuePrefix marker.
+func (v *addHuman) exprValue(name string) (string, bool) {
+	raw, ok := v.values[name]
+	if !ok || !strings.HasPrefix(raw, ExprValuePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(raw, ExprValuePrefix), true
+}
+
+// Summary reports, after bulk generation, how much was done: the number of
+// resource blocks generated, the distinct files written (zero when the output
+// went to stdout), and the warnings reported along the way. Like the
+// next-step hints it goes to stderr and is suppressed under the same
+// conditions, so scripts capturing the generated configuration don't collect
+// it.
+func (v *addHuman) Summary(warnings int) {
+	if v.quiet {
+		return
+	}
+	if v.outPath == "" && v.outDir == "" && !v.stderrIsTerminal() {
+		return
+	}
+
+	fmt.Fprintf(v.stderrWriter(), "\nterraform add complete: resource blocks generated: %d, files written: %d, warnings: %d.\n",
+		v.generated, len(v.filesWritten), warnings)
+}
+
+// CheckSummary writes a structured summary of a bulk generation run — the
+// number of resource blocks generated, the files written and the warnings
+// reported — to the given path, wrapped in the JSON:API document shape the
+// cloud backend uses, so a configuration-generation job running inside a
+// Terraform Cloud run (identified by runID) can publish it as a run-task
+// style result.
+func (v *addHuman) CheckSummary(path, runID string, warnings int) error {
+	files := make([]string, 0, len(v.filesWritten))
+	for f := range v.filesWritten {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	status := "passed"
+	if warnings > 0 {
+		status = "passed_with_warnings"
+	}
+
+	type attributes struct {
+		RunID     string   `json:"run-id"`
+		Status    string   `json:"status"`
+		Resources int      `json:"resources-generated"`
+		Files     []string `json:"files-written"`
+		Warnings  int      `json:"warnings"`
+	}
+	type data struct {
+		Type       string     `json:"type"`
+		Attributes attributes `json:"attributes"`
+	}
+	doc := struct {
+		Data data `json:"data"`
+	}{
+		Data: data{
+			Type: "add-check-summaries",
+			Attributes: attributes{
+				RunID:     runID,
+				Status:    status,
+				Resources: v.generated,
+				Files:     files,
+				Warnings:  warnings,
+			},
+		},
+	}
+
+	src, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(src, '\n'), 0644)
+}
+
+// BulkCancelled reports that the user declined the confirmation prompt
+// shown before bulk generation, so nothing was generated.
+func (v *addHuman) BulkCancelled() {
+	fmt.Fprintln(v.stdoutWriter(), "Bulk generation cancelled; no configuration was generated.")
+}
+
+// Diagnostics renders the diagnostics with each recognized summary carrying
+// its stable code (see addDiagCodes): in brackets after the summary in the
+// human-readable form, or as a separate "code" property when -json-diags
+// selects the newline-delimited JSON form on standard error.
+func (v *addHuman) Diagnostics(diags tfdiags.Diagnostics) {
+	if v.jsonDiags {
+		v.diagnosticsJSON(diags)
+		return
+	}
+	v.view.Diagnostics(withAddDiagCodes(diags))
+}
+
+// addDiagnosticJSON is the shape of one -json-diags line. Automation should
+// match on Code where it is set; the summary and detail text may be reworded
+// between releases.
+type addDiagnosticJSON struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+	Code     string `json:"code,omitempty"`
+}
+
+func (v *addHuman) diagnosticsJSON(diags tfdiags.Diagnostics) {
+	if len(diags) == 0 {
+		return
+	}
+	diags.Sort()
+	w := v.stderrWriter()
+	for _, diag := range diags {
+		desc := diag.Description()
+		severity := "error"
+		if diag.Severity() == tfdiags.Warning {
+			severity = "warning"
+		}
+		src, err := json.Marshal(addDiagnosticJSON{
+			Severity: severity,
+			Summary:  desc.Summary,
+			Detail:   desc.Detail,
+			Code:     addDiagnosticCode(desc.Summary),
+		})
+		if err != nil {
+			// A diagnostic of plain strings cannot fail to marshal; if one
+			// somehow does, losing it silently would hide an error, so fall
+			// back to the human form.
+			v.view.Diagnostics(tfdiags.Diagnostics{diag})
+			continue
+		}
+		fmt.Fprintf(w, "%s\n", src)
+	}
+}
+
+func (v *addHuman) writeConfigAttributes(buf *strings.Builder, attrs map[string]*configschema.Attribute, indent int) error {
+	return v.writeConfigAttributesExamples(buf, attrs, indent, nil)
+}
+
+// writeConfigAttributesExamples is writeConfigAttributes with per-attribute
+// example values from the enclosing block's schema metadata, which are
+// rendered in place of zero placeholders; see SetBlockExamples.
+func (v *addHuman) writeConfigAttributesExamples(buf *strings.Builder, attrs map[string]*configschema.Attribute, indent int, examples map[string]cty.Value) error {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	// Get a list of sorted attribute names so the output will be consistent between runs.
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i := range keys {
+		name := keys[i]
+		attrS := attrs[name]
+		if attrS.NestedType != nil {
+			if err := v.writeConfigNestedTypeAttribute(buf, name, attrS, indent); err != nil {
+				return err
+			}
+			continue
+		}
+		// Legacy SDK lists of objects must be written in block syntax, or
+		// the generated configuration may fail validation.
+		if attrAsBlocks(attrS) {
+			if err := v.writeAttrAsBlocks(buf, name, attrS, indent); err != nil {
+				return err
+			}
+			continue
+		}
+		if attrS.Required || (attrS.Optional && v.optional) {
+			// With -extract-variables an attribute which would otherwise get
+			// a placeholder is wired to a generated input variable instead;
+			// values supplied through -values-stdin still win.
+			if _, supplied := v.values[name]; v.extractVariables && !supplied {
+				buf.WriteString(strings.Repeat(" ", indent))
+				buf.WriteString(fmt.Sprintf("%s = var.%s\n", name, name))
+				v.recordVariable(name, extractedVariable{
+					ty:          attrS.Type,
+					description: attrS.Description,
+					sensitive:   attrS.Sensitive,
+				})
+				continue
+			}
+			buf.WriteString(strings.Repeat(" ", indent))
+			buf.WriteString(fmt.Sprintf("%s = ", name))
+			if expr, ok := v.exprValue(name); ok {
+				// A supplied @expr: value is an expression, written
+				// verbatim rather than as a quoted string.
+				buf.WriteString(expr)
+			} else {
+				tok := hclwrite.TokensForValue(v.placeholderValueExample(name, attrS, examples))
+				if _, err := tok.WriteTo(buf); err != nil {
+					return err
+				}
+			}
+			v.writeAttrTypeConstraint(buf, name, attrS)
+		}
+	}
+	return nil
+}
+
+func (v *addHuman) writeConfigAttributesFromExisting(buf *strings.Builder, stateVal cty.Value, attrs map[string]*configschema.Attribute, indent int, refs []outputRef) error {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	// Get a list of sorted attribute names so the output will be consistent between runs.
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i := range keys {
+		name := keys[i]
+		attrS := attrs[name]
+
+		// Attributes matching a redaction pattern are written as references
+		// to a generated sensitive variable, never with their state values.
+		if (attrS.Required || attrS.Optional) && v.redactMatch(name) {
+			if attrS.Optional && attrS.Computed && !v.includeOptionalComputed {
+				continue
+			}
+			buf.WriteString(strings.Repeat(" ", indent))
+			buf.WriteString(fmt.Sprintf("%s = var.%s\n", name, name))
+			ty := attrS.Type
+			if attrS.NestedType != nil {
+				ty = attrS.NestedType.ImpliedType()
+			}
+			v.recordVariable(name, extractedVariable{
+				ty: ty,
+				// The provider's documentation of the attribute carries into
+				// the declaration, the same as with -extract-variables, so
+				// terraform-docs output for the generated module is
+				// immediately meaningful.
+				description: attrS.Description,
+				sensitive:   true,
+			})
+			continue
+		}
+
+		if attrS.NestedType != nil {
+			if err := v.writeConfigNestedTypeAttributeFromExisting(buf, name, attrS, stateVal, indent, refs); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Exclude computed-only attributes, and by default also attributes
+		// which are optional+computed: their state values were likely decided
+		// by the provider and would be recomputed from the other arguments.
+		if attrS.Optional && attrS.Computed && !v.includeOptionalComputed {
+			continue
+		}
+		// Legacy SDK lists of objects must be written in block syntax, or
+		// the generated configuration may fail validation.
+		if attrAsBlocks(attrS) && (attrS.Required || attrS.Optional) {
+			if err := v.writeAttrAsBlocksFromExisting(buf, name, attrS, stateVal, indent, refs); err != nil {
+				return err
+			}
+			continue
+		}
+		if attrS.Required || attrS.Optional {
+			buf.WriteString(strings.Repeat(" ", indent))
+			buf.WriteString(fmt.Sprintf("%s = ", name))
+
+			var val cty.Value
+			if stateVal.Type().HasAttribute(name) {
+				val = stateVal.GetAttr(name)
+			} else {
+				val = attrS.EmptyValue()
+			}
+
+			// With -attr-provenance each attribute says where its value came
+			// from, so reviewers of bulk-generated configuration can tell
+			// state values from defaults and unset placeholders at a glance.
+			// Sensitive values keep their own marker instead.
+			var annotation string
+			if v.attrProvenance && !(attrS.Sensitive || val.HasMark("sensitive")) {
+				switch {
+				case stateVal.Type().HasAttribute(name) && !val.IsNull():
+					annotation = v.annotationComment("from state")
+				case attrS.Required:
+					annotation = v.annotationComment("REQUIRED - please set")
+				default:
+					annotation = v.annotationComment("default")
+				}
+			}
+
+			// Import identity attributes are marked in this mode too, since
+			// configuration generated from one object's state is often edited
+			// into blocks for further objects to import.
+			if v.importIdentity && isImportIdentityName(name) && !(attrS.Sensitive || val.HasMark("sensitive")) {
+				if annotation == "" {
+					annotation = v.annotationComment("import identity: must match the real object")
+				} else {
+					annotation += " (import identity)"
+				}
+			}
+
+			// A value which equals another module's output is written as a
+			// reference to that output, preserving the cross-module
+			// structure instead of flattening it into a constant.
+			if expr, ok := outputRefFor(refs, val); ok && !attrS.Sensitive {
+				buf.WriteString(expr)
+				buf.WriteString(annotation)
+				buf.WriteString("\n")
+				continue
+			}
+
+			if attrS.Sensitive || val.HasMark("sensitive") {
+				buf.WriteString("null # sensitive")
+			} else if needsPrettyValue(val) {
+				// Sets and maps of objects read much better with one element
+				// per line; see writePrettyValue.
+				if err := writePrettyValue(buf, val, indent); err != nil {
+					return err
+				}
+			} else {
+				val, _ = val.Unmark()
+				tok := hclwrite.TokensForValue(val)
+				if _, err := tok.WriteTo(buf); err != nil {
+					return err
+				}
+			}
+
+			buf.WriteString(annotation)
+			buf.WriteString("\n")
+		}
+	}
+	return nil
+}
+
+func (v *addHuman) writeConfigBlocks(buf *strings.Builder, blocks map[string]*configschema.NestedBlock, indent int) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	// Get a list of sorted block names so the output will be consistent between runs.
+	names := make([]string, 0, len(blocks))
+	for k := range blocks {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for i := range names {
+		name := names[i]
+		blockS := blocks[name]
+		if !v.optional && blockS.MinItems == 0 {
+			continue
+		}
+		if err := v.writeConfigNestedBlock(buf, name, blockS, indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeConfigNestedBlock renders one nested block unconditionally; whether
+// an optional block warrants rendering at all is the caller's decision.
+func (v *addHuman) writeConfigNestedBlock(buf *strings.Builder, name string, schema *configschema.NestedBlock, indent int) error {
+	// The body is rendered first, so that a block with nothing in it — no
+	// required attributes, and no optional ones requested — can use the
+	// compact single-line form "name {}" instead of spending a line on an
+	// empty body.
+	var body strings.Builder
+	if err := v.writeConfigAttributesExamples(&body, schema.Attributes, indent+2, v.blockExamples[name]); err != nil {
+		return err
+	}
+	if err := v.writeConfigBlocks(&body, schema.BlockTypes, indent+2); err != nil {
+		return err
+	}
+
+	switch schema.Nesting {
+	case configschema.NestingSingle, configschema.NestingGroup:
+		buf.WriteString(strings.Repeat(" ", indent))
+		if body.Len() == 0 {
+			buf.WriteString(fmt.Sprintf("%s {}", name))
+			v.writeBlockTypeConstraint(buf, schema)
+			return nil
+		}
+		buf.WriteString(fmt.Sprintf("%s {", name))
+		v.writeBlockTypeConstraint(buf, schema)
+		buf.WriteString(body.String())
+		buf.WriteString("}\n")
+		return nil
+	case configschema.NestingList, configschema.NestingSet:
+		buf.WriteString(strings.Repeat(" ", indent))
+		if body.Len() == 0 {
+			buf.WriteString(fmt.Sprintf("%s {}", name))
+			v.writeBlockTypeConstraint(buf, schema)
+			return nil
+		}
+		buf.WriteString(fmt.Sprintf("%s {", name))
+		v.writeBlockTypeConstraint(buf, schema)
+		buf.WriteString(body.String())
+		buf.WriteString("}\n")
+		return nil
+	case configschema.NestingMap:
+		buf.WriteString(strings.Repeat(" ", indent))
+		// we use an arbitrary placeholder key (block label) "key"
+		if body.Len() == 0 {
+			buf.WriteString(fmt.Sprintf("%s \"key\" {}", name))
+			v.writeBlockTypeConstraint(buf, schema)
+			return nil
+		}
+		buf.WriteString(fmt.Sprintf("%s \"key\" {", name))
+		v.writeBlockTypeConstraint(buf, schema)
+		buf.WriteString(body.String())
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString("}\n")
+		return nil
+	default:
+		// This should not happen, the above should be exhaustive.
+		return fmt.Errorf("unsupported NestingMode %s", schema.Nesting.String())
+	}
+}
+
+func (v *addHuman) writeConfigNestedTypeAttribute(buf *strings.Builder, name string, schema *configschema.Attribute, indent int) error {
+	if !schema.Required && !v.optional {
+		return nil
+	}
+
+	// A required object attribute must be filled in for the generated
+	// configuration to be valid, so its fields are expanded as if -optional
+	// were set rather than leaving an empty object the user has to
+	// reconstruct from documentation. Newer SDKs commonly model required
+	// objects whose fields are all individually optional.
+	if schema.Required && !v.optional {
+		v.optional = true
+		defer func() { v.optional = false }()
+	}
+
+	buf.WriteString(strings.Repeat(" ", indent))
+	buf.WriteString(fmt.Sprintf("%s = ", name))
+
+	switch schema.NestedType.Nesting {
+	case configschema.NestingSingle:
+		buf.WriteString("{")
+		v.writeAttrTypeConstraint(buf, name, schema)
+		if err := v.writeConfigAttributes(buf, schema.NestedType.Attributes, indent+2); err != nil {
+			return err
+		}
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString("}\n")
+		return nil
+	case configschema.NestingList, configschema.NestingSet:
+		buf.WriteString("[{")
+		v.writeAttrTypeConstraint(buf, name, schema)
+		if err := v.writeConfigAttributes(buf, schema.NestedType.Attributes, indent+2); err != nil {
+			return err
+		}
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString("}]\n")
+		return nil
+	case configschema.NestingMap:
+		buf.WriteString("{")
+		v.writeAttrTypeConstraint(buf, name, schema)
+		buf.WriteString(strings.Repeat(" ", indent+2))
+		// we use an arbitrary placeholder key "key"
+		buf.WriteString("key = {\n")
+		if err := v.writeConfigAttributes(buf, schema.NestedType.Attributes, indent+4); err != nil {
+			return err
+		}
+		buf.WriteString(strings.Repeat(" ", indent+2))
+		buf.WriteString("}\n")
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString("}\n")
+		return nil
+	default:
+		// This should not happen, the above should be exhaustive.
+		return fmt.Errorf("unsupported NestingMode %s", schema.NestedType.Nesting.String())
+	}
+}
+
+func (v *addHuman) writeConfigBlocksFromExisting(buf *strings.Builder, stateVal cty.Value, blocks map[string]*configschema.NestedBlock, indent int, refs []outputRef) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	// Get a list of sorted block names so the output will be consistent between runs.
+	names := make([]string, 0, len(blocks))
+	for k := range blocks {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		blockS := blocks[name]
+		// This shouldn't happen in real usage; state always has all values (set
+		// to null as needed), but it protects against panics in tests (and any
+		// really weird and unlikely cases).
+		if !stateVal.Type().HasAttribute(name) {
+			continue
+		}
+		blockVal := stateVal.GetAttr(name)
+		if err := v.writeConfigNestedBlockFromExisting(buf, name, blockS, blockVal, indent, refs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *addHuman) writeConfigNestedTypeAttributeFromExisting(buf *strings.Builder, name string, schema *configschema.Attribute, stateVal cty.Value, indent int, refs []outputRef) error {
+	switch schema.NestedType.Nesting {
+	case configschema.NestingSingle:
+		if schema.Sensitive || stateVal.HasMark("sensitive") {
+			buf.WriteString(strings.Repeat(" ", indent))
+			buf.WriteString(fmt.Sprintf("%s = {} # sensitive\n", name))
+			return nil
+		}
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString(fmt.Sprintf("%s = {\n", name))
+
+		// This shouldn't happen in real usage; state always has all values (set
+		// to null as needed), but it protects against panics in tests (and any
+		// really weird and unlikely cases).
+		if !stateVal.Type().HasAttribute(name) {
+			return nil
+		}
+		nestedVal := stateVal.GetAttr(name)
+		if err := v.writeConfigAttributesFromExisting(buf, nestedVal, schema.NestedType.Attributes, indent+2, refs); err != nil {
+			return err
+		}
+		buf.WriteString("}\n")
+		return nil
+
+	case configschema.NestingList, configschema.NestingSet:
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString(fmt.Sprintf("%s = [", name))
+
+		if schema.Sensitive || stateVal.HasMark("sensitive") {
+			buf.WriteString("] # sensitive\n")
+			return nil
+		}
+
+		buf.WriteString("\n")
+
+		listVals := ctyCollectionValues(stateVal.GetAttr(name))
+		for i := range listVals {
+			buf.WriteString(strings.Repeat(" ", indent+2))
+
+			// The entire element is marked.
+			if listVals[i].HasMark("sensitive") {
+				buf.WriteString("{}, # sensitive\n")
+				continue
+			}
+
+			buf.WriteString("{\n")
+			if err := v.writeConfigAttributesFromExisting(buf, listVals[i], schema.NestedType.Attributes, indent+4, refs); err != nil {
+				return err
+			}
+			buf.WriteString(strings.Repeat(" ", indent+2))
+			buf.WriteString("},\n")
+		}
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString("]\n")
+		return nil
+
+	case configschema.NestingMap:
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString(fmt.Sprintf("%s = {", name))
+
+		if schema.Sensitive || stateVal.HasMark("sensitive") {
+			buf.WriteString(" } # sensitive\n")
+			return nil
+		}
+
+		buf.WriteString("\n")
+
+		vals := stateVal.GetAttr(name).AsValueMap()
+		keys := make([]string, 0, len(vals))
+		for key := range vals {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			buf.WriteString(strings.Repeat(" ", indent+2))
+			buf.WriteString(fmt.Sprintf("%s = {", key))
+
+			// This entire value is marked
+			if vals[key].HasMark("sensitive") {
+				buf.WriteString("} # sensitive\n")
+				continue
+			}
+
+			buf.WriteString("\n")
+			if err := v.writeConfigAttributesFromExisting(buf, vals[key], schema.NestedType.Attributes, indent+4, refs); err != nil {
+				return err
+			}
+			buf.WriteString(strings.Repeat(" ", indent+2))
+			buf.WriteString("}\n")
+		}
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString("}\n")
+		return nil
+
+	default:
+		// This should not happen, the above should be exhaustive.
+		return fmt.Errorf("unsupported NestingMode %s", schema.NestedType.Nesting.String())
+	}
+}
+
+func (v *addHuman) writeConfigNestedBlockFromExisting(buf *strings.Builder, name string, schema *configschema.NestedBlock, stateVal cty.Value, indent int, refs []outputRef) error {
+	switch schema.Nesting {
+	case configschema.NestingSingle, configschema.NestingGroup:
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString(fmt.Sprintf("%s {", name))
+
+		// If the entire value is marked, don't print any nested attributes
+		if stateVal.HasMark("sensitive") {
+			buf.WriteString("} # sensitive\n")
+			return nil
+		}
+		buf.WriteString("\n")
+		if err := v.writeConfigAttributesFromExisting(buf, stateVal, schema.Attributes, indent+2, refs); err != nil {
+			return err
+		}
+		if err := v.writeConfigBlocksFromExisting(buf, stateVal, schema.BlockTypes, indent+2, refs); err != nil {
+			return err
+		}
+		buf.WriteString("}\n")
+		return nil
+	case configschema.NestingList, configschema.NestingSet:
+		if stateVal.HasMark("sensitive") {
+			buf.WriteString(strings.Repeat(" ", indent))
+			buf.WriteString(fmt.Sprintf("%s {} # sensitive\n", name))
+			return nil
+		}
+		listVals := ctyCollectionValues(stateVal)
+		for i := range listVals {
+			buf.WriteString(strings.Repeat(" ", indent))
+			buf.WriteString(fmt.Sprintf("%s {\n", name))
+			if err := v.writeConfigAttributesFromExisting(buf, listVals[i], schema.Attributes, indent+2, refs); err != nil {
+				return err
+			}
+			if err := v.writeConfigBlocksFromExisting(buf, listVals[i], schema.BlockTypes, indent+2, refs); err != nil {
+				return err
+			}
+			buf.WriteString("}\n")
+		}
+		return nil
+	case configschema.NestingMap:
+		// If the entire value is marked, don't print any nested attributes
+		if stateVal.HasMark("sensitive") {
+			buf.WriteString(fmt.Sprintf("%s {} # sensitive\n", name))
+			return nil
+		}
+
+		vals := stateVal.AsValueMap()
+		keys := make([]string, 0, len(vals))
+		for key := range vals {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			buf.WriteString(strings.Repeat(" ", indent))
+			buf.WriteString(fmt.Sprintf("%s %q {", name, key))
+			// This entire map element is marked
+			if vals[key].HasMark("sensitive") {
+				buf.WriteString("} # sensitive\n")
+				return nil
+			}
+			buf.WriteString("\n")
+
+			if err := v.writeConfigAttributesFromExisting(buf, vals[key], schema.Attributes, indent+2, refs); err != nil {
+				return err
+			}
+			if err := v.writeConfigBlocksFromExisting(buf, vals[key], schema.BlockTypes, indent+2, refs); err != nil {
+				return err
+			}
+			buf.WriteString(strings.Repeat(" ", indent))
+			buf.WriteString("}\n")
+		}
+		return nil
+	default:
+		// This should not happen, the above should be exhaustive.
+		return fmt.Errorf("unsupported NestingMode %s", schema.Nesting.String())
+	}
+}
+
+// placeholderValue returns the value to emit for an attribute with no known
+// value: normally the empty value for its type, but in secure-defaults mode
+// attributes found in the secure defaults catalog are pre-populated instead.
+func (v *addHuman) placeholderValue(name string, attrS *configschema.Attribute) cty.Value {
+	// Values supplied explicitly, such as key=value pairs from -values-stdin,
+	// win over everything else. The caller has already type-checked them
+	// against the schema, so a failed conversion here cannot normally happen
+	// and falls through to the usual placeholder. Expression values are not
+	// literals at all; writeConfigAttributes emits those verbatim instead.
+	if raw, ok := v.values[name]; ok && !strings.HasPrefix(raw, ExprValuePrefix) {
+		if val, err := convert.Convert(cty.StringVal(raw), attrS.Type); err == nil {
+			return val
+		}
+	}
+	if v.secureDefaults {
+		if val, ok := secureDefaultValue(name, attrS.Type); ok {
+			return val
+		}
+	}
+	// A user-chosen token format always produces a string, regardless of the
+	// attribute type: the token is meant to be replaced by a template engine
+	// before the configuration is ever parsed.
+	if v.placeholderFormat != "" {
+		return cty.StringVal(fmt.Sprintf(v.placeholderFormat, name))
+	}
+	// Type placeholders from the CLI configuration apply to required
+	// attributes only: an optional attribute rendered as null is a valid
+	// configuration already, while a required attribute must carry some
+	// value for the generated file to validate.
+	if attrS.Required {
+		if val, ok := v.typePlaceholders[placeholderTypeKey(attrS.Type)]; ok {
+			return val
+		}
+	}
+	return attrS.EmptyValue()
+}
+
+// placeholderValueExample prefers an example value the provider's schema
+// metadata publishes for the enclosing block over the regular placeholder,
+// unless the user supplied a value for the attribute explicitly, which
+// always wins.
+func (v *addHuman) placeholderValueExample(name string, attrS *configschema.Attribute, examples map[string]cty.Value) cty.Value {
+	if example, ok := examples[name]; ok {
+		if _, supplied := v.values[name]; !supplied {
+			if val, err := convert.Convert(example, attrS.Type); err == nil {
+				return val
+			}
+		}
+	}
+	return v.placeholderValue(name, attrS)
+}
+
+func (v *addHuman) writeAttrTypeConstraint(buf *strings.Builder, name string, schema *configschema.Attribute) {
+	if v.noComments {
+		buf.WriteString("\n")
+		return
+	}
+
+	var note strings.Builder
+	switch {
+	case schema.Required:
+		note.WriteString("REQUIRED ")
+	case schema.Optional && schema.Computed:
+		// A distinct marker for optional+computed attributes, since leaving
+		// those unset is normal and lets the provider decide the value.
+		note.WriteString("OPTIONAL/COMPUTED ")
+	default:
+		note.WriteString("OPTIONAL ")
+	}
+
+	// The type is rendered in Terraform's type expression syntax rather than
+	// cty's English-like friendly name, so it can be copied straight into a
+	// variable declaration's "type" argument.
+	if schema.NestedType != nil {
+		note.WriteString(typeConstraintString(schema.NestedType.ImpliedType(), typeExprRenderDepth))
+	} else {
+		note.WriteString(typeConstraintString(schema.Type, typeExprRenderDepth))
+	}
+
+	// For well-known attribute names we also suggest an example expression,
+	// since a null placeholder alone gives no hint about what kind of
+	// reference would normally appear here.
+	if example, ok := v.examples[name]; ok && schema.NestedType == nil {
+		note.WriteString(fmt.Sprintf(" (e.g. %s)", example))
+	}
+
+	// With -infer, attributes whose names match a well-known environment
+	// convention also get the value found in the environment, as a suggestion
+	// rather than a populated default.
+	if suggestion, ok := v.inferred[name]; ok && schema.NestedType == nil {
+		note.WriteString(fmt.Sprintf(" (suggested: %s)", suggestion))
+	}
+
+	// With -import-identity, mark the attributes "terraform import" will
+	// match against the real object, since those must be set to its exact
+	// current values rather than placeholders of the user's choosing.
+	if v.importIdentity && isImportIdentityName(name) && schema.NestedType == nil {
+		note.WriteString(" (import identity: must match the real object)")
+	}
+
+	buf.WriteString(v.annotationComment(note.String()))
+	buf.WriteString("\n")
+}
+
+func (v *addHuman) writeBlockTypeConstraint(buf *strings.Builder, schema *configschema.NestedBlock) {
+	if v.noComments {
+		buf.WriteString("\n")
+		return
+	}
+
+	var note strings.Builder
+	if schema.MinItems > 0 {
+		note.WriteString("REQUIRED block")
+	} else {
+		note.WriteString("OPTIONAL block")
+	}
+
+	// For repeatable blocks the schema may also constrain how many are
+	// allowed, so note the cardinality and save the reader a trip to the
+	// provider documentation. Zero MaxItems means unlimited.
+	if schema.Nesting == configschema.NestingList || schema.Nesting == configschema.NestingSet {
+		switch {
+		case schema.MinItems > 0 && schema.MaxItems > 0:
+			note.WriteString(fmt.Sprintf(" (min %d, max %d)", schema.MinItems, schema.MaxItems))
+		case schema.MinItems > 1:
+			note.WriteString(fmt.Sprintf(" (min %d)", schema.MinItems))
+		case schema.MaxItems > 0:
+			note.WriteString(fmt.Sprintf(" (max %d)", schema.MaxItems))
+		}
+	}
+
+	buf.WriteString(v.annotationComment(note.String()))
+	buf.WriteString("\n")
+}
+
+// copied from command/format/diff
+func ctyCollectionValues(val cty.Value) []cty.Value {
+	if !val.IsKnown() || val.IsNull() {
+		return nil
+	}
+
+	var len int
+	if val.IsMarked() {
+		val, _ = val.Unmark()
+		len = val.LengthInt()
+	} else {
+		len = val.LengthInt()
+	}
+
+	ret := make([]cty.Value, 0, len)
+	for it := val.ElementIterator(); it.Next(); {
+		_, value := it.Element()
+		ret = append(ret, value)
+	}
+
+	return ret
+}
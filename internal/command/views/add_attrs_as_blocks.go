@@ -0,0 +1,94 @@
+package views
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/lang/blocktoattr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// attrAsBlocks reports whether the given attribute is one of the legacy SDK
+// "attributes as blocks" quirks: a list or set of objects declared as an
+// attribute, which providers built on that SDK expect to see written in
+// block syntax. These are the same names lang/blocktoattr treats as
+// ambiguous when decoding configuration, and generating them in attribute
+// syntax can fail validation against such providers.
+func attrAsBlocks(attrS *configschema.Attribute) bool {
+	if attrS.NestedType != nil {
+		return false
+	}
+	ty := attrS.Type
+	return (ty.IsListType() || ty.IsSetType()) && ty.ElementType().IsObjectType()
+}
+
+// writeAttrAsBlocks renders a placeholder block for an attrs-as-blocks
+// attribute, using an element schema derived from the attribute's type. The
+// element type can't record which of its attributes are required, so they
+// are all included as optional placeholders.
+func (v *addHuman) writeAttrAsBlocks(buf *strings.Builder, name string, attrS *configschema.Attribute, indent int) error {
+	if !attrS.Required && !(attrS.Optional && v.optional) {
+		return nil
+	}
+
+	blockS := blocktoattr.SchemaForCtyContainerType(attrS.Type)
+
+	buf.WriteString(strings.Repeat(" ", indent))
+	buf.WriteString(fmt.Sprintf("%s {", name))
+	if attrS.Required {
+		buf.WriteString(v.annotationComment("REQUIRED block"))
+	} else {
+		buf.WriteString(v.annotationComment("OPTIONAL block"))
+	}
+	buf.WriteString("\n")
+
+	keys := make([]string, 0, len(blockS.Attributes))
+	for k := range blockS.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		elemS := blockS.Attributes[key]
+		buf.WriteString(strings.Repeat(" ", indent+2))
+		buf.WriteString(fmt.Sprintf("%s = ", key))
+		tok := hclwrite.TokensForValue(v.placeholderValue(key, elemS))
+		if _, err := tok.WriteTo(buf); err != nil {
+			return err
+		}
+		v.writeAttrTypeConstraint(buf, key, elemS)
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+// writeAttrAsBlocksFromExisting renders one block per element of the state
+// value of an attrs-as-blocks attribute, in the same way nested blocks
+// declared as such in the schema are rendered.
+func (v *addHuman) writeAttrAsBlocksFromExisting(buf *strings.Builder, name string, attrS *configschema.Attribute, stateVal cty.Value, indent int, refs []outputRef) error {
+	var val cty.Value
+	if stateVal.Type().HasAttribute(name) {
+		val = stateVal.GetAttr(name)
+	} else {
+		val = attrS.EmptyValue()
+	}
+
+	if attrS.Sensitive || val.HasMark("sensitive") {
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString(fmt.Sprintf("%s {} # sensitive\n", name))
+		return nil
+	}
+
+	blockS := blocktoattr.SchemaForCtyContainerType(attrS.Type)
+	for _, elemVal := range ctyCollectionValues(val) {
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString(fmt.Sprintf("%s {\n", name))
+		if err := v.writeConfigAttributesFromExisting(buf, elemVal, blockS.Attributes, indent+2, refs); err != nil {
+			return err
+		}
+		buf.WriteString("}\n")
+	}
+	return nil
+}
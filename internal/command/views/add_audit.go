@@ -0,0 +1,98 @@
+package views
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+// AuditResult describes how one configured resource block compares against
+// its provider schema, for the -audit report.
+type AuditResult struct {
+	Addr addrs.ConfigResource
+
+	// MissingRequired names the attributes the schema requires which the
+	// block does not set.
+	MissingRequired []string
+
+	// UnsetOptional names the settable attributes the schema declares which
+	// the block does not set; after a provider upgrade these are typically
+	// the attributes the new provider version added.
+	UnsetOptional []string
+}
+
+// auditResultJSON is the wire shape of one AuditResult in the -audit-format=json
+// report.
+type auditResultJSON struct {
+	Address         string   `json:"address"`
+	MissingRequired []string `json:"missing_required"`
+	UnsetOptional   []string `json:"unset_optional"`
+}
+
+// Audit reports how the resources already in the configuration compare
+// against their provider schemas, either as an aligned table for reading or
+// as JSON for tooling.
+func (v *addHuman) Audit(results []AuditResult, format string) error {
+	if format == "json" {
+		rows := make([]auditResultJSON, len(results))
+		for i, result := range results {
+			rows[i] = auditResultJSON{
+				Address:         result.Addr.String(),
+				MissingRequired: result.MissingRequired,
+				UnsetOptional:   result.UnsetOptional,
+			}
+			// Empty lists read better than null in the output.
+			if rows[i].MissingRequired == nil {
+				rows[i].MissingRequired = []string{}
+			}
+			if rows[i].UnsetOptional == nil {
+				rows[i].UnsetOptional = []string{}
+			}
+		}
+		src, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(v.stdoutWriter(), string(src))
+		return err
+	}
+
+	if len(results) == 0 {
+		_, err := fmt.Fprintln(v.stdoutWriter(), "No resources in configuration.")
+		return err
+	}
+
+	const (
+		addrHeader     = "RESOURCE"
+		requiredHeader = "MISSING REQUIRED"
+		optionalHeader = "UNSET OPTIONAL"
+	)
+	addrWidth := len(addrHeader)
+	requiredWidth := len(requiredHeader)
+	for _, result := range results {
+		if l := len(result.Addr.String()); l > addrWidth {
+			addrWidth = l
+		}
+		if l := len(strings.Join(result.MissingRequired, ", ")); l > requiredWidth {
+			requiredWidth = l
+		}
+	}
+
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("%-*s  %-*s  %s\n", addrWidth, addrHeader, requiredWidth, requiredHeader, optionalHeader))
+	for _, result := range results {
+		required := strings.Join(result.MissingRequired, ", ")
+		optional := strings.Join(result.UnsetOptional, ", ")
+		if required == "" {
+			required = "-"
+		}
+		if optional == "" {
+			optional = "-"
+		}
+		buf.WriteString(fmt.Sprintf("%-*s  %-*s  %s\n", addrWidth, result.Addr.String(), requiredWidth, required, optional))
+	}
+	_, err := fmt.Fprintln(v.stdoutWriter(), strings.TrimRight(buf.String(), "\n"))
+	return err
+}
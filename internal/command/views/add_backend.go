@@ -0,0 +1,55 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/version"
+)
+
+// BackendScaffold emits a terraform block containing a backend configuration
+// skeleton for the given backend type, generated from the backend's own
+// configuration schema: required settings become placeholders, and with
+// -optional the optional settings are included as well. Unlike resource
+// generation there is no provider or state involved, so the block is
+// rendered from the schema alone.
+func (v *addHuman) BackendScaffold(typeName string, schema *configschema.Block) error {
+	var buf strings.Builder
+
+	if !v.skipExperimentNote {
+		buf.WriteString(`# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+`)
+	}
+
+	if v.provenance {
+		buf.WriteString(fmt.Sprintf("# generated by \"terraform add\" v%s on %s from backend schema\n",
+			version.String(), time.Now().Format("2006-01-02")))
+	}
+
+	buf.WriteString("terraform {\n")
+	buf.WriteString(fmt.Sprintf("  backend %q {\n", typeName))
+	if err := v.writeConfigAttributes(&buf, schema.Attributes, 4); err != nil {
+		return err
+	}
+	if err := v.writeConfigBlocks(&buf, schema.BlockTypes, 4); err != nil {
+		return err
+	}
+	buf.WriteString("  }\n}")
+
+	formatted := hclwrite.Format([]byte(buf.String()))
+
+	// A backend block has no resource address or provider; emit only
+	// consults those for the -out-dir split modes, which the argument
+	// parser rejects together with -type=backend.
+	return v.emit(addrs.AbsResourceInstance{}, addrs.LocalProviderConfig{}, formatted)
+}
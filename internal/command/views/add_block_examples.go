@@ -0,0 +1,18 @@
+package views
+
+import (
+	"github.com/zclconf/go-cty/cty"
+)
+
+// SetBlockExamples supplies per-block example values published in the
+// provider's schema metadata, keyed by block type name and then attribute
+// name. When a nested block is rendered as a placeholder template those
+// values are preferred over zero placeholders, so the scaffold shows a
+// realistic configuration — ingress { from_port = 443 } rather than
+// from_port = null. Block type names are looked up without regard to where
+// the block nests, matching how the examples are collected. Like SetValues
+// it must be called before rendering begins, since rendering may happen
+// concurrently.
+func (v *addHuman) SetBlockExamples(examples map[string]map[string]cty.Value) {
+	v.blockExamples = examples
+}
@@ -0,0 +1,28 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+// writeCheckBlock appends a top-level check block scaffold for the given
+// resource: a data block re-reading the object the resource manages, and an
+// assert verifying that it still matches, as a starting point for continuous
+// post-deployment validation. Which arguments look the object up depends on
+// the resource type, so the data block's content is left as a TODO for the
+// author.
+func writeCheckBlock(buf *strings.Builder, addr addrs.AbsResourceInstance) {
+	rs := addr.Resource.Resource
+	buf.WriteString(fmt.Sprintf("check %q {\n", fmt.Sprintf("%s_%s", rs.Type, rs.Name)))
+	buf.WriteString(fmt.Sprintf("  data %q %q {\n", rs.Type, rs.Name))
+	buf.WriteString("    # TODO: Set arguments which look up the object this resource manages,\n")
+	buf.WriteString("    # for example by its id or name.\n")
+	buf.WriteString("  }\n\n")
+	buf.WriteString("  assert {\n")
+	buf.WriteString(fmt.Sprintf("    condition     = data.%s.%s.id == %s.id\n", rs.Type, rs.Name, rs.String()))
+	buf.WriteString(fmt.Sprintf("    error_message = %q\n", fmt.Sprintf("%s no longer matches the object it manages.", rs.String())))
+	buf.WriteString("  }\n")
+	buf.WriteString("}")
+}
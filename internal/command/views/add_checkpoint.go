@@ -0,0 +1,67 @@
+package views
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+// The -checkpoint file holds one resource instance address per line, each
+// recording a block a previous run already wrote to disk. Bulk runs append
+// to it after every successful write and remove it when the whole run
+// completes, so an interrupted run can be re-run with the same arguments and
+// pick up where it left off instead of generating the finished blocks twice.
+
+// loadCheckpoint reads the addresses recorded in the checkpoint file, if
+// any. A missing file just means there is nothing to resume.
+func (v *addHuman) loadCheckpoint() (map[string]bool, error) {
+	if v.checkpointPath == "" {
+		return nil, nil
+	}
+	src, err := os.ReadFile(v.checkpointPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading the -checkpoint file: %s", err)
+	}
+	done := make(map[string]bool)
+	for _, line := range strings.Split(string(src), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			done[line] = true
+		}
+	}
+	return done, nil
+}
+
+// appendCheckpoint records that the block for the given address has been
+// written, flushing to disk immediately so the record survives however the
+// run is interrupted afterwards.
+func (v *addHuman) appendCheckpoint(addr addrs.AbsResourceInstance) error {
+	if v.checkpointPath == "" {
+		return nil
+	}
+	f, err := os.OpenFile(v.checkpointPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("writing the -checkpoint file: %s", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%s\n", addr); err != nil {
+		return fmt.Errorf("writing the -checkpoint file: %s", err)
+	}
+	return nil
+}
+
+// clearCheckpoint removes the checkpoint file once the run has written every
+// requested block, so the next run starts fresh.
+func (v *addHuman) clearCheckpoint() error {
+	if v.checkpointPath == "" {
+		return nil
+	}
+	if err := os.Remove(v.checkpointPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing the -checkpoint file: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package views
+
+// annotationComment renders one trailing annotation, such as "REQUIRED
+// string" or "OPTIONAL block", in the style selected with -comment-style:
+// a "#" comment by default, a "//" comment with the slash style, or nothing
+// at all with none. Teams post-processing generated files sometimes need
+// "//" leaders or no annotations in specific positions, while the rest of
+// the generated comments are unaffected; -no-comments remains the way to
+// drop every comment.
+func (v *addHuman) annotationComment(text string) string {
+	switch v.commentStyle {
+	case "slash":
+		return " // " + text
+	case "none":
+		return ""
+	default:
+		return " # " + text
+	}
+}
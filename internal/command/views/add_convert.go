@@ -0,0 +1,15 @@
+package views
+
+import (
+	"fmt"
+)
+
+// Converted prints the result of an -hcl2json or -json2hcl conversion. The
+// body is the converted document exactly as the command produced it, so no
+// experiment note, provenance marker or line wrapping is applied: the point
+// of the conversion modes is that the output can be saved as a configuration
+// file as-is.
+func (v *addHuman) Converted(body []byte) error {
+	_, err := fmt.Fprint(v.stdoutWriter(), string(body))
+	return err
+}
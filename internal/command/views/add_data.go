@@ -0,0 +1,73 @@
+package views
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/version"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// DataSource emits a data block which reads the same object a managed
+// resource tracks in state, using the provider's data source of the same
+// type name and the given lookup arguments. This backs the -as-data option,
+// for configurations being split where one side should consume the object
+// rather than manage it.
+func (v *addHuman) DataSource(addr addrs.AbsResourceInstance, pc addrs.LocalProviderConfig, lookup map[string]cty.Value) error {
+	var buf strings.Builder
+
+	if !v.skipExperimentNote {
+		buf.WriteString(`# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+`)
+	}
+
+	if v.provenance {
+		buf.WriteString(fmt.Sprintf("# generated by \"terraform add\" v%s on %s from state\n",
+			version.String(), time.Now().Format("2006-01-02")))
+	}
+
+	buf.WriteString(fmt.Sprintf("data %q %q {\n", addr.Resource.Resource.Type, addr.Resource.Resource.Name))
+
+	if pc.LocalName != addr.Resource.Resource.ImpliedProvider() || pc.Alias != "" {
+		buf.WriteString(fmt.Sprintf("  provider = %s\n\n", pc.StringCompact()))
+	}
+
+	names := make([]string, 0, len(lookup))
+	for name := range lookup {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		buf.WriteString(fmt.Sprintf("  %s = ", name))
+		tok := hclwrite.TokensForValue(lookup[name])
+		if _, err := tok.WriteTo(&buf); err != nil {
+			return err
+		}
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("}")
+
+	formatted := hclwrite.Format([]byte(buf.String()))
+	if v.maxLineWidth > 0 {
+		formatted = hclwrite.Format(wrapLongLines(formatted, v.maxLineWidth))
+	}
+
+	if err := v.emit(addr, pc, formatted); err != nil {
+		return err
+	}
+	v.generated++
+
+	v.writeHints(addr, true)
+	return nil
+}
@@ -0,0 +1,174 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// Every diagnostic the "terraform add" family emits carries a stable code,
+// shown in brackets after the summary in human output and as a separate
+// "code" property with -json-diags. Automation should match on the codes:
+// the English summary and detail text may be reworded between releases, but
+// a code keeps its meaning. The table is append-only — a new diagnostic
+// takes the next free number, a reworded summary keeps its code, and a
+// retired code is never reused for a different meaning.
+var addDiagCodes = map[string]string{
+	// Command-line argument problems.
+	"Failed to parse command-line flags":                  "TF-ADD-001",
+	"Incompatible command-line options":                   "TF-ADD-002",
+	"Too few command line arguments":                      "TF-ADD-003",
+	"Too many command line arguments":                     "TF-ADD-004",
+	"Invalid block type":                                  "TF-ADD-005",
+	"Invalid audit format":                                "TF-ADD-006",
+	"Invalid backend arguments":                           "TF-ADD-007",
+	"Invalid clone arguments":                             "TF-ADD-008",
+	"Invalid migration arguments":                         "TF-ADD-009",
+	"Invalid maximum line width":                          "TF-ADD-010",
+	"Invalid newline mode":                                "TF-ADD-011",
+	"Invalid output directory":                            "TF-ADD-012",
+	"Invalid output filename":                             "TF-ADD-013",
+	"Invalid placeholder format":                          "TF-ADD-014",
+	"Invalid position format":                             "TF-ADD-015",
+	"Invalid script language":                             "TF-ADD-016",
+	"Invalid split mode":                                  "TF-ADD-017",
+	"Invalid state serial":                                "TF-ADD-018",
+	"Wildcard address requires -from-state":               "TF-ADD-019",
+	"Data sources are not yet supported by terraform add": "TF-ADD-022",
+	"Module calls are not yet supported by terraform add": "TF-ADD-023",
+
+	// Problems with auxiliary input files and the CLI configuration.
+	"Failed to read example expressions file":   "TF-ADD-024",
+	"Invalid example expressions file":          "TF-ADD-025",
+	"Failed to read file header file":           "TF-ADD-026",
+	"Failed to read generation exclude file":    "TF-ADD-027",
+	"Invalid generation exclude pattern":        "TF-ADD-028",
+	"Failed to read policy directory":           "TF-ADD-029",
+	"Failed to read policy file":                "TF-ADD-030",
+	"Invalid policy file":                       "TF-ADD-031",
+	"Failed to read schema override file":       "TF-ADD-032",
+	"Invalid schema override file":              "TF-ADD-033",
+	"Unknown redaction profile":                 "TF-ADD-034",
+	"Failed to read values from standard input": "TF-ADD-035",
+	"Invalid value on standard input":           "TF-ADD-036",
+	"Invalid attribute value":                   "TF-ADD-037",
+	"Invalid attribute expression":              "TF-ADD-038",
+	"Unknown attribute":                         "TF-ADD-039",
+
+	// Problems resolving resources against the existing configuration.
+	"No Terraform configuration files":      "TF-ADD-040",
+	"Resource already in configuration":     "TF-ADD-041",
+	"Resource not in configuration":         "TF-ADD-042",
+	"Resource type mismatch":                "TF-ADD-043",
+	"Resource excluded from generation":     "TF-ADD-044",
+	"Cannot clone resource configuration":   "TF-ADD-045",
+	"Cannot copy resource configuration":    "TF-ADD-046",
+	"Cannot convert resource configuration": "TF-ADD-047",
+	"Cannot read source configuration":      "TF-ADD-048",
+	"Resource is already in JSON syntax":    "TF-ADD-049",
+	"Resource is already in native syntax":  "TF-ADD-050",
+
+	// Backend and state problems.
+	"Backend initialization required, please run \"terraform init\"": "TF-ADD-051",
+	"Initialization failed":            "TF-ADD-052",
+	"Unsupported backend":              "TF-ADD-053",
+	"Unsupported backend type":         "TF-ADD-054",
+	"Error initializing config loader": "TF-ADD-055",
+	"Error loading state":              "TF-ADD-056",
+	"Error refreshing state":           "TF-ADD-057",
+	"Error decoding state":             "TF-ADD-058",
+	"Error selecting workspace":        "TF-ADD-060",
+	"No state":                         "TF-ADD-061",
+	"No state for resource":            "TF-ADD-062",
+	"State serial mismatch":            "TF-ADD-063",
+	"State serial not available":       "TF-ADD-064",
+	"No matching data source":          "TF-ADD-065",
+
+	// Provider and schema problems.
+	"Error loading plugin path":                   "TF-ADD-066",
+	"Provider plugin not available":               "TF-ADD-067",
+	"Failed to start provider plugin":             "TF-ADD-068",
+	"Failed to read provider schema":              "TF-ADD-069",
+	"Some provider schemas could not be loaded":   "TF-ADD-070",
+	"Missing schema for provider":                 "TF-ADD-071",
+	"Missing resource schema from provider":       "TF-ADD-072",
+	"Schema version mismatch":                     "TF-ADD-073",
+	"Provider not declared in required_providers": "TF-ADD-074",
+
+	// Generation and output problems.
+	"Approval required for bulk generation":                      "TF-ADD-075",
+	"Error asking for approval":                                  "TF-ADD-076",
+	"Existing block differs from generated configuration":        "TF-ADD-077",
+	"Failed to write generated configuration":                    "TF-ADD-078",
+	"The out path doesn't exist or is not accessible":            "TF-ADD-079",
+	"Invalid generated configuration":                            "TF-ADD-080",
+	"Generator program failed":                                   "TF-ADD-081",
+	"Failed to decode generated configuration for policy checks": "TF-ADD-082",
+	"Failed to encode generated configuration for policy checks": "TF-ADD-083",
+	"No policies in policy directory":                            "TF-ADD-084",
+	"Ignored non-JSON files in policy directory":                 "TF-ADD-085",
+}
+
+// A few summaries carry their subject in the summary text itself, so those
+// are recognized by prefix rather than exact match.
+var addDiagCodePrefixes = map[string]string{
+	"Error parsing resource address":    "TF-ADD-020",
+	"Invalid provider string":           "TF-ADD-021",
+	"Error decoding state for resource": "TF-ADD-059",
+}
+
+// addDiagnosticCode returns the stable code for a diagnostic summary, or an
+// empty string for a diagnostic this command didn't produce itself — such as
+// one passed through from the configuration loader — which keeps its
+// original presentation.
+func addDiagnosticCode(summary string) string {
+	if code, ok := addDiagCodes[summary]; ok {
+		return code
+	}
+	for prefix, code := range addDiagCodePrefixes {
+		if strings.HasPrefix(summary, prefix) {
+			return code
+		}
+	}
+	return ""
+}
+
+// withAddDiagCodes returns the given diagnostics with each recognized
+// summary suffixed by its stable code in brackets, which is how the codes
+// reach human-readable output. Source ranges are preserved so diagnostics
+// pointing into configuration files keep their snippets.
+func withAddDiagCodes(diags tfdiags.Diagnostics) tfdiags.Diagnostics {
+	out := make(tfdiags.Diagnostics, 0, len(diags))
+	for _, diag := range diags {
+		desc := diag.Description()
+		code := addDiagnosticCode(desc.Summary)
+		if code == "" {
+			out = append(out, diag)
+			continue
+		}
+		summary := fmt.Sprintf("%s [%s]", desc.Summary, code)
+
+		if src := diag.Source(); src.Subject != nil {
+			hclDiag := &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  summary,
+				Detail:   desc.Detail,
+				Subject:  src.Subject.ToHCL().Ptr(),
+			}
+			if diag.Severity() == tfdiags.Warning {
+				hclDiag.Severity = hcl.DiagWarning
+			}
+			if src.Context != nil {
+				hclDiag.Context = src.Context.ToHCL().Ptr()
+			}
+			out = out.Append(hclDiag)
+			continue
+		}
+
+		out = out.Append(tfdiags.Sourceless(diag.Severity(), summary, desc.Detail))
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+package views
+
+// defaultExampleExpressions maps well-known attribute names to example
+// expressions which are rendered as commented suggestions next to the
+// placeholder values in a generated configuration template. These are
+// intended only as a learning aid; the generated comment shows one
+// idiomatic way a practitioner might populate the attribute.
+//
+// Users can extend or replace entries in this table with the
+// -example-expressions command line option, which accepts a JSON file
+// containing an object mapping attribute names to expression strings.
+var defaultExampleExpressions = map[string]string{
+	"ami":               "data.aws_ami.selected.id",
+	"subnet_id":         "aws_subnet.example.id",
+	"vpc_id":            "aws_vpc.example.id",
+	"security_group_id": "aws_security_group.example.id",
+	"instance_type":     `"t3.micro"`,
+	"availability_zone": `data.aws_availability_zones.available.names[0]`,
+}
+
+// exampleExpressions returns the example expression table to use for a
+// generated template, merging any user-supplied overrides over the
+// built-in defaults. A nil or empty overrides map leaves the defaults
+// unchanged.
+func exampleExpressions(overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return defaultExampleExpressions
+	}
+	ret := make(map[string]string, len(defaultExampleExpressions)+len(overrides))
+	for k, v := range defaultExampleExpressions {
+		ret[k] = v
+	}
+	for k, v := range overrides {
+		ret[k] = v
+	}
+	return ret
+}
@@ -0,0 +1,100 @@
+package views
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// writeExplanation reports, as an aside on standard error after a generated
+// block, why each emitted attribute and nested block is present: required by
+// the schema, a min_items constraint, requested with -optional, or populated
+// from state. The report mirrors the decisions the renderer made, so it is
+// useful both for learning the schema-driven behavior and for debugging
+// unexpected output.
+func (v *addHuman) writeExplanation(addr addrs.AbsResourceInstance, schema *configschema.Block, stateVal cty.Value) {
+	fromState := !stateVal.RawEquals(cty.NilVal)
+
+	type item struct {
+		name   string
+		reason string
+	}
+	var items []item
+
+	names := make([]string, 0, len(schema.Attributes))
+	for name := range schema.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		attrS := schema.Attributes[name]
+		switch {
+		case fromState:
+			// Mirrors writeConfigAttributesFromExisting: computed-only
+			// attributes are never arguments, and optional+computed values
+			// were likely decided by the provider.
+			if !attrS.Required && !attrS.Optional {
+				continue
+			}
+			if attrS.Optional && attrS.Computed && !v.includeOptionalComputed {
+				continue
+			}
+			if attrS.Required {
+				items = append(items, item{name, "required by the schema; value populated from state"})
+			} else {
+				items = append(items, item{name, "value populated from state"})
+			}
+		case attrS.Required:
+			items = append(items, item{name, "required by the schema"})
+		case attrS.Optional && v.optional:
+			items = append(items, item{name, "requested with -optional"})
+		}
+	}
+
+	blockNames := make([]string, 0, len(schema.BlockTypes))
+	for name := range schema.BlockTypes {
+		blockNames = append(blockNames, name)
+	}
+	sort.Strings(blockNames)
+	for _, name := range blockNames {
+		blockS := schema.BlockTypes[name]
+		switch {
+		case fromState:
+			// Repeating blocks render one copy per element in state, so an
+			// empty or null collection emits nothing to explain.
+			if !stateVal.Type().HasAttribute(name) {
+				continue
+			}
+			bv := stateVal.GetAttr(name)
+			repeating := blockS.Nesting != configschema.NestingSingle && blockS.Nesting != configschema.NestingGroup
+			if repeating && !bv.HasMark("sensitive") && len(ctyCollectionValues(bv)) == 0 {
+				continue
+			}
+			items = append(items, item{name, "block populated from state"})
+		case blockS.MinItems > 0:
+			items = append(items, item{name, fmt.Sprintf("block required by the schema (min_items %d)", blockS.MinItems)})
+		case v.optional:
+			items = append(items, item{name, "block requested with -optional"})
+		}
+	}
+
+	w := v.stderrWriter()
+	fmt.Fprintf(w, "\n%s: why each emitted item appears:\n", addr)
+	if len(items) == 0 {
+		fmt.Fprintf(w, "  (the schema has no arguments to emit)\n")
+		return
+	}
+	width := 0
+	for _, it := range items {
+		if len(it.name) > width {
+			width = len(it.name)
+		}
+	}
+	for _, it := range items {
+		fmt.Fprintf(w, "  %s%s - %s\n", it.name, strings.Repeat(" ", width-len(it.name)), it.reason)
+	}
+}
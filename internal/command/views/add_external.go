@@ -0,0 +1,150 @@
+package views
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/version"
+)
+
+// externalScriptSh and externalScriptPython implement the external data
+// source protocol just well enough to run: the query arguments arrive as a
+// JSON object on standard input, and the program must print a JSON object
+// whose values are all strings.
+const externalScriptSh = `#!/bin/sh
+set -eu
+
+# The query arguments arrive as a JSON object on standard input.
+QUERY=$(cat)
+
+# TODO: compute the result from $QUERY; jq is convenient for both sides:
+#   VALUE=$(printf '%s' "$QUERY" | jq -r .some_argument)
+printf '{"result": "TODO"}\n'
+`
+
+const externalScriptPython = `#!/usr/bin/env python
+import json
+import sys
+
+# The query arguments arrive as a JSON object on standard input.
+query = json.load(sys.stdin)
+
+# TODO: compute the result from query; every value must be a string.
+json.dump({"result": "TODO"}, sys.stdout)
+`
+
+// ExternalDataSource renders a data "external" block for the given address
+// from the data source's fixed protocol schema, without consulting any
+// provider. With a script language ("sh" or "python") an executable stub
+// implementing the protocol is written next to the -out or -out-dir output
+// and the program argument points at it; otherwise program gets a
+// placeholder like any other required attribute.
+func (v *addHuman) ExternalDataSource(addr addrs.AbsResourceInstance, script string) error {
+	var buf strings.Builder
+
+	if !v.skipExperimentNote {
+		buf.WriteString(`# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+`)
+	}
+
+	if v.provenance {
+		buf.WriteString(fmt.Sprintf("# generated by \"terraform add\" v%s on %s from schema\n",
+			version.String(), time.Now().Format("2006-01-02")))
+	}
+
+	name := addr.Resource.Resource.Name
+
+	buf.WriteString(fmt.Sprintf("data \"external\" %q {\n", name))
+	if !v.noComments {
+		buf.WriteString("  # The program is run with a JSON object of the query arguments on its\n")
+		buf.WriteString("  # standard input, and must print a JSON object whose values are all\n")
+		buf.WriteString("  # strings; that object is available as result.\n")
+	}
+	switch script {
+	case "sh":
+		buf.WriteString(fmt.Sprintf("  program = [\"${path.module}/%s.sh\"]\n", name))
+	case "python":
+		buf.WriteString(fmt.Sprintf("  program = [\"python3\", \"${path.module}/%s.py\"]\n", name))
+	default:
+		buf.WriteString("  program = null")
+		if !v.noComments {
+			buf.WriteString(v.annotationComment("REQUIRED list(string)"))
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+	buf.WriteString("  query = {\n")
+	if !v.noComments {
+		buf.WriteString("    # key = \"value\"\n")
+	}
+	buf.WriteString("  }\n")
+	if v.optional {
+		buf.WriteString("\n  working_dir = null")
+		if !v.noComments {
+			buf.WriteString(v.annotationComment("OPTIONAL string"))
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}")
+
+	formatted := hclwrite.Format([]byte(buf.String()))
+
+	if err := v.emit(addr, addrs.LocalProviderConfig{LocalName: "external"}, formatted); err != nil {
+		return err
+	}
+	v.generated++
+
+	if script != "" {
+		if err := v.writeExternalScript(addr, script); err != nil {
+			return err
+		}
+	}
+
+	// The import hint makes no sense for a data source, so the hints are
+	// written as for from-state output.
+	v.writeHints(addr, true)
+	return nil
+}
+
+// writeExternalScript writes the executable stub the generated data block
+// runs, named after the data source (for data.external.lookup, lookup.sh or
+// lookup.py) in the -out-dir or next to the -out file. An existing file is
+// left alone, so re-running the command never clobbers a stub the user has
+// already filled in.
+func (v *addHuman) writeExternalScript(addr addrs.AbsResourceInstance, script string) error {
+	dir := v.outDir
+	if dir == "" {
+		dir = filepath.Dir(v.outPath)
+	}
+
+	var path, stub string
+	switch script {
+	case "sh":
+		path = filepath.Join(dir, addr.Resource.Resource.Name+".sh")
+		stub = externalScriptSh
+	case "python":
+		path = filepath.Join(dir, addr.Resource.Resource.Name+".py")
+		stub = externalScriptPython
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		fmt.Fprintf(v.stderrWriter(), "%s already exists and was left unchanged.\n", path)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(stub), 0755); err != nil {
+		return err
+	}
+	v.recordFileWritten(path)
+	return nil
+}
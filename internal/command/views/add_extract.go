@@ -0,0 +1,118 @@
+package views
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Providers describe value constraints only in attribute documentation — the
+// schema carries no machine-readable enum or length information — so these
+// patterns recover the conventions the documentation actually uses.
+var (
+	// allowedValuesRe finds the list segment of phrases like "Valid values
+	// are `a`, `b` and `c`." or "Must be one of: small, large.".
+	allowedValuesRe = regexp.MustCompile(`(?i)(?:valid values(?:\s+are)?|must be one of|one of)\s*:?\s*([^.\n]+)`)
+
+	// allowedTokenRe picks the backtick- or double-quote-delimited values
+	// out of such a list segment.
+	allowedTokenRe = regexp.MustCompile("`([^`]+)`|\"([^\"]+)\"")
+
+	// bareTokenRe recognizes a bare word usable as an allowed value when the
+	// documentation doesn't delimit its list.
+	bareTokenRe = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+
+	// lengthRangeRe and lengthMaxRe recover documented string length bounds,
+	// e.g. "must be between 3 and 63 characters" or "maximum length of 64
+	// characters".
+	lengthRangeRe = regexp.MustCompile(`(?i)between\s+(\d+)\s+and\s+(\d+)\s+characters`)
+	lengthMaxRe   = regexp.MustCompile(`(?i)(?:maximum length(?:\s+of)?|no longer than|at most)\s+(\d+)\s+characters?`)
+)
+
+// descriptionSummary reduces a provider's (possibly multi-paragraph,
+// markdown) attribute description to its first line, which is enough context
+// for a variable declaration without dragging whole documents into it.
+func descriptionSummary(desc string) string {
+	line := desc
+	if idx := strings.IndexByte(line, '\n'); idx >= 0 {
+		line = line[:idx]
+	}
+	return strings.TrimSpace(line)
+}
+
+// writeValidationScaffold appends a validation block to a variable
+// declaration generated by -extract-variables, when the provider's
+// documentation of the source attribute communicates a constraint we can
+// turn into a condition: an enumeration of allowed values, or string length
+// bounds. When an allowed-values phrase is present but the values themselves
+// cannot be parsed out, a TODO comment quoting the documentation is written
+// instead, so the author still sees that a constraint exists.
+func (v *addHuman) writeValidationScaffold(buf *strings.Builder, name string, ev extractedVariable) {
+	if ev.description == "" {
+		return
+	}
+
+	if m := allowedValuesRe.FindStringSubmatch(ev.description); m != nil {
+		if values := allowedValues(m[1]); len(values) >= 2 && ev.ty.Equals(cty.String) {
+			quoted := make([]string, len(values))
+			for i, val := range values {
+				quoted[i] = fmt.Sprintf("%q", val)
+			}
+			buf.WriteString("  validation {\n")
+			buf.WriteString(fmt.Sprintf("    condition = contains([%s], var.%s)\n", strings.Join(quoted, ", "), name))
+			buf.WriteString(fmt.Sprintf("    error_message = %q\n", fmt.Sprintf("The %s value must be one of: %s.", name, strings.Join(values, ", "))))
+			buf.WriteString("  }\n")
+			return
+		}
+		if !v.noComments {
+			buf.WriteString("  # TODO: Add a validation block; the provider documents allowed values:\n")
+			buf.WriteString(fmt.Sprintf("  # %s\n", strings.TrimSpace(m[0])))
+		}
+		return
+	}
+
+	if !ev.ty.Equals(cty.String) {
+		return
+	}
+	if m := lengthRangeRe.FindStringSubmatch(ev.description); m != nil {
+		buf.WriteString("  validation {\n")
+		buf.WriteString(fmt.Sprintf("    condition = length(var.%s) >= %s && length(var.%s) <= %s\n", name, m[1], name, m[2]))
+		buf.WriteString(fmt.Sprintf("    error_message = %q\n", fmt.Sprintf("The %s value must be between %s and %s characters.", name, m[1], m[2])))
+		buf.WriteString("  }\n")
+		return
+	}
+	if m := lengthMaxRe.FindStringSubmatch(ev.description); m != nil {
+		buf.WriteString("  validation {\n")
+		buf.WriteString(fmt.Sprintf("    condition = length(var.%s) <= %s\n", name, m[1]))
+		buf.WriteString(fmt.Sprintf("    error_message = %q\n", fmt.Sprintf("The %s value must be at most %s characters.", name, m[1])))
+		buf.WriteString("  }\n")
+	}
+}
+
+// allowedValues extracts the individual allowed values from the list segment
+// of an allowed-values phrase: the delimited tokens when the documentation
+// uses backticks or quotes, or bare comma/or-separated words otherwise.
+func allowedValues(segment string) []string {
+	var values []string
+	if ms := allowedTokenRe.FindAllStringSubmatch(segment, -1); len(ms) > 0 {
+		for _, m := range ms {
+			if m[1] != "" {
+				values = append(values, m[1])
+			} else {
+				values = append(values, m[2])
+			}
+		}
+		return values
+	}
+	segment = strings.ReplaceAll(segment, " or ", ",")
+	segment = strings.ReplaceAll(segment, " and ", ",")
+	for _, part := range strings.Split(segment, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" && bareTokenRe.MatchString(part) {
+			values = append(values, part)
+		}
+	}
+	return values
+}
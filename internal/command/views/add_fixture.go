@@ -0,0 +1,95 @@
+package views
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/typeexpr"
+	"github.com/hashicorp/terraform/version"
+)
+
+// TestFixture emits a minimal test suite configuration for the given resource
+// type, for use under the tests directory read by "terraform test": the
+// required_providers block (including the built-in test provider), a provider
+// block to configure for the test environment, a typed variable per required
+// attribute, the resource under test wired to those variables, and a
+// test_assertions resource holding a starter assertion. Everything comes from
+// the resource type's schema, so provider and module authors can scaffold a
+// test case before any real configuration exists.
+func (v *addHuman) TestFixture(addr addrs.AbsResourceInstance, schema *configschema.Block, pc addrs.LocalProviderConfig, provider addrs.Provider) error {
+	var buf strings.Builder
+
+	if !v.skipExperimentNote {
+		buf.WriteString(`# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+`)
+	}
+
+	if v.provenance {
+		buf.WriteString(fmt.Sprintf("# generated by \"terraform add\" v%s on %s from schema\n",
+			version.String(), time.Now().Format("2006-01-02")))
+	}
+
+	rs := addr.Resource.Resource
+
+	buf.WriteString("terraform {\n  required_providers {\n")
+	buf.WriteString("    # The test provider is only available when running \"terraform test\",\n")
+	buf.WriteString("    # and test suite modules must always declare it.\n")
+	buf.WriteString("    test = {\n      source = \"terraform.io/builtin/test\"\n    }\n\n")
+	buf.WriteString(fmt.Sprintf("    %s = {\n      source = %q\n    }\n", pc.LocalName, provider.ForDisplay()))
+	buf.WriteString("  }\n}\n\n")
+
+	buf.WriteString("# TODO: Configure this provider for the test environment, or point it at\n")
+	buf.WriteString("# a test double of the real service, so the suite can run safely.\n")
+	buf.WriteString(fmt.Sprintf("provider %q {\n}\n\n", pc.LocalName))
+
+	var required []string
+	for name, attrS := range schema.Attributes {
+		if attrS.Required {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+
+	for _, name := range required {
+		buf.WriteString(fmt.Sprintf("variable %q {\n", name))
+		buf.WriteString(fmt.Sprintf("  type = %s\n", typeexpr.TypeString(schema.Attributes[name].Type)))
+		buf.WriteString("}\n\n")
+	}
+
+	buf.WriteString(fmt.Sprintf("resource %q %q {\n", rs.Type, rs.Name))
+	for _, name := range required {
+		buf.WriteString(fmt.Sprintf("  %s = var.%s\n", name, name))
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("resource \"test_assertions\" %q {\n", rs.Name))
+	buf.WriteString(fmt.Sprintf("  component = %q\n\n", rs.Name))
+	if len(required) > 0 {
+		name := required[0]
+		buf.WriteString(fmt.Sprintf("  equal %q {\n", name))
+		buf.WriteString("    description = \"TODO: describe what this assertion verifies\"\n")
+		buf.WriteString(fmt.Sprintf("    got         = %s.%s.%s\n", rs.Type, rs.Name, name))
+		buf.WriteString(fmt.Sprintf("    want        = var.%s\n", name))
+		buf.WriteString("  }\n")
+	} else {
+		buf.WriteString("  check \"exists\" {\n")
+		buf.WriteString("    description = \"TODO: describe what this assertion verifies\"\n")
+		buf.WriteString(fmt.Sprintf("    condition   = can(%s.%s)\n", rs.Type, rs.Name))
+		buf.WriteString("  }\n")
+	}
+	buf.WriteString("}")
+
+	formatted := hclwrite.Format([]byte(buf.String()))
+	return v.emit(addr, pc, formatted)
+}
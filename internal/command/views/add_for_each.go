@@ -0,0 +1,186 @@
+package views
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/version"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ResourceForEach emits a single resource block reconstructing a for_each
+// resource from the state of all of its instances, instead of one
+// near-identical block per instance. Attributes on which the instances agree
+// are written literally; attributes which differ are written as each.value
+// references into a generated local map whose keys are the instance keys, so
+// the original abstraction survives the round trip through flattened state.
+func (v *addHuman) ResourceForEach(addr addrs.AbsResource, schema *configschema.Block, pc addrs.LocalProviderConfig, insts map[string]cty.Value) error {
+	keys := make([]string, 0, len(insts))
+	for k := range insts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	first := insts[keys[0]]
+
+	localName := fmt.Sprintf("%s_%s_instances", addr.Resource.Type, addr.Resource.Name)
+
+	// Partition the attributes the rendering would include into those the
+	// instances agree on and those which differ. Sensitive attributes never
+	// vary through the map, since their values must not be written at all;
+	// the common path renders them as "null # sensitive" like elsewhere.
+	var varying []string
+	common := make(map[string]*configschema.Attribute, len(schema.Attributes))
+	for name, attrS := range schema.Attributes {
+		if !attrS.Required && !attrS.Optional {
+			continue
+		}
+		if attrS.Optional && attrS.Computed && !v.includeOptionalComputed {
+			continue
+		}
+		if attrS.NestedType != nil || attrAsBlocks(attrS) || attrS.Sensitive || v.redactMatch(name) {
+			common[name] = attrS
+			continue
+		}
+		if instancesAgree(insts, name) {
+			common[name] = attrS
+			continue
+		}
+		varying = append(varying, name)
+	}
+	sort.Strings(varying)
+
+	var buf strings.Builder
+
+	if !v.skipExperimentNote {
+		buf.WriteString(`# NOTE: The "terraform add" command is currently experimental and offers only a
+# starting point for your resource configuration, with some limitations.
+#
+# The behavior of this command may change in future based on feedback, possibly
+# in incompatible ways. We don't recommend building automation around this
+# command at this time. If you have feedback about this command, please open
+# a feature request issue in the Terraform GitHub repository.
+`)
+	}
+
+	if v.provenance {
+		buf.WriteString(fmt.Sprintf("# generated by \"terraform add\" v%s on %s from state\n",
+			version.String(), time.Now().Format("2006-01-02")))
+	}
+
+	buf.WriteString(fmt.Sprintf("resource %q %q {\n", addr.Resource.Type, addr.Resource.Name))
+	buf.WriteString(fmt.Sprintf("  for_each = local.%s\n\n", localName))
+
+	if pc.LocalName != addr.Resource.ImpliedProvider() || pc.Alias != "" {
+		buf.WriteString(fmt.Sprintf("  provider = %s\n\n", pc.StringCompact()))
+	}
+
+	for _, name := range varying {
+		buf.WriteString(fmt.Sprintf("  %s = each.value.%s\n", name, name))
+	}
+
+	if err := v.writeConfigAttributesFromExisting(&buf, first, common, 2, nil); err != nil {
+		return err
+	}
+
+	// Nested blocks don't participate in the generated map; they are shown
+	// from one instance, with a note when the instances disagree.
+	for _, name := range sortedBlockTypeNames(schema.BlockTypes) {
+		if !instancesAgree(insts, name) {
+			buf.WriteString(fmt.Sprintf("  # NOTE: The %s blocks differ between instances; the values shown are\n  # from instance %q.\n", name, keys[0]))
+		}
+	}
+	if err := v.writeConfigBlocksFromExisting(&buf, first, schema.BlockTypes, 2, nil); err != nil {
+		return err
+	}
+
+	buf.WriteString("}\n\n")
+
+	// The generated local map holds, per instance key, only the attributes
+	// which differ between the instances.
+	buf.WriteString("locals {\n")
+	buf.WriteString(fmt.Sprintf("  %s = ", localName))
+	outer := make(map[string]cty.Value, len(keys))
+	for _, key := range keys {
+		vals := make(map[string]cty.Value, len(varying))
+		for _, name := range varying {
+			val := insts[key].GetAttr(name)
+			val, _ = val.Unmark()
+			vals[name] = val
+		}
+		outer[key] = cty.ObjectVal(vals)
+	}
+	outerVal := cty.ObjectVal(outer)
+	if needsPrettyValue(outerVal) {
+		if err := writePrettyValue(&buf, outerVal, 2); err != nil {
+			return err
+		}
+	} else {
+		tok := hclwrite.TokensForValue(outerVal)
+		if _, err := tok.WriteTo(&buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("\n}")
+
+	formatted := hclwrite.Format([]byte(buf.String()))
+	if v.maxLineWidth > 0 {
+		formatted = hclwrite.Format(wrapLongLines(formatted, v.maxLineWidth))
+	}
+
+	if err := lintGeneratedBlock(formatted, schema); err != nil {
+		return err
+	}
+
+	instAddr := addr.Instance(addrs.NoKey)
+	formatted, err := v.runGenerators(instAddr, formatted)
+	if err != nil {
+		return err
+	}
+	if err := v.emit(instAddr, pc, formatted); err != nil {
+		return err
+	}
+	v.generated++
+	if err := v.emitRedactedVariables(); err != nil {
+		return err
+	}
+
+	v.writeHints(instAddr, true)
+	return nil
+}
+
+// instancesAgree reports whether every instance has the same value for the
+// named attribute. Instances whose object type lacks the attribute at all
+// (which can happen across schema upgrades) count as disagreement.
+func instancesAgree(insts map[string]cty.Value, name string) bool {
+	var ref cty.Value
+	refSet := false
+	for _, val := range insts {
+		if !val.Type().HasAttribute(name) {
+			return false
+		}
+		attr := val.GetAttr(name)
+		if !refSet {
+			ref = attr
+			refSet = true
+			continue
+		}
+		if !attr.RawEquals(ref) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedBlockTypeNames(blocks map[string]*configschema.NestedBlock) []string {
+	names := make([]string, 0, len(blocks))
+	for name := range blocks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
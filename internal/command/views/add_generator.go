@@ -0,0 +1,62 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+// GeneratorError reports that an external generator program from an
+// add_generator block in the CLI configuration failed, so the command can
+// report it with the program's own stderr rather than as a generic write
+// failure.
+type GeneratorError struct {
+	Command string
+	Err     error
+	Stderr  string
+}
+
+func (e *GeneratorError) Error() string {
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		return fmt.Sprintf("generator program %q failed: %s\n%s", e.Command, e.Err, stderr)
+	}
+	return fmt.Sprintf("generator program %q failed: %s", e.Command, e.Err)
+}
+
+// runGenerators pipes a generated block through the external generator
+// programs whose patterns match the resource type, in the order they were
+// configured, and returns the final output. Each program receives the
+// current text on its standard input and its standard output replaces it,
+// so organizations can post-process or entirely rewrite the default
+// generation for their own resource types. The resource type and address
+// are passed in the environment for programs which dispatch on them.
+func (v *addHuman) runGenerators(addr addrs.AbsResourceInstance, src []byte) ([]byte, error) {
+	for _, gen := range v.generators {
+		matched, err := path.Match(gen.Pattern, addr.Resource.Resource.Type)
+		if err != nil || !matched {
+			// Invalid patterns are rejected when the CLI configuration is
+			// validated, so an error here only means no match.
+			continue
+		}
+
+		cmd := exec.Command(gen.Command, gen.Args...)
+		cmd.Stdin = bytes.NewReader(src)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		cmd.Env = append(os.Environ(),
+			"TF_ADD_RESOURCE_TYPE="+addr.Resource.Resource.Type,
+			"TF_ADD_RESOURCE_ADDRESS="+addr.String(),
+		)
+		if err := cmd.Run(); err != nil {
+			return nil, &GeneratorError{Command: gen.Command, Err: err, Stderr: stderr.String()}
+		}
+		src = stdout.Bytes()
+	}
+	return src, nil
+}
@@ -0,0 +1,19 @@
+package views
+
+import (
+	"strings"
+)
+
+// withFileHeader returns the formatted output with the -file-header contents
+// prepended when the write is about to create a brand new file, so
+// organization file header policies (copyright notices, codeowners hints)
+// apply to generated files too. The header is written once, separated from
+// the first block by a blank line; files which already have contents are
+// left untouched.
+func (v *addHuman) withFileHeader(existing, formatted []byte) []byte {
+	if v.fileHeader == "" || len(existing) > 0 {
+		return formatted
+	}
+	header := strings.TrimRight(v.fileHeader, "\n")
+	return append([]byte(header+"\n\n"), formatted...)
+}
@@ -0,0 +1,81 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+// BlockDriftError is returned by emit when the -out file already contains a
+// block for the same address whose content differs from what this run
+// generated. The command maps it to a distinct exit status so scripted
+// pipelines can tell "the file needs attention" from an ordinary failure.
+type BlockDriftError struct {
+	Addr addrs.AbsResourceInstance
+	Path string
+	Diff string
+}
+
+func (e *BlockDriftError) Error() string {
+	return fmt.Sprintf("%s already has a block in %s with different content", e.Addr, e.Path)
+}
+
+// findResourceBlock returns the formatted text of the resource block for the
+// given address within src, or nil if src has no such block (or is not
+// parseable as configuration, which for this purpose is the same thing).
+func findResourceBlock(src []byte, addr addrs.AbsResourceInstance) []byte {
+	f, diags := hclwrite.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	rs := addr.Resource.Resource
+	for _, block := range f.Body().Blocks() {
+		if block.Type() != "resource" {
+			continue
+		}
+		labels := block.Labels()
+		if len(labels) == 2 && labels[0] == rs.Type && labels[1] == rs.Name {
+			out := hclwrite.NewEmptyFile()
+			out.Body().AppendBlock(block)
+			return hclwrite.Format(out.Bytes())
+		}
+	}
+	return nil
+}
+
+// blockDiff renders a minimal line-oriented diff between the block already in
+// the file and the block generated by this run: unchanged leading and
+// trailing lines keep a two-space margin, removed lines are prefixed with
+// "-" and added lines with "+".
+func blockDiff(old, new []byte) string {
+	oldLines := strings.Split(strings.TrimRight(string(old), "\n"), "\n")
+	newLines := strings.Split(strings.TrimRight(string(new), "\n"), "\n")
+
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var buf strings.Builder
+	for _, line := range oldLines[:prefix] {
+		buf.WriteString("  " + line + "\n")
+	}
+	for _, line := range oldLines[prefix : len(oldLines)-suffix] {
+		buf.WriteString("- " + line + "\n")
+	}
+	for _, line := range newLines[prefix : len(newLines)-suffix] {
+		buf.WriteString("+ " + line + "\n")
+	}
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		buf.WriteString("  " + line + "\n")
+	}
+	return buf.String()
+}
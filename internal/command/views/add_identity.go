@@ -0,0 +1,19 @@
+package views
+
+// importIdentityNames are the attribute names which conventionally identify
+// the real remote object a resource block describes: the fields "terraform
+// import" matches against. The provider protocol does not yet expose an
+// identity schema from which to derive these, so this is a name convention
+// shared by the major providers; once providers can report their identity
+// attributes directly this table becomes the fallback.
+var importIdentityNames = map[string]bool{
+	"id":   true,
+	"name": true,
+	"arn":  true,
+}
+
+// isImportIdentityName reports whether an attribute name conventionally forms
+// part of the resource's import identity; see importIdentityNames.
+func isImportIdentityName(name string) bool {
+	return importIdentityNames[name]
+}
@@ -0,0 +1,59 @@
+package views
+
+import (
+	"fmt"
+	"os"
+)
+
+// inferenceRule pairs a well-known attribute name with the environment
+// variables that conventionally hold its value, in the order they should
+// be consulted. These mirror the conventions the provider SDKs themselves
+// read, so an inferred suggestion matches what the provider would use.
+type inferenceRule struct {
+	attribute string
+	envVars   []string
+}
+
+// inferenceRules is the registry consulted by -infer. The built-in entries
+// cover the attribute names shared by the major providers; additional rules
+// can be plugged in with RegisterInference.
+var inferenceRules = []inferenceRule{
+	{"region", []string{"AWS_REGION", "AWS_DEFAULT_REGION"}},
+	{"profile", []string{"AWS_PROFILE"}},
+	{"project", []string{"GOOGLE_PROJECT", "GOOGLE_CLOUD_PROJECT", "CLOUDSDK_CORE_PROJECT"}},
+	{"zone", []string{"GOOGLE_ZONE", "CLOUDSDK_COMPUTE_ZONE"}},
+	{"location", []string{"ARM_LOCATION"}},
+	{"subscription_id", []string{"ARM_SUBSCRIPTION_ID"}},
+}
+
+// RegisterInference adds a rule to the inference registry, for callers
+// embedding this package who know about environment conventions beyond the
+// built-in table. Rules registered for an attribute that already has one
+// are only consulted if the earlier rule's variables are all unset.
+func RegisterInference(attribute string, envVars ...string) {
+	inferenceRules = append(inferenceRules, inferenceRule{attribute, envVars})
+}
+
+// inferredSuggestions consults the registry against the current process
+// environment and returns, per attribute name, the suggestion comment to
+// show next to its placeholder. The values are only ever rendered as
+// comments — never as the attribute value itself — so a wrong guess costs
+// nothing.
+func inferredSuggestions() map[string]string {
+	var ret map[string]string
+	for _, rule := range inferenceRules {
+		if _, ok := ret[rule.attribute]; ok {
+			continue
+		}
+		for _, name := range rule.envVars {
+			if val := os.Getenv(name); val != "" {
+				if ret == nil {
+					ret = make(map[string]string)
+				}
+				ret[rule.attribute] = fmt.Sprintf("%q from $%s", val, name)
+				break
+			}
+		}
+	}
+	return ret
+}
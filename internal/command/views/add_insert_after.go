@@ -0,0 +1,82 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/command/arguments"
+)
+
+// insertAfterAnchor writes the formatted block into the -out file directly
+// after the block named with -after, so generated blocks can land next to
+// related configuration rather than always at the end of the file. The
+// caller has already ruled out duplicate and drifted blocks, and formatted
+// ends with a newline.
+func (v *addHuman) insertAfterAnchor(addr addrs.AbsResourceInstance, existing, formatted []byte) error {
+	// ParseAdd validated the anchor already, so a parse failure here cannot
+	// normally happen.
+	blockType, labels, err := arguments.ParseBlockAnchor(v.insertAfter)
+	if err != nil {
+		return fmt.Errorf("invalid -after anchor %q: %s", v.insertAfter, err)
+	}
+
+	f, parseDiags := hclsyntax.ParseConfig(existing, v.outPath, hcl.InitialPos)
+	if parseDiags.HasErrors() {
+		return fmt.Errorf("parsing %s to locate the -after block: %s", v.outPath, parseDiags.Error())
+	}
+
+	var anchor *hclsyntax.Block
+Blocks:
+	for _, block := range f.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != blockType || len(block.Labels) != len(labels) {
+			continue
+		}
+		for i := range labels {
+			if block.Labels[i] != labels[i] {
+				continue Blocks
+			}
+		}
+		anchor = block
+		break
+	}
+	if anchor == nil {
+		return fmt.Errorf("%s does not contain the block %s named with -after; nothing was written", v.outPath, v.insertAfter)
+	}
+
+	// Splice the new block in after the anchor's closing brace, separated
+	// from it by a blank line like hand-written configuration. The existing
+	// contents keep their own line endings; only the inserted chunk is
+	// normalized to match.
+	off := anchor.CloseBraceRange.End.Byte
+	sep := normalizeNewlines([]byte("\n\n"), v.newline, existing)
+	body := normalizeNewlines(formatted, v.newline, existing)
+
+	merged := make([]byte, 0, len(existing)+len(sep)+len(body))
+	merged = append(merged, existing[:off]...)
+	merged = append(merged, sep...)
+	merged = append(merged, body...)
+	merged = append(merged, existing[off:]...)
+
+	// When the anchor was the final block the splice leaves the old trailing
+	// newline dangling after the new block; collapse any run of blank lines
+	// at the end of the file to a single newline.
+	for bytes.HasSuffix(merged, []byte("\r\n\r\n")) {
+		merged = merged[:len(merged)-2]
+	}
+	for bytes.HasSuffix(merged, []byte("\n\n")) {
+		merged = merged[:len(merged)-1]
+	}
+
+	if err := os.WriteFile(v.outPath, merged, 0600); err != nil {
+		return err
+	}
+	v.recordFileWritten(v.outPath)
+	if v.position != "" {
+		return v.reportPosition(addr, merged[:off+len(sep)], body)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package views
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// InstallProgress reports one line of provider installation progress while
+// -auto-init downloads and verifies plugins. The messages come from the init
+// command's installer event hooks, so they match what "terraform init" would
+// print; routing them through the view keeps them off standard output, where
+// they would otherwise interleave with generated configuration when that is
+// being piped to a file.
+//
+// In the human form each line goes to the progress stream (standard error).
+// With -json-diags each line instead becomes a newline-delimited JSON object
+// on the same stream as the diagnostics, distinguished from them by its
+// "type" property.
+func (v *addHuman) InstallProgress(message string) {
+	message = strings.TrimRight(message, "\n")
+	if v.jsonDiags {
+		src, err := json.Marshal(addInstallProgressJSON{
+			Type:    "install_progress",
+			Message: message,
+		})
+		if err != nil {
+			// A struct of plain strings cannot fail to marshal; if one
+			// somehow does, fall back to the human form rather than dropping
+			// the progress line.
+			fmt.Fprintln(v.stderrWriter(), message)
+			return
+		}
+		fmt.Fprintf(v.stderrWriter(), "%s\n", src)
+		return
+	}
+	fmt.Fprintln(v.stderrWriter(), message)
+}
+
+// addInstallProgressJSON is the shape of one -json-diags installation
+// progress line. Diagnostic lines carry no "type" property, so automation
+// consuming the stream should treat objects with type "install_progress" as
+// progress and everything else as diagnostics.
+type addInstallProgressJSON struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
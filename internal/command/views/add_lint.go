@@ -0,0 +1,86 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/lang/blocktoattr"
+)
+
+// GenerationError reports that a generated configuration block failed
+// validation against the schema it was generated from, before being written
+// anywhere. It always indicates a bug in the generation code rather than a
+// problem with the user's request, and the command reports it as such.
+type GenerationError struct {
+	Problem string
+}
+
+func (e *GenerationError) Error() string {
+	return e.Problem
+}
+
+// lintMetaArgs are the meta-arguments the generators may legitimately write
+// at the top level of a block even though no provider schema declares them.
+var lintMetaArgs = map[string]bool{
+	"provider": true,
+	"for_each": true,
+}
+
+// lintGeneratedBlock checks an already-formatted chunk of generated output
+// against the schema it was generated from, so that a bug in generation is
+// caught here with an internal-error diagnostic rather than by the user's
+// next plan. The generated expressions may refer to variables, locals or
+// other modules' outputs and so can't be evaluated here; the check is
+// structural instead: the text must parse, and every attribute and nested
+// block of each resource or data block must be declared in the schema.
+func lintGeneratedBlock(src []byte, schema *configschema.Block) error {
+	f, hclDiags := hclsyntax.ParseConfig(src, "generated.tf", hcl.InitialPos)
+	if hclDiags.HasErrors() {
+		return &GenerationError{fmt.Sprintf("the generated configuration does not parse: %s", hclDiags.Error())}
+	}
+
+	for _, block := range f.Body.(*hclsyntax.Body).Blocks {
+		// Any check block scaffolds and generated locals blocks alongside the
+		// main block have no schema to check against.
+		if block.Type != "resource" && block.Type != "data" {
+			continue
+		}
+		if err := lintBody(block.Body, schema, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lintBody(body *hclsyntax.Body, schema *configschema.Block, topLevel bool) error {
+	for name := range body.Attributes {
+		if topLevel && lintMetaArgs[name] {
+			continue
+		}
+		if _, ok := schema.Attributes[name]; !ok {
+			return &GenerationError{fmt.Sprintf("generated attribute %q is not declared in the schema", name)}
+		}
+	}
+
+	for _, block := range body.Blocks {
+		if blockS, ok := schema.BlockTypes[block.Type]; ok {
+			if err := lintBody(block.Body, &blockS.Block, false); err != nil {
+				return err
+			}
+			continue
+		}
+		// Legacy SDK attrs-as-blocks attributes are generated in block
+		// syntax, which the real config loader accepts via the same fixup.
+		if attrS, ok := schema.Attributes[block.Type]; ok && attrAsBlocks(attrS) {
+			elemS := blocktoattr.SchemaForCtyContainerType(attrS.Type)
+			if err := lintBody(block.Body, &elemS.Block, false); err != nil {
+				return err
+			}
+			continue
+		}
+		return &GenerationError{fmt.Sprintf("generated block type %q is not declared in the schema", block.Type)}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+// appendMigrationSteps appends a commented migration runbook after a
+// generated resource block, covering the common "adopt existing
+// infrastructure into a refactored layout" workflow end to end: moving the
+// state entry when the object is already managed at a different address,
+// importing it when it isn't, and the plan check confirming the migration is
+// complete. The configuration language has no block form for these
+// operations, so the runbook gives the ready-to-run commands instead.
+func (v *addHuman) appendMigrationSteps(formatted []byte, addr addrs.AbsResourceInstance) []byte {
+	var buf strings.Builder
+	buf.Write(formatted)
+	buf.WriteString("\n\n")
+	buf.WriteString(fmt.Sprintf("# Migration steps for %s:\n", addr))
+
+	step := 1
+	if v.movedFrom != nil {
+		buf.WriteString(fmt.Sprintf("# %d. Move the existing state entry to this address:\n", step))
+		buf.WriteString(fmt.Sprintf("#      terraform state mv '%s' '%s'\n", v.movedFrom, addr))
+		step++
+		buf.WriteString(fmt.Sprintf("# %d. Or, if the object is not under management yet, import it:\n", step))
+	} else {
+		buf.WriteString(fmt.Sprintf("# %d. Import the existing object into this address:\n", step))
+	}
+	buf.WriteString(fmt.Sprintf("#      terraform import '%s' ID\n", addr))
+	step++
+	buf.WriteString(fmt.Sprintf("# %d. Fill in the values above to match the real object, then run\n", step))
+	buf.WriteString("#    \"terraform plan\" and confirm it reports no changes.")
+	return []byte(buf.String())
+}
@@ -0,0 +1,42 @@
+package views
+
+import (
+	"bytes"
+	"runtime"
+)
+
+// normalizeNewlines rewrites the line endings of a generated configuration
+// according to the -newline mode, so that generated files can match the
+// line-ending conventions of the repository they are written into:
+//
+//	lf:   always Unix-style "\n"
+//	crlf: always Windows-style "\r\n"
+//	auto: match the file the output is appended to, if it has any CRLF
+//	      line endings already, and otherwise use the platform convention
+//
+// existing is the prior content of the output file, if any, and is only
+// consulted in "auto" mode.
+func normalizeNewlines(data []byte, mode string, existing []byte) []byte {
+	// The generators all emit plain "\n", but normalize anyway so that the
+	// conversion below cannot double up carriage returns.
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	crlf := false
+	switch mode {
+	case "crlf":
+		crlf = true
+	case "lf":
+		// crlf stays false
+	default: // "auto"
+		if len(existing) > 0 {
+			crlf = bytes.Contains(existing, []byte("\r\n"))
+		} else {
+			crlf = runtime.GOOS == "windows"
+		}
+	}
+
+	if crlf {
+		data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+	}
+	return data
+}
@@ -0,0 +1,65 @@
+package views
+
+import (
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// outputRef pairs a value from the state's outputs index with the expression
+// which references the output it came from.
+type outputRef struct {
+	val  cty.Value
+	expr string
+}
+
+// ModuleOutputs records the output values of the state's child module
+// instances, indexed by the expression which references them (such as
+// "module.network.subnet_id"). Attribute values generated from state which
+// equal one of these outputs are written as that reference instead of as a
+// literal, preserving the cross-module structure of the architecture.
+func (v *addHuman) ModuleOutputs(outputs map[string]cty.Value) {
+	exprs := make([]string, 0, len(outputs))
+	for expr := range outputs {
+		exprs = append(exprs, expr)
+	}
+	sort.Strings(exprs)
+
+	refs := make([]outputRef, 0, len(exprs))
+	for _, expr := range exprs {
+		refs = append(refs, outputRef{val: outputs[expr], expr: expr})
+	}
+
+	// When two different outputs have the same value we cannot know which
+	// one a matching attribute came from, so we drop both rather than
+	// guessing and emit the literal value instead.
+	ambiguous := make([]bool, len(refs))
+	for i := range refs {
+		for j := i + 1; j < len(refs); j++ {
+			if refs[i].val.RawEquals(refs[j].val) {
+				ambiguous[i] = true
+				ambiguous[j] = true
+			}
+		}
+	}
+	v.outputRefs = v.outputRefs[:0]
+	for i, ref := range refs {
+		if !ambiguous[i] {
+			v.outputRefs = append(v.outputRefs, ref)
+		}
+	}
+}
+
+// outputRefFor returns the expression referencing the module output whose
+// value equals the given value, if there is exactly one.
+func outputRefFor(refs []outputRef, val cty.Value) (string, bool) {
+	if val.IsNull() || val.IsMarked() {
+		return "", false
+	}
+	for _, ref := range refs {
+		if ref.val.RawEquals(val) {
+			return ref.expr, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,31 @@
+package views
+
+import (
+	"github.com/zclconf/go-cty/cty"
+)
+
+// SetTypePlaceholders supplies the placeholder values from any
+// add_placeholders block in the CLI configuration, keyed by primitive type
+// name: "string", "number" or "bool". Required attributes of a matching type
+// with no known value render this placeholder instead of null, so the
+// generated file validates while remaining obviously incomplete. Like
+// SetValues it must be called before rendering begins, since rendering may
+// happen concurrently.
+func (v *addHuman) SetTypePlaceholders(placeholders map[string]cty.Value) {
+	v.typePlaceholders = placeholders
+}
+
+// placeholderTypeKey maps an attribute type to the key used in an
+// add_placeholders block, or "" for types the block cannot customize.
+func placeholderTypeKey(ty cty.Type) string {
+	switch ty {
+	case cty.String:
+		return "string"
+	case cty.Number:
+		return "number"
+	case cty.Bool:
+		return "bool"
+	default:
+		return ""
+	}
+}
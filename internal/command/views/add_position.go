@@ -0,0 +1,41 @@
+package views
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+// reportPosition reports where a block just appended to the -out file landed:
+// the file name and the one-based start and end lines of the inserted
+// content. Editors invoking this command with -out use the report to scroll
+// to or select the new block, so the -position=json form goes to standard
+// output as one object per block, while the human form is an aside on
+// standard error like the other progress messages.
+func (v *addHuman) reportPosition(addr addrs.AbsResourceInstance, existing, inserted []byte) error {
+	// The inserted content begins on the line after the existing content's
+	// final newline, or continues its unterminated last line, which both
+	// work out to one line per existing newline.
+	start := bytes.Count(existing, []byte("\n")) + 1
+	end := start + bytes.Count(inserted, []byte("\n")) - 1
+
+	if v.position == "json" {
+		pos := struct {
+			Address   string `json:"address"`
+			Filename  string `json:"filename"`
+			StartLine int    `json:"start_line"`
+			EndLine   int    `json:"end_line"`
+		}{addr.String(), v.outPath, start, end}
+		src, err := json.Marshal(pos)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(v.stdoutWriter(), string(src))
+		return err
+	}
+
+	fmt.Fprintf(v.stderrWriter(), "%s: inserted into %s, lines %d-%d.\n", addr, v.outPath, start, end)
+	return nil
+}
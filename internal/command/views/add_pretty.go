@@ -0,0 +1,135 @@
+package views
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// hclwrite.TokensForValue writes the elements of a list, set or tuple on a
+// single line, so a set of objects decoded from state — a security group's
+// rule set, for example — runs its elements together into a line too dense
+// to review. The helpers here render such values with one element per line
+// instead.
+
+// isStructuralOrCollection reports whether values of the given type have
+// elements or attributes of their own, which is when spreading a containing
+// sequence across lines pays off.
+func isStructuralOrCollection(ty cty.Type) bool {
+	return ty.IsObjectType() || ty.IsMapType() || ty.IsListType() || ty.IsSetType() || ty.IsTupleType()
+}
+
+// needsPrettyValue reports whether the given value contains, at any depth, a
+// sequence with structural or collection elements — the case the single-line
+// TokensForValue rendering handles poorly. Values without one keep the
+// TokensForValue form, which already writes objects and maps one attribute
+// per line.
+func needsPrettyValue(val cty.Value) bool {
+	if val.IsNull() || !val.IsKnown() || val.IsMarked() {
+		return false
+	}
+	ty := val.Type()
+	switch {
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		for _, ev := range ctyCollectionValues(val) {
+			if !ev.IsMarked() && isStructuralOrCollection(ev.Type()) {
+				return true
+			}
+		}
+	case ty.IsMapType() || ty.IsObjectType():
+		for _, ev := range val.AsValueMap() {
+			if needsPrettyValue(ev) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writePrettyValue renders a collection value from state with one element per
+// line: sequences with structural elements get one element per line, and
+// objects and maps one attribute per line. Primitive leaves, and sequences of
+// primitives, fall back to TokensForValue, whose single-line form reads fine
+// (and is still subject to wrapLongLines). Sensitive elements are omitted
+// with a marker, the same way writeConfigAttributesFromExisting omits
+// sensitive attribute values.
+func writePrettyValue(buf *strings.Builder, val cty.Value, indent int) error {
+	if val.HasMark("sensitive") {
+		buf.WriteString("null # sensitive")
+		return nil
+	}
+	val, _ = val.Unmark()
+	ty := val.Type()
+
+	switch {
+	case val.IsNull() || !val.IsKnown():
+		// Nothing structural to spread out; fall back below.
+
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		elems := ctyCollectionValues(val)
+		spread := false
+		for _, ev := range elems {
+			if isStructuralOrCollection(ev.Type()) {
+				spread = true
+				break
+			}
+		}
+		if !spread {
+			break
+		}
+		buf.WriteString("[\n")
+		for _, ev := range elems {
+			buf.WriteString(strings.Repeat(" ", indent+2))
+			// Inside a sequence the comma has to come before the comment,
+			// since newlines alone don't separate tuple elements.
+			if ev.HasMark("sensitive") {
+				buf.WriteString("null, # sensitive\n")
+				continue
+			}
+			if err := writePrettyValue(buf, ev, indent+2); err != nil {
+				return err
+			}
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString("]")
+		return nil
+
+	case ty.IsMapType() || ty.IsObjectType():
+		if val.LengthInt() == 0 {
+			break
+		}
+		vals := val.AsValueMap()
+		keys := make([]string, 0, len(vals))
+		for key := range vals {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf.WriteString("{\n")
+		for _, key := range keys {
+			buf.WriteString(strings.Repeat(" ", indent+2))
+			if hclsyntax.ValidIdentifier(key) {
+				buf.WriteString(key)
+			} else {
+				buf.WriteString(fmt.Sprintf("%q", key))
+			}
+			buf.WriteString(" = ")
+			if err := writePrettyValue(buf, vals[key], indent+2); err != nil {
+				return err
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteString("}")
+		return nil
+	}
+
+	tok := hclwrite.TokensForValue(val)
+	_, err := tok.WriteTo(buf)
+	return err
+}
@@ -0,0 +1,218 @@
+package views
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	convert5 "github.com/hashicorp/terraform/internal/plugin/convert"
+	convert6 "github.com/hashicorp/terraform/internal/plugin6/convert"
+	"github.com/hashicorp/terraform/internal/terminal"
+	proto5 "github.com/hashicorp/terraform/internal/tfplugin5"
+	proto6 "github.com/hashicorp/terraform/internal/tfplugin6"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// renderSchemaSnapshot renders a full resource block for the given schema the
+// same way the command does, returning the generated configuration.
+func renderSchemaSnapshot(t *testing.T, schema *configschema.Block) string {
+	t.Helper()
+	streams, done := terminal.StreamsForTesting(t)
+	v := addHuman{view: NewView(streams), optional: true, quiet: true, skipExperimentNote: true}
+	var stdout, stderr strings.Builder
+	v.SetOutput(&stdout, &stderr)
+	if err := v.Resource(mustResourceInstanceAddr("aws_thing.example"), schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+		t.Fatal(err.Error())
+	}
+	done(t)
+	return stdout.String()
+}
+
+// The renderer consumes configschema.Block, which schemas delivered over
+// both plugin protocol versions are converted into, so the same resource
+// type served by a v5 plugin and a v6 plugin must generate identical
+// configuration. These snapshots pin that parity: a regression in either
+// protocol conversion, or in the renderer itself, shows up as a diff.
+func TestAddResource_protocolParity(t *testing.T) {
+	stringTy := []byte(`"string"`)
+	boolTy := []byte(`"bool"`)
+	numberTy := []byte(`"number"`)
+	mapStringTy := []byte(`["map","string"]`)
+
+	tests := map[string]struct {
+		v5   *proto5.Schema_Block
+		v6   *proto6.Schema_Block
+		want string
+	}{
+		"primitive attributes": {
+			&proto5.Schema_Block{
+				Attributes: []*proto5.Schema_Attribute{
+					{Name: "name", Type: stringTy, Required: true},
+					{Name: "count_limit", Type: numberTy, Optional: true},
+					{Name: "enabled", Type: boolTy, Optional: true},
+					{Name: "id", Type: stringTy, Optional: true, Computed: true},
+					{Name: "tags", Type: mapStringTy, Optional: true},
+				},
+			},
+			&proto6.Schema_Block{
+				Attributes: []*proto6.Schema_Attribute{
+					{Name: "name", Type: stringTy, Required: true},
+					{Name: "count_limit", Type: numberTy, Optional: true},
+					{Name: "enabled", Type: boolTy, Optional: true},
+					{Name: "id", Type: stringTy, Optional: true, Computed: true},
+					{Name: "tags", Type: mapStringTy, Optional: true},
+				},
+			},
+			`resource "aws_thing" "example" {
+  count_limit = null # OPTIONAL number
+  enabled     = null # OPTIONAL bool
+  id          = null # OPTIONAL/COMPUTED string
+  name        = null # REQUIRED string
+  tags        = null # OPTIONAL map(string)
+}
+`,
+		},
+		"nested blocks": {
+			&proto5.Schema_Block{
+				Attributes: []*proto5.Schema_Attribute{
+					{Name: "name", Type: stringTy, Required: true},
+				},
+				BlockTypes: []*proto5.Schema_NestedBlock{
+					{
+						TypeName: "settings",
+						Nesting:  proto5.Schema_NestedBlock_LIST,
+						MinItems: 1,
+						Block: &proto5.Schema_Block{
+							Attributes: []*proto5.Schema_Attribute{
+								{Name: "size", Type: numberTy, Required: true},
+							},
+						},
+					},
+					{
+						TypeName: "network",
+						Nesting:  proto5.Schema_NestedBlock_SINGLE,
+						Block: &proto5.Schema_Block{
+							Attributes: []*proto5.Schema_Attribute{
+								{Name: "cidr", Type: stringTy, Required: true},
+							},
+						},
+					},
+				},
+			},
+			&proto6.Schema_Block{
+				Attributes: []*proto6.Schema_Attribute{
+					{Name: "name", Type: stringTy, Required: true},
+				},
+				BlockTypes: []*proto6.Schema_NestedBlock{
+					{
+						TypeName: "settings",
+						Nesting:  proto6.Schema_NestedBlock_LIST,
+						MinItems: 1,
+						Block: &proto6.Schema_Block{
+							Attributes: []*proto6.Schema_Attribute{
+								{Name: "size", Type: numberTy, Required: true},
+							},
+						},
+					},
+					{
+						TypeName: "network",
+						Nesting:  proto6.Schema_NestedBlock_SINGLE,
+						Block: &proto6.Schema_Block{
+							Attributes: []*proto6.Schema_Attribute{
+								{Name: "cidr", Type: stringTy, Required: true},
+							},
+						},
+					},
+				},
+			},
+			`resource "aws_thing" "example" {
+  name = null   # REQUIRED string
+  network {     # OPTIONAL block
+    cidr = null # REQUIRED string
+  }
+  settings {    # REQUIRED block
+    size = null # REQUIRED number
+  }
+}
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got5 := renderSchemaSnapshot(t, convert5.ProtoToConfigSchema(test.v5))
+			got6 := renderSchemaSnapshot(t, convert6.ProtoToConfigSchema(test.v6))
+
+			if got5 != got6 {
+				t.Fatalf("rendering differs between protocol versions:\n%s", cmp.Diff(got5, got6))
+			}
+			if !cmp.Equal(got5, test.want) {
+				t.Fatalf("wrong output:\n%s", cmp.Diff(test.want, got5))
+			}
+		})
+	}
+}
+
+// Nested attribute types (Schema_Object) exist only in protocol v6; a v5
+// plugin cannot express them at all. This snapshot pins how a v6-only schema
+// renders, so protocol-specific regressions cannot hide behind the parity
+// cases above.
+func TestAddResource_protocolV6NestedAttributes(t *testing.T) {
+	stringTy := []byte(`"string"`)
+
+	schema := convert6.ProtoToConfigSchema(&proto6.Schema_Block{
+		Attributes: []*proto6.Schema_Attribute{
+			{Name: "name", Type: stringTy, Required: true},
+			{
+				Name:     "metadata",
+				Optional: true,
+				NestedType: &proto6.Schema_Object{
+					Nesting: proto6.Schema_Object_SINGLE,
+					Attributes: []*proto6.Schema_Attribute{
+						{Name: "labels", Type: []byte(`["map","string"]`), Optional: true},
+						{Name: "owner", Type: stringTy, Required: true},
+					},
+				},
+			},
+			{
+				Name:     "rules",
+				Optional: true,
+				NestedType: &proto6.Schema_Object{
+					Nesting: proto6.Schema_Object_LIST,
+					Attributes: []*proto6.Schema_Attribute{
+						{Name: "action", Type: stringTy, Required: true},
+					},
+				},
+			},
+		},
+	})
+
+	// The converted schema must round-trip the nested object types, not
+	// just render: the renderer consults them for the type constraint
+	// comments below.
+	if schema.Attributes["metadata"].NestedType == nil {
+		t.Fatal("metadata lost its nested type in conversion")
+	}
+	if got := schema.Attributes["metadata"].NestedType.Attributes["labels"].Type; !got.Equals(cty.Map(cty.String)) {
+		t.Fatalf("wrong type for metadata.labels: %#v", got)
+	}
+
+	want := `resource "aws_thing" "example" {
+  metadata = {    # OPTIONAL object({ labels = map(string), owner = string })
+    labels = null # OPTIONAL map(string)
+    owner  = null # REQUIRED string
+  }
+  name = null     # REQUIRED string
+  rules = [{      # OPTIONAL list(object({ action = string }))
+    action = null # REQUIRED string
+  }]
+}
+`
+
+	got := renderSchemaSnapshot(t, schema)
+	if !cmp.Equal(got, want) {
+		t.Fatalf("wrong output:\n%s", cmp.Diff(want, got))
+	}
+}
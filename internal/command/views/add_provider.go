@@ -0,0 +1,100 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+)
+
+// ProviderScaffold emits a terraform block pinning the given provider in
+// required_providers, followed by a provider configuration block when the
+// provider's own configuration schema makes anything mandatory — azurerm's
+// empty features block, for example — so that configuration generated for a
+// module which doesn't declare the provider yet initializes cleanly. Each
+// provider is scaffolded at most once per run.
+func (v *addHuman) ProviderScaffold(addr addrs.AbsResourceInstance, pc addrs.LocalProviderConfig, provider addrs.Provider, schema *configschema.Block) error {
+	if _, done := v.scaffoldedProviders[pc.LocalName]; done {
+		return nil
+	}
+	if v.scaffoldedProviders == nil {
+		v.scaffoldedProviders = make(map[string]struct{})
+	}
+	v.scaffoldedProviders[pc.LocalName] = struct{}{}
+
+	var buf strings.Builder
+	if !v.noComments {
+		buf.WriteString(fmt.Sprintf("# This module does not declare provider %s yet; the following\n# scaffold pins it and configures its mandatory arguments.\n", provider.ForDisplay()))
+	}
+	buf.WriteString("terraform {\n  required_providers {\n")
+	buf.WriteString(fmt.Sprintf("    %s = {\n      source = %q\n", pc.LocalName, provider.ForDisplay()))
+
+	// When the dependency lock file records which version is installed, pin
+	// that as a floor: the scaffold follows the installed version's schema,
+	// which older releases may not support, so allowing a downgrade would
+	// invite validation failures later.
+	if version, ok := v.providerVersions[provider]; ok {
+		if !v.noComments {
+			buf.WriteString("      # Floor taken from the installed provider version; the generated\n      # configuration follows its schema, which older releases may lack.\n")
+		}
+		buf.WriteString(fmt.Sprintf("      version = %q\n", ">= "+version))
+	}
+	buf.WriteString("    }\n")
+	buf.WriteString("  }\n}\n")
+
+	// The provider block itself is only worth scaffolding when the schema
+	// requires something in it; an empty provider block is implied anyway.
+	if schema != nil && providerNeedsConfig(schema) {
+		requiredAttrs := make(map[string]*configschema.Attribute)
+		for name, attrS := range schema.Attributes {
+			if attrS.Required {
+				requiredAttrs[name] = attrS
+			}
+		}
+		requiredBlocks := make(map[string]*configschema.NestedBlock)
+		for name, blockS := range schema.BlockTypes {
+			if blockS.MinItems > 0 {
+				requiredBlocks[name] = blockS
+			}
+		}
+
+		buf.WriteString(fmt.Sprintf("\nprovider %q {\n", pc.LocalName))
+		if err := v.writeConfigAttributes(&buf, requiredAttrs, 2); err != nil {
+			return err
+		}
+		if err := v.writeConfigBlocks(&buf, requiredBlocks, 2); err != nil {
+			return err
+		}
+		buf.WriteString("}")
+	}
+
+	formatted := hclwrite.Format([]byte(buf.String()))
+	return v.emit(addr, pc, formatted)
+}
+
+// SetProviderVersions supplies the version of each installed provider, as
+// recorded in the dependency lock file, for ProviderScaffold to pin as
+// version floors. Like SetValues it must be called before rendering begins,
+// since rendering may happen concurrently.
+func (v *addHuman) SetProviderVersions(versions map[addrs.Provider]string) {
+	v.providerVersions = versions
+}
+
+// providerNeedsConfig reports whether a provider configuration schema has
+// any required arguments or mandatory nested blocks, which is when a
+// generated provider block is needed before the configuration can be used.
+func providerNeedsConfig(schema *configschema.Block) bool {
+	for _, attrS := range schema.Attributes {
+		if attrS.Required {
+			return true
+		}
+	}
+	for _, blockS := range schema.BlockTypes {
+		if blockS.MinItems > 0 {
+			return true
+		}
+	}
+	return false
+}
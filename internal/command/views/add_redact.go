@@ -0,0 +1,124 @@
+package views
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/typeexpr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// redactMatch reports whether the given attribute name matches one of the
+// redaction patterns selected with -redact. Patterns are attribute names,
+// possibly containing "*" wildcards, such as "password" or "*secret*".
+// Invalid patterns are rejected when the CLI configuration is loaded, so a
+// match error here just means no match.
+func (v *addHuman) redactMatch(name string) bool {
+	for _, pattern := range v.redactPatterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// extractedVariable describes one variable declaration to emit once the
+// resource blocks are done: its type, the provider's documentation for the
+// source attribute, and whether it must be marked sensitive.
+type extractedVariable struct {
+	ty          cty.Type
+	description string
+	sensitive   bool
+}
+
+// recordVariable remembers that a reference to var.<name> was written in
+// place of an attribute value, so a matching variable declaration can be
+// emitted afterwards. Rendering may happen concurrently, so the record is
+// guarded by a mutex.
+func (v *addHuman) recordVariable(name string, ev extractedVariable) {
+	v.redactedMu.Lock()
+	defer v.redactedMu.Unlock()
+	if v.redactedVars == nil {
+		v.redactedVars = make(map[string]extractedVariable)
+	}
+	if _, ok := v.redactedVars[name]; !ok {
+		v.redactedVars[name] = ev
+	}
+}
+
+// emitRedactedVariables writes a variable declaration for each redacted or
+// extracted attribute, once all resource blocks have been emitted. The
+// declarations go to a variables.tf file alongside -out or -out-dir output,
+// or to standard output after the resource blocks.
+func (v *addHuman) emitRedactedVariables() error {
+	v.redactedMu.Lock()
+	vars := v.redactedVars
+	v.redactedVars = nil
+	v.redactedMu.Unlock()
+
+	if len(vars) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		ev := vars[name]
+		buf.WriteString(fmt.Sprintf("variable %q {\n", name))
+		buf.WriteString(fmt.Sprintf("  type = %s\n", typeexpr.TypeString(ev.ty)))
+		if ev.description != "" {
+			buf.WriteString(fmt.Sprintf("  description = %q\n", descriptionSummary(ev.description)))
+		}
+		if ev.sensitive {
+			buf.WriteString("  sensitive = true\n")
+		}
+		v.writeValidationScaffold(&buf, name, ev)
+		buf.WriteString("}\n")
+	}
+	formatted := hclwrite.Format([]byte(buf.String()))
+
+	switch {
+	case v.outDir != "":
+		return v.appendToFile(filepath.Join(v.outDir, "variables.tf"), formatted)
+	case v.outPath != "":
+		return v.appendToFile(filepath.Join(filepath.Dir(v.outPath), "variables.tf"), formatted)
+	default:
+		_, err := fmt.Fprintln(v.stdoutWriter(), strings.TrimSuffix(string(formatted), "\n"))
+		return err
+	}
+}
+
+// appendToFile appends an already-formatted chunk of output to the given
+// file, creating it if necessary and normalizing its line endings the same
+// way emit does for -out files.
+func (v *addHuman) appendToFile(path string, formatted []byte) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	formatted = normalizeNewlines(v.withFileHeader(existing, formatted), v.newline, existing)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(formatted); err != nil {
+		return err
+	}
+	v.recordFileWritten(path)
+	return nil
+}
@@ -0,0 +1,42 @@
+package views
+
+import (
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// secureDefaults is a catalog of security-sensitive attribute names and the
+// conservative values we pre-populate for them when a template is generated
+// in -secure-defaults mode. The intent is that a scaffold is compliant by
+// construction: encryption on, public access off, modern TLS.
+//
+// The catalog deliberately matches on attribute name alone, across all
+// providers and resource types; a value is only used where it can be
+// converted to the attribute's actual type, so a provider that happens to
+// reuse one of these names with an incompatible type keeps its normal
+// placeholder. New entries can be added here as further conventions emerge.
+var secureDefaults = map[string]cty.Value{
+	"encrypted":                     cty.True,
+	"storage_encrypted":             cty.True,
+	"enable_key_rotation":           cty.True,
+	"publicly_accessible":           cty.False,
+	"public_network_access_enabled": cty.False,
+	"force_destroy":                 cty.False,
+	"minimum_tls_version":           cty.StringVal("1.2"),
+	"min_tls_version":               cty.StringVal("1.2"),
+}
+
+// secureDefaultValue returns the catalog value for the named attribute
+// converted to the wanted type, or false if the attribute has no secure
+// default or the value cannot conform to the type.
+func secureDefaultValue(name string, ty cty.Type) (cty.Value, bool) {
+	val, ok := secureDefaults[name]
+	if !ok {
+		return cty.NilVal, false
+	}
+	converted, err := convert.Convert(val, ty)
+	if err != nil {
+		return cty.NilVal, false
+	}
+	return converted, true
+}
@@ -0,0 +1,112 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+)
+
+// splitFileHeader is the first line of every file maintained by the split
+// output mode, so we can recognize our own files and keep an index of the
+// blocks appended to them.
+const splitFileHeader = `# This file is maintained by "terraform add".`
+
+// splitFileName returns the deterministic file name within -out-dir for a
+// generated resource, according to the selected -split-by mode:
+//
+//	type:     the resource type with its provider prefix removed, reduced to
+//	          its first word, e.g. aws_s3_bucket -> s3.tf
+//	provider: the local provider name, e.g. aws.tf
+//	module:   main.tf for the root module, or module-NAME.tf
+func splitFileName(mode string, addr addrs.AbsResourceInstance, pc addrs.LocalProviderConfig) string {
+	switch mode {
+	case "provider":
+		return pc.LocalName + ".tf"
+	case "module":
+		if addr.Module.IsRoot() {
+			return "main.tf"
+		}
+		return "module-" + strings.Join(addr.Module.Module(), "-") + ".tf"
+	default: // "type"
+		ty := addr.Resource.Resource.Type
+		group := strings.TrimPrefix(ty, pc.LocalName+"_")
+		if idx := strings.Index(group, "_"); idx > 0 {
+			group = group[:idx]
+		}
+		return group + ".tf"
+	}
+}
+
+// isSplitFile reports whether the given file contents were written by the
+// split output mode, meaning the maintained header opens the file or opens a
+// line further down, below a -file-header preamble.
+func isSplitFile(existing []byte) bool {
+	return strings.HasPrefix(string(existing), splitFileHeader) ||
+		strings.Contains(string(existing), "\n"+splitFileHeader)
+}
+
+// appendToSplitFile writes a formatted configuration block into the
+// appropriate file in outDir, creating the file with an index header if
+// necessary and recording the appended block's address in the index. Files
+// not created by this command are appended to without touching their
+// contents.
+func (v *addHuman) appendToSplitFile(addr addrs.AbsResourceInstance, pc addrs.LocalProviderConfig, formatted []byte) error {
+	path := filepath.Join(v.outDir, splitFileName(v.splitBy, addr, pc))
+	indexLine := fmt.Sprintf("# index: %s", addr)
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var buf bytes.Buffer
+	switch {
+	case len(existing) == 0:
+		// The -file-header preamble, if any, comes before our own maintained
+		// header, since policies such as copyright notices expect to open
+		// the file.
+		if v.fileHeader != "" {
+			buf.WriteString(strings.TrimRight(v.fileHeader, "\n") + "\n\n")
+		}
+		buf.WriteString(splitFileHeader + "\n")
+		buf.WriteString(fmt.Sprintf("# Blocks are grouped by %s.\n", v.splitBy))
+		buf.WriteString(indexLine + "\n\n")
+	case isSplitFile(existing):
+		// Insert the new index entry after the existing "# index:" lines,
+		// which immediately follow the maintained header lines (themselves
+		// possibly preceded by a -file-header preamble).
+		lines := strings.SplitAfter(string(existing), "\n")
+		seenHeader := false
+		inserted := false
+		for _, line := range lines {
+			if !inserted && seenHeader && !strings.HasPrefix(line, "#") {
+				buf.WriteString(indexLine + "\n")
+				inserted = true
+			}
+			if strings.HasPrefix(line, splitFileHeader) {
+				seenHeader = true
+			}
+			buf.WriteString(line)
+		}
+		if !inserted {
+			buf.WriteString(indexLine + "\n")
+		}
+	default:
+		// A pre-existing file we don't manage; leave it untouched and
+		// append below.
+		buf.Write(existing)
+	}
+
+	buf.Write(formatted)
+	buf.WriteByte('\n')
+
+	if err := os.WriteFile(path, normalizeNewlines(buf.Bytes(), v.newline, existing), 0600); err != nil {
+		return err
+	}
+	v.recordFileWritten(path)
+	return nil
+}
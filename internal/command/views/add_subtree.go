@@ -0,0 +1,61 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+)
+
+// ResourceSubtree renders just one nested block or attribute of a resource
+// type's schema, for users who already have the resource block and want to
+// scaffold a newly-needed nested section to paste into it. The subtree block
+// holds only the entry the command resolved from the -path option. The
+// output is deliberately bare — no experiment note and no provenance marker
+// — since a nested section is not valid top-level configuration and only
+// ever lands inside an existing block.
+func (v *addHuman) ResourceSubtree(addr addrs.AbsResourceInstance, subtree *configschema.Block) error {
+	var buf strings.Builder
+
+	// The entry was named explicitly, so it renders even when it is optional
+	// and -optional was not given. The arguments inside a nested block still
+	// follow -optional as usual; a nested object attribute expands the same
+	// way a required one would.
+	for name, attrS := range subtree.Attributes {
+		if attrS.NestedType != nil || attrAsBlocks(attrS) {
+			restore := v.optional
+			v.optional = true
+			var err error
+			if attrS.NestedType != nil {
+				err = v.writeConfigNestedTypeAttribute(&buf, name, attrS, 0)
+			} else {
+				err = v.writeAttrAsBlocks(&buf, name, attrS, 0)
+			}
+			v.optional = restore
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("%s = ", name))
+		tok := hclwrite.TokensForValue(v.placeholderValue(name, attrS))
+		if _, err := tok.WriteTo(&buf); err != nil {
+			return err
+		}
+		v.writeAttrTypeConstraint(&buf, name, attrS)
+	}
+	for name, blockS := range subtree.BlockTypes {
+		if err := v.writeConfigNestedBlock(&buf, name, blockS, 0); err != nil {
+			return err
+		}
+	}
+
+	formatted := hclwrite.Format([]byte(buf.String()))
+	if v.maxLineWidth > 0 {
+		formatted = hclwrite.Format(wrapLongLines(formatted, v.maxLineWidth))
+	}
+	_, err := fmt.Fprintf(v.stdoutWriter(), "%s", formatted)
+	return err
+}
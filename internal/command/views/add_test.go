@@ -0,0 +1,3734 @@
+package views
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/command/arguments"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/terminal"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// updateGolden rewrites the golden files under testdata/add with the current
+// rendering, instead of comparing against them: go test -run golden -update
+var updateGolden = flag.Bool("update", false, "update golden files under testdata/add")
+
+// The output is tested in greater detail in other tests; this suite renders
+// a schema shape per case and compares against a golden file under
+// testdata/add. To add coverage for a new schema shape, add a case here and
+// run the tests once with -update to record its expected rendering.
+func TestAddResource(t *testing.T) {
+	cases := map[string]struct {
+		schema   *configschema.Block
+		state    cty.Value
+		optional bool
+	}{
+		"config-only": {
+			schema:   addTestSchemaSensitive(configschema.NestingSingle),
+			state:    cty.NilVal,
+			optional: true,
+		},
+		"from-state": {
+			schema: addTestSchemaSensitive(configschema.NestingSingle),
+			state: cty.ObjectVal(map[string]cty.Value{
+				"ami": cty.StringVal("ami-123456789"),
+				"disks": cty.ObjectVal(map[string]cty.Value{
+					"mount_point": cty.StringVal("/mnt/foo"),
+					"size":        cty.StringVal("50GB"),
+				}),
+			}),
+			optional: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RenderResourceForTesting(t, "test_instance.foo",
+				addrs.NewDefaultLocalProviderConfig("mytest"),
+				tc.schema, tc.state, tc.optional)
+			assertGoldenMatch(t, filepath.Join("testdata", "add", name+".golden"), got)
+		})
+	}
+}
+
+// assertGoldenMatch compares the rendered output against the golden file at
+// the given path, rewriting the file instead when -update was given.
+func assertGoldenMatch(t *testing.T, path, got string) {
+	t.Helper()
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("failed to update golden file %s: %s", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file %s (run the tests with -update to create it): %s", path, err)
+	}
+	if diff := cmp.Diff(string(want), got); diff != "" {
+		t.Errorf("wrong result for %s (run the tests with -update if the change is intended):\n%s", path, diff)
+	}
+}
+
+// The JSON outputs are consumed by diff-based tooling, so their key order
+// must not change between runs: the view sorts attribute names itself, and
+// encoding/json writes map keys in sorted order. These golden tests lock the
+// exact bytes in, and each output is rendered twice to catch any ordering
+// that only holds by accident of map iteration.
+func TestAddJSON_deterministic(t *testing.T) {
+	t.Run("inputs-json", func(t *testing.T) {
+		schema := addTestSchema(configschema.NestingSingle)
+		render := func(t *testing.T) string {
+			streams, done := terminal.StreamsForTesting(t)
+			v := addHuman{view: NewView(streams)}
+			if err := v.InputsJSON(schema); err != nil {
+				t.Fatal(err.Error())
+			}
+			return done(t).Stdout()
+		}
+		first := render(t)
+		assertGoldenMatch(t, filepath.Join("testdata", "add", "inputs-json.golden"), first)
+		if second := render(t); second != first {
+			t.Errorf("output differs between runs:\n%s", cmp.Diff(first, second))
+		}
+	})
+
+	t.Run("audit-json", func(t *testing.T) {
+		results := []AuditResult{
+			{
+				Addr: addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_instance",
+					Name: "one",
+				}.InModule(addrs.RootModule),
+				MissingRequired: []string{"ami", "value"},
+				UnsetOptional:   []string{"tags"},
+			},
+			{
+				Addr: addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_instance",
+					Name: "two",
+				}.InModule(addrs.RootModule),
+			},
+		}
+		render := func(t *testing.T) string {
+			streams, done := terminal.StreamsForTesting(t)
+			v := addHuman{view: NewView(streams)}
+			if err := v.Audit(results, "json"); err != nil {
+				t.Fatal(err.Error())
+			}
+			return done(t).Stdout()
+		}
+		first := render(t)
+		assertGoldenMatch(t, filepath.Join("testdata", "add", "audit-json.golden"), first)
+		if second := render(t); second != first {
+			t.Errorf("output differs between runs:\n%s", cmp.Diff(first, second))
+		}
+	})
+}
+
+func TestAddResource_splitFiles(t *testing.T) {
+	streams, _ := terminal.StreamsForTesting(t)
+	outDir := t.TempDir()
+	v := addHuman{view: NewView(streams), outDir: outDir, splitBy: "type"}
+
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+		},
+	}
+
+	for _, addr := range []string{"aws_s3_bucket.one", "aws_s3_bucket.two", "aws_iam_role.roly"} {
+		err := v.Resource(
+			mustResourceInstanceAddr(addr),
+			schema,
+			addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal,
+		)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	s3, err := os.ReadFile(filepath.Join(outDir, "s3.tf"))
+	if err != nil {
+		t.Fatalf("missing expected s3.tf: %s", err)
+	}
+	for _, want := range []string{
+		splitFileHeader,
+		"# index: aws_s3_bucket.one",
+		"# index: aws_s3_bucket.two",
+		`resource "aws_s3_bucket" "one" {`,
+		`resource "aws_s3_bucket" "two" {`,
+	} {
+		if !strings.Contains(string(s3), want) {
+			t.Errorf("s3.tf missing %q:\n%s", want, s3)
+		}
+	}
+
+	iam, err := os.ReadFile(filepath.Join(outDir, "iam.tf"))
+	if err != nil {
+		t.Fatalf("missing expected iam.tf: %s", err)
+	}
+	if !strings.Contains(string(iam), `resource "aws_iam_role" "roly" {`) {
+		t.Errorf("iam.tf missing generated block:\n%s", iam)
+	}
+}
+
+func TestAdd_setOutput(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_s3_bucket.example")
+	pc := addrs.NewDefaultLocalProviderConfig("aws")
+
+	streams, done := terminal.StreamsForTesting(t)
+	v := addHuman{view: NewView(streams), skipExperimentNote: true}
+
+	var stdout, stderr strings.Builder
+	v.SetOutput(&stdout, &stderr)
+
+	if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+		t.Fatal(err.Error())
+	}
+	output := done(t)
+
+	if !strings.Contains(stdout.String(), `resource "aws_s3_bucket" "example" {`) {
+		t.Errorf("missing generated block in the injected writer:\n%s", stdout.String())
+	}
+	if output.Stdout() != "" || output.Stderr() != "" {
+		t.Errorf("output leaked to the terminal streams despite SetOutput:\nstdout: %q\nstderr: %q", output.Stdout(), output.Stderr())
+	}
+	// Redirected output is never a terminal, so the interactive next-step
+	// hints stay suppressed even though quiet wasn't set.
+	if strings.Contains(stderr.String(), "Next steps:") {
+		t.Errorf("interactive hints written to redirected stderr:\n%s", stderr.String())
+	}
+}
+
+func TestAddResource_fileHeader(t *testing.T) {
+	const header = "# Copyright Example Corp.\n# See CODEOWNERS.\n"
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_s3_bucket.example")
+	pc := addrs.NewDefaultLocalProviderConfig("aws")
+
+	t.Run("new -out file", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		v := addHuman{view: NewView(streams), outPath: outPath, quiet: true, newline: "auto", fileHeader: header}
+
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		result, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		if !strings.HasPrefix(string(result), "# Copyright Example Corp.\n# See CODEOWNERS.\n\n") {
+			t.Errorf("result does not open with the header and a blank line:\n%s", result)
+		}
+	})
+
+	t.Run("existing -out file is appended to unchanged", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		if err := os.WriteFile(outPath, []byte("# existing\n"), 0600); err != nil {
+			t.Fatal(err.Error())
+		}
+		v := addHuman{view: NewView(streams), outPath: outPath, quiet: true, newline: "auto", fileHeader: header}
+
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		result, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		if !strings.HasPrefix(string(result), "# existing\n") {
+			t.Errorf("existing contents were disturbed:\n%s", result)
+		}
+		if strings.Contains(string(result), "Copyright") {
+			t.Errorf("header was added to a pre-existing file:\n%s", result)
+		}
+	})
+
+	t.Run("split files keep their index below the header", func(t *testing.T) {
+		streams, _ := terminal.StreamsForTesting(t)
+		outDir := t.TempDir()
+		v := addHuman{view: NewView(streams), outDir: outDir, splitBy: "type", quiet: true, fileHeader: header}
+
+		for _, a := range []string{"aws_s3_bucket.one", "aws_s3_bucket.two"} {
+			if err := v.Resource(mustResourceInstanceAddr(a), schema, pc, cty.NilVal); err != nil {
+				t.Fatal(err.Error())
+			}
+		}
+
+		result, err := os.ReadFile(filepath.Join(outDir, "s3.tf"))
+		if err != nil {
+			t.Fatalf("missing expected s3.tf: %s", err)
+		}
+		if !strings.HasPrefix(string(result), "# Copyright Example Corp.\n# See CODEOWNERS.\n\n"+splitFileHeader+"\n") {
+			t.Errorf("s3.tf does not open with the header followed by the maintained header:\n%s", result)
+		}
+		for _, want := range []string{
+			"# index: aws_s3_bucket.one",
+			"# index: aws_s3_bucket.two",
+			`resource "aws_s3_bucket" "one" {`,
+			`resource "aws_s3_bucket" "two" {`,
+		} {
+			if !strings.Contains(string(result), want) {
+				t.Errorf("s3.tf missing %q:\n%s", want, result)
+			}
+		}
+		if count := strings.Count(string(result), "Copyright"); count != 1 {
+			t.Errorf("header appears %d times; want once:\n%s", count, result)
+		}
+	})
+}
+
+func TestAdd_attrsAsBlocks(t *testing.T) {
+	// Legacy SDK providers declare some lists of objects as attributes but
+	// require them to be written in block syntax; see lang/blocktoattr.
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+			"rule": {
+				Type: cty.List(cty.Object(map[string]cty.Type{
+					"action": cty.String,
+					"port":   cty.Number,
+				})),
+				Required: true,
+			},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_s3_bucket.example")
+
+	t.Run("placeholder", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		stdout := done(t).Stdout()
+		want := `  rule {          # REQUIRED block
+    action = null # OPTIONAL string
+    port   = null # OPTIONAL number
+  }`
+		if !strings.Contains(stdout, want) {
+			t.Errorf("missing block syntax for attrs-as-blocks attribute\ngot:\n%s\nwant substring:\n%s", stdout, want)
+		}
+		if strings.Contains(stdout, "rule = ") {
+			t.Errorf("attrs-as-blocks attribute rendered in attribute syntax:\n%s", stdout)
+		}
+	})
+
+	t.Run("from state", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+		stateVal := cty.ObjectVal(map[string]cty.Value{
+			"bucket": cty.StringVal("my-bucket"),
+			"rule": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"action": cty.StringVal("allow"),
+					"port":   cty.NumberIntVal(443),
+				}),
+				cty.ObjectVal(map[string]cty.Value{
+					"action": cty.StringVal("deny"),
+					"port":   cty.NumberIntVal(80),
+				}),
+			}),
+		})
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		stdout := done(t).Stdout()
+		for _, want := range []string{
+			"rule {\n    action = \"allow\"\n    port   = 443\n  }",
+			"rule {\n    action = \"deny\"\n    port   = 80\n  }",
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("missing block for list element\ngot:\n%s\nwant substring:\n%s", stdout, want)
+			}
+		}
+		if strings.Contains(stdout, "rule = ") {
+			t.Errorf("attrs-as-blocks attribute rendered in attribute syntax:\n%s", stdout)
+		}
+	})
+}
+
+func TestAddResources_ordering(t *testing.T) {
+	// Rendering happens concurrently across a worker pool, but the emitted
+	// output must keep the order the resources were given in.
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+		},
+	}
+
+	var rs []ResourceToGenerate
+	for i := 0; i < 50; i++ {
+		rs = append(rs, ResourceToGenerate{
+			Addr:     mustResourceInstanceAddr(fmt.Sprintf("aws_s3_bucket.b%03d", i)),
+			Schema:   schema,
+			Provider: addrs.NewDefaultLocalProviderConfig("aws"),
+			State:    cty.NilVal,
+		})
+	}
+
+	streams, done := terminal.StreamsForTesting(t)
+	v := addHuman{view: NewView(streams), quiet: true}
+	if err := v.Resources(rs); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	stdout := done(t).Stdout()
+	last := -1
+	for _, r := range rs {
+		idx := strings.Index(stdout, fmt.Sprintf("resource \"aws_s3_bucket\" %q {", r.Addr.Resource.Resource.Name))
+		if idx < 0 {
+			t.Fatalf("missing block for %s in output", r.Addr)
+		}
+		if idx < last {
+			t.Fatalf("block for %s emitted out of order", r.Addr)
+		}
+		last = idx
+	}
+}
+
+func TestAddResources_checkpoint(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+		},
+	}
+	rs := []ResourceToGenerate{
+		{
+			Addr:     mustResourceInstanceAddr("aws_s3_bucket.first"),
+			Schema:   schema,
+			Provider: addrs.NewDefaultLocalProviderConfig("aws"),
+			State:    cty.NilVal,
+		},
+		{
+			Addr:     mustResourceInstanceAddr("aws_s3_bucket.second"),
+			Schema:   schema,
+			Provider: addrs.NewDefaultLocalProviderConfig("aws"),
+			State:    cty.NilVal,
+		},
+	}
+
+	t.Run("skips recorded addresses and clears the file", func(t *testing.T) {
+		dir := t.TempDir()
+		outPath := filepath.Join(dir, "generated.tf")
+		ckPath := filepath.Join(dir, "add.checkpoint")
+
+		// An interrupted earlier run already wrote the first block.
+		if err := os.WriteFile(ckPath, []byte("aws_s3_bucket.first\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true, outPath: outPath, checkpointPath: ckPath}
+		if err := v.Resources(rs); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		written, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(written), `"first"`) {
+			t.Errorf("block recorded in the checkpoint was written again:\n%s", written)
+		}
+		if !strings.Contains(string(written), `"second"`) {
+			t.Errorf("missing block for the unrecorded address:\n%s", written)
+		}
+		if _, err := os.Stat(ckPath); !os.IsNotExist(err) {
+			t.Error("checkpoint file should be removed after a completed run")
+		}
+	})
+
+	t.Run("records each written block", func(t *testing.T) {
+		dir := t.TempDir()
+		outPath := filepath.Join(dir, "generated.tf")
+		ckPath := filepath.Join(dir, "add.checkpoint")
+
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true, outPath: outPath, checkpointPath: ckPath}
+		if err := v.appendCheckpoint(rs[0].Addr); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		recorded, err := os.ReadFile(ckPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(recorded) != "aws_s3_bucket.first\n" {
+			t.Errorf("wrong checkpoint contents: %q", recorded)
+		}
+	})
+}
+
+func TestAddResource_newline(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_s3_bucket.example")
+
+	t.Run("crlf", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		v := addHuman{view: NewView(streams), outPath: outPath, quiet: true, newline: "crlf"}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		result, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		if !strings.Contains(string(result), "\r\n") {
+			t.Errorf("expected CRLF line endings in result:\n%q", result)
+		}
+		if strings.Contains(strings.ReplaceAll(string(result), "\r\n", ""), "\n") {
+			t.Errorf("found bare LF line endings in result:\n%q", result)
+		}
+	})
+
+	t.Run("auto matches existing file", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		if err := os.WriteFile(outPath, []byte("# existing\r\n"), 0600); err != nil {
+			t.Fatal(err.Error())
+		}
+		v := addHuman{view: NewView(streams), outPath: outPath, quiet: true, newline: "auto"}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		result, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		if !strings.Contains(string(result), "resource \"aws_s3_bucket\" \"example\" {\r\n") {
+			t.Errorf("expected appended block to use the existing CRLF line endings:\n%q", result)
+		}
+	})
+}
+
+func TestAddResource_position(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_s3_bucket.example")
+
+	t.Run("human", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		if err := os.WriteFile(outPath, []byte("# one\n# two\n"), 0600); err != nil {
+			t.Fatal(err.Error())
+		}
+		v := addHuman{view: NewView(streams), outPath: outPath, quiet: true, newline: "auto", position: "human"}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t)
+
+		result, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		lastLine := strings.Count(string(result), "\n")
+		want := fmt.Sprintf("aws_s3_bucket.example: inserted into %s, lines 3-%d.", outPath, lastLine)
+		if !strings.Contains(output.Stderr(), want) {
+			t.Errorf("missing %q in stderr:\n%s", want, output.Stderr())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		v := addHuman{view: NewView(streams), outPath: outPath, quiet: true, newline: "auto", position: "json"}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t)
+
+		result, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+
+		var pos struct {
+			Address   string `json:"address"`
+			Filename  string `json:"filename"`
+			StartLine int    `json:"start_line"`
+			EndLine   int    `json:"end_line"`
+		}
+		if err := json.Unmarshal([]byte(output.Stdout()), &pos); err != nil {
+			t.Fatalf("stdout is not a JSON position report: %s\n%s", err, output.Stdout())
+		}
+		if pos.Address != "aws_s3_bucket.example" {
+			t.Errorf("wrong address %q", pos.Address)
+		}
+		if pos.Filename != outPath {
+			t.Errorf("wrong filename %q; want %q", pos.Filename, outPath)
+		}
+		if pos.StartLine != 1 {
+			t.Errorf("wrong start_line %d; want 1 for a new file", pos.StartLine)
+		}
+		if want := strings.Count(string(result), "\n"); pos.EndLine != want {
+			t.Errorf("wrong end_line %d; want %d", pos.EndLine, want)
+		}
+	})
+}
+
+func TestAddResource_insertAfter(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_s3_bucket.example")
+	existing := `resource "aws_s3_bucket" "first" {
+  bucket = "first"
+}
+
+resource "aws_s3_bucket" "last" {
+  bucket = "last"
+}
+`
+
+	t.Run("between existing blocks", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		if err := os.WriteFile(outPath, []byte(existing), 0600); err != nil {
+			t.Fatal(err.Error())
+		}
+		v := addHuman{view: NewView(streams), outPath: outPath, quiet: true, newline: "auto", insertAfter: `resource "aws_s3_bucket" "first"`}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		result, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		first := strings.Index(string(result), `"first"`)
+		example := strings.Index(string(result), `"example"`)
+		last := strings.Index(string(result), `"last"`)
+		if first < 0 || example < 0 || last < 0 {
+			t.Fatalf("result is missing a block:\n%s", result)
+		}
+		if !(first < example && example < last) {
+			t.Errorf("block was not inserted between the anchor and the following block:\n%s", result)
+		}
+	})
+
+	t.Run("after the final block", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		if err := os.WriteFile(outPath, []byte(existing), 0600); err != nil {
+			t.Fatal(err.Error())
+		}
+		v := addHuman{view: NewView(streams), outPath: outPath, quiet: true, newline: "auto", insertAfter: `resource "aws_s3_bucket" "last"`}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		result, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		if last, example := strings.Index(string(result), `"last"`), strings.Index(string(result), `"example"`); !(last < example) {
+			t.Errorf("block was not inserted after the final block:\n%s", result)
+		}
+		if strings.HasSuffix(string(result), "\n\n") {
+			t.Errorf("result ends with a dangling blank line:\n%s", result)
+		}
+	})
+
+	t.Run("anchor not found", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		if err := os.WriteFile(outPath, []byte(existing), 0600); err != nil {
+			t.Fatal(err.Error())
+		}
+		v := addHuman{view: NewView(streams), outPath: outPath, quiet: true, newline: "auto", insertAfter: `resource "aws_s3_bucket" "missing"`}
+
+		err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal)
+		if err == nil {
+			t.Fatal("expected an error for a missing anchor block")
+		}
+		if !strings.Contains(err.Error(), "named with -after") {
+			t.Errorf("unexpected error message: %s", err)
+		}
+		done(t)
+
+		result, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		if string(result) != existing {
+			t.Errorf("file was modified despite the error:\n%s", result)
+		}
+	})
+}
+
+func TestAddResource_explain(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+			"acl":    {Type: cty.String, Optional: true},
+			"id":     {Type: cty.String, Computed: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"versioning": {
+				Nesting:  configschema.NestingList,
+				MinItems: 1,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"enabled": {Type: cty.Bool, Required: true},
+					},
+				},
+			},
+			"website": {
+				Nesting: configschema.NestingSingle,
+				Block:   configschema.Block{},
+			},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_s3_bucket.example")
+	pc := addrs.NewDefaultLocalProviderConfig("aws")
+
+	t.Run("schema-driven generation", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true, explain: true}
+
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t)
+
+		for _, want := range []string{
+			"aws_s3_bucket.example: why each emitted item appears:",
+			"bucket     - required by the schema",
+			"versioning - block required by the schema (min_items 1)",
+		} {
+			if !strings.Contains(output.Stderr(), want) {
+				t.Errorf("missing %q in stderr:\n%s", want, output.Stderr())
+			}
+		}
+		// Attributes and blocks the renderer skipped get no explanation.
+		for _, unwanted := range []string{"acl", "id", "website"} {
+			if strings.Contains(output.Stderr(), unwanted) {
+				t.Errorf("unexpected %q in stderr:\n%s", unwanted, output.Stderr())
+			}
+		}
+		if strings.Contains(output.Stdout(), "why each emitted item appears") {
+			t.Errorf("explanation leaked into stdout:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("with -optional", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true, explain: true, optional: true}
+
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t)
+
+		for _, want := range []string{
+			"acl        - requested with -optional",
+			"website    - block requested with -optional",
+		} {
+			if !strings.Contains(output.Stderr(), want) {
+				t.Errorf("missing %q in stderr:\n%s", want, output.Stderr())
+			}
+		}
+	})
+
+	t.Run("from state", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true, explain: true}
+
+		stateVal := cty.ObjectVal(map[string]cty.Value{
+			"bucket": cty.StringVal("my-bucket"),
+			"acl":    cty.StringVal("private"),
+			"id":     cty.StringVal("my-bucket"),
+			"versioning": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{"enabled": cty.True}),
+			}),
+			"website": cty.NullVal(cty.EmptyObject),
+		})
+
+		if err := v.Resource(addr, schema, pc, stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t)
+
+		for _, want := range []string{
+			"bucket     - required by the schema; value populated from state",
+			"acl        - value populated from state",
+			"versioning - block populated from state",
+		} {
+			if !strings.Contains(output.Stderr(), want) {
+				t.Errorf("missing %q in stderr:\n%s", want, output.Stderr())
+			}
+		}
+		// Computed-only attributes are never arguments.
+		if strings.Contains(output.Stderr(), "id ") {
+			t.Errorf("unexpected computed-only attribute in stderr:\n%s", output.Stderr())
+		}
+	})
+}
+
+func TestAddResource_maxLineWidth(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"subnet_ids": {Type: cty.List(cty.String), Required: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_instance.example")
+	stateVal := cty.ObjectVal(map[string]cty.Value{
+		"subnet_ids": cty.ListVal([]cty.Value{
+			cty.StringVal("subnet-0123456789abcdef0"),
+			cty.StringVal("subnet-0123456789abcdef1"),
+			cty.StringVal("subnet-0123456789abcdef2"),
+		}),
+	})
+
+	t.Run("long list is broken across lines", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, maxLineWidth: 40}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		want := `resource "aws_instance" "example" {
+  subnet_ids = [
+    "subnet-0123456789abcdef0",
+    "subnet-0123456789abcdef1",
+    "subnet-0123456789abcdef2"
+  ]
+}
+`
+		if !strings.Contains(output, want) {
+			t.Fatalf("wrong output:\ngot:\n%s\nwant to contain:\n%s", output, want)
+		}
+	})
+
+	t.Run("short lines are left alone", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, maxLineWidth: 120}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if !strings.Contains(output, `subnet_ids = ["subnet-0123456789abcdef0", "subnet-0123456789abcdef1", "subnet-0123456789abcdef2"]`) {
+			t.Fatalf("wrong output:\n%s", output)
+		}
+	})
+}
+
+func TestAddResource_prettyCollections(t *testing.T) {
+	// Collections with structural elements are spread across lines, one
+	// element per line, while collections of primitives keep the single-line
+	// TokensForValue form.
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"rules": {Type: cty.Set(cty.Map(cty.String)), Optional: true},
+			"names": {Type: cty.Set(cty.String), Optional: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_security_group.example")
+	stateVal := cty.ObjectVal(map[string]cty.Value{
+		"rules": cty.SetVal([]cty.Value{
+			cty.MapVal(map[string]cty.Value{
+				"action": cty.StringVal("allow"),
+				"port":   cty.StringVal("443"),
+			}),
+			cty.MapVal(map[string]cty.Value{
+				"action": cty.StringVal("deny"),
+				"port":   cty.StringVal("80"),
+			}),
+		}),
+		"names": cty.SetVal([]cty.Value{
+			cty.StringVal("a"),
+			cty.StringVal("b"),
+		}),
+	})
+
+	streams, done := terminal.StreamsForTesting(t)
+	v := addHuman{view: NewView(streams), quiet: true}
+
+	if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), stateVal); err != nil {
+		t.Fatal(err.Error())
+	}
+	output := done(t).Stdout()
+
+	if !strings.Contains(output, "rules = [\n") {
+		t.Errorf("set of maps not spread across lines:\n%s", output)
+	}
+	for _, want := range []string{
+		"{\n      action = \"allow\"\n      port   = \"443\"\n    },",
+		"{\n      action = \"deny\"\n      port   = \"80\"\n    },",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing element\ngot:\n%s\nwant substring:\n%s", output, want)
+		}
+	}
+	if !strings.Contains(output, `names = ["a", "b"]`) {
+		t.Errorf("set of primitives should stay on one line:\n%s", output)
+	}
+}
+
+func TestAddResource_summary(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"value": {Type: cty.String, Required: true},
+		},
+	}
+	outPath := filepath.Join(t.TempDir(), "generated.tf")
+
+	streams, done := terminal.StreamsForTesting(t)
+	v := addHuman{view: NewView(streams), outPath: outPath, skipExperimentNote: true}
+	pc := addrs.NewDefaultLocalProviderConfig("test")
+	for _, name := range []string{"one", "two"} {
+		if err := v.Resource(mustResourceInstanceAddr("test_instance."+name), schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	v.Summary(1)
+
+	stderr := done(t).Stderr()
+	want := "resource blocks generated: 2, files written: 1, warnings: 1."
+	if !strings.Contains(stderr, want) {
+		t.Errorf("missing %q in stderr:\n%s", want, stderr)
+	}
+}
+
+func TestAddResource_attrProvenance(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"value": {Type: cty.String, Required: true},
+			"name":  {Type: cty.String, Required: true},
+			"ami":   {Type: cty.String, Optional: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("test_instance.new")
+	pc := addrs.NewDefaultLocalProviderConfig("test")
+
+	// "name" is deliberately missing from the state object and "ami" is null
+	// in it, so all three provenance annotations appear.
+	stateVal := cty.ObjectVal(map[string]cty.Value{
+		"value": cty.StringVal("foo"),
+		"ami":   cty.NullVal(cty.String),
+	})
+
+	streams, done := terminal.StreamsForTesting(t)
+	v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true, attrProvenance: true}
+
+	if err := v.Resource(addr, schema, pc, stateVal); err != nil {
+		t.Fatal(err.Error())
+	}
+	output := done(t).Stdout()
+
+	for _, want := range []string{
+		`value = "foo" # from state`,
+		"name  = null  # REQUIRED - please set",
+		"ami   = null  # default",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestAddResource_importIdentity(t *testing.T) {
+	addr := mustResourceInstanceAddr("test_instance.new")
+	pc := addrs.NewDefaultLocalProviderConfig("test")
+
+	t.Run("placeholders", func(t *testing.T) {
+		schema := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"id":    {Type: cty.String, Optional: true, Computed: true},
+				"name":  {Type: cty.String, Required: true},
+				"value": {Type: cty.String, Required: true},
+			},
+		}
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), optional: true, quiet: true, skipExperimentNote: true, importIdentity: true}
+
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		for _, want := range []string{
+			"# OPTIONAL/COMPUTED string (import identity: must match the real object)",
+			"# REQUIRED string (import identity: must match the real object)",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("missing %q in output:\n%s", want, output)
+			}
+		}
+		if strings.Contains(output, `value = null # REQUIRED string (import identity`) {
+			t.Errorf("value should not be marked as import identity:\n%s", output)
+		}
+	})
+
+	t.Run("from state", func(t *testing.T) {
+		schema := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"name":  {Type: cty.String, Required: true},
+				"value": {Type: cty.String, Required: true},
+			},
+		}
+		stateVal := cty.ObjectVal(map[string]cty.Value{
+			"name":  cty.StringVal("web"),
+			"value": cty.StringVal("foo"),
+		})
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true, importIdentity: true}
+
+		if err := v.Resource(addr, schema, pc, stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if want := `name  = "web" # import identity: must match the real object`; !strings.Contains(output, want) {
+			t.Errorf("missing %q in output:\n%s", want, output)
+		}
+	})
+
+	t.Run("combined with attribute provenance", func(t *testing.T) {
+		schema := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"name":  {Type: cty.String, Required: true},
+				"value": {Type: cty.String, Required: true},
+			},
+		}
+		stateVal := cty.ObjectVal(map[string]cty.Value{
+			"name":  cty.StringVal("web"),
+			"value": cty.StringVal("foo"),
+		})
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true, importIdentity: true, attrProvenance: true}
+
+		if err := v.Resource(addr, schema, pc, stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		for _, want := range []string{
+			`name  = "web" # from state (import identity)`,
+			`value = "foo" # from state`,
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("missing %q in output:\n%s", want, output)
+			}
+		}
+	})
+}
+
+func TestAddResource_importSuggestion(t *testing.T) {
+	addr := mustResourceInstanceAddr("test_instance.new")
+	pc := addrs.NewDefaultLocalProviderConfig("test")
+
+	t.Run("documented import ID", func(t *testing.T) {
+		schema := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"id":    {Type: cty.String, Optional: true, Computed: true, Description: "the instance identifier, e.g. i-0123456789abcdef0"},
+				"value": {Type: cty.String, Required: true},
+			},
+		}
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true}
+
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		for _, want := range []string{
+			`# test_instance supports "terraform import"`,
+			"#   terraform import 'test_instance.new' ID",
+			"# where ID is: the instance identifier, e.g. i-0123456789abcdef0",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("missing %q in output:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("undocumented id attribute", func(t *testing.T) {
+		schema := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"id":    {Type: cty.String, Optional: true, Computed: true},
+				"value": {Type: cty.String, Required: true},
+			},
+		}
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true}
+
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if strings.Contains(output, "terraform import") {
+			t.Errorf("unexpected import suggestion without a documented ID format:\n%s", output)
+		}
+	})
+
+	t.Run("suppressed when populating from state", func(t *testing.T) {
+		schema := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"id":    {Type: cty.String, Optional: true, Computed: true, Description: "the instance identifier"},
+				"value": {Type: cty.String, Required: true},
+			},
+		}
+		stateVal := cty.ObjectVal(map[string]cty.Value{
+			"id":    cty.StringVal("i-123"),
+			"value": cty.StringVal("foo"),
+		})
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true}
+
+		if err := v.Resource(addr, schema, pc, stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if strings.Contains(output, "terraform import") {
+			t.Errorf("unexpected import suggestion for a block populated from state:\n%s", output)
+		}
+	})
+}
+
+func TestAddResource_migration(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id":    {Type: cty.String, Optional: true, Computed: true, Description: "the instance identifier"},
+			"value": {Type: cty.String, Required: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("test_instance.new")
+	pc := addrs.NewDefaultLocalProviderConfig("test")
+
+	t.Run("import only", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), optional: true, quiet: true, skipExperimentNote: true, migration: true}
+
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		for _, want := range []string{
+			"# Migration steps for test_instance.new:",
+			"# 1. Import the existing object into this address:",
+			"#      terraform import 'test_instance.new' ID",
+			"# 2. Fill in the values above to match the real object, then run",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("missing %q in output:\n%s", want, output)
+			}
+		}
+		// The runbook subsumes the plain import suggestion.
+		if strings.Contains(output, `supports "terraform import"`) {
+			t.Errorf("unexpected standalone import suggestion alongside the runbook:\n%s", output)
+		}
+	})
+
+	t.Run("with -moved-from", func(t *testing.T) {
+		movedFrom := mustResourceInstanceAddr("test_instance.old")
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), optional: true, quiet: true, skipExperimentNote: true, migration: true, movedFrom: &movedFrom}
+
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		for _, want := range []string{
+			"# 1. Move the existing state entry to this address:",
+			"#      terraform state mv 'test_instance.old' 'test_instance.new'",
+			"# 2. Or, if the object is not under management yet, import it:",
+			"#      terraform import 'test_instance.new' ID",
+			"# 3. Fill in the values above to match the real object, then run",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("missing %q in output:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("from state", func(t *testing.T) {
+		stateVal := cty.ObjectVal(map[string]cty.Value{
+			"id":    cty.StringVal("i-123"),
+			"value": cty.StringVal("foo"),
+		})
+		movedFrom := mustResourceInstanceAddr("test_instance.old")
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true, migration: true, movedFrom: &movedFrom}
+
+		if err := v.Resource(addr, schema, pc, stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if want := "#      terraform state mv 'test_instance.old' 'test_instance.new'"; !strings.Contains(output, want) {
+			t.Errorf("missing %q in output:\n%s", want, output)
+		}
+	})
+}
+
+func TestAddResource_infer(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"region": {Type: cty.String, Required: true},
+			"value":  {Type: cty.String, Required: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("test_instance.new")
+	pc := addrs.NewDefaultLocalProviderConfig("test")
+
+	t.Run("suggests matching environment values", func(t *testing.T) {
+		t.Setenv("AWS_REGION", "eu-west-1")
+
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true, inferred: inferredSuggestions()}
+
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		want := `region = null # REQUIRED string (suggested: "eu-west-1" from $AWS_REGION)`
+		if !strings.Contains(output, want) {
+			t.Errorf("missing suggestion\ngot:\n%s\nwant substring:\n%s", output, want)
+		}
+		if strings.Contains(output, `value = "eu-west-1"`) || strings.Contains(output, `region = "eu-west-1"`) {
+			t.Errorf("inferred value must stay a comment, not become the attribute value:\n%s", output)
+		}
+	})
+
+	t.Run("later variables in a rule are fallbacks", func(t *testing.T) {
+		t.Setenv("AWS_REGION", "")
+		t.Setenv("AWS_DEFAULT_REGION", "us-east-2")
+
+		suggestions := inferredSuggestions()
+		want := `"us-east-2" from $AWS_DEFAULT_REGION`
+		if got := suggestions["region"]; got != want {
+			t.Errorf("wrong suggestion for region: got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("no suggestions without -infer", func(t *testing.T) {
+		t.Setenv("AWS_REGION", "eu-west-1")
+
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true}
+
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if strings.Contains(output, "suggested:") {
+			t.Errorf("unexpected suggestion without -infer:\n%s", output)
+		}
+	})
+}
+
+func TestAddResource_placeholderFormat(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"value": {Type: cty.String, Required: true},
+			"count": {Type: cty.Number, Optional: true},
+		},
+	}
+	streams, done := terminal.StreamsForTesting(t)
+	v := addHuman{
+		view:               NewView(streams),
+		optional:           true,
+		quiet:              true,
+		skipExperimentNote: true,
+		placeholderFormat:  "{{ %s }}",
+	}
+	if err := v.Resource(mustResourceInstanceAddr("test_instance.example"), schema, addrs.NewDefaultLocalProviderConfig("test"), cty.NilVal); err != nil {
+		t.Fatal(err.Error())
+	}
+	output := done(t).Stdout()
+
+	// Tokens are strings regardless of the attribute type; the template
+	// engine replaces them before the configuration is parsed.
+	for _, want := range []string{
+		`value = "{{ value }}" # REQUIRED string`,
+		`count = "{{ count }}" # OPTIONAL number`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestAddResource_emptySchema(t *testing.T) {
+	// Some resource types (aws_default_vpc, for example) require nothing at
+	// all; the generated block must explain its own emptiness rather than
+	// printing a bare pair of braces.
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id":  {Type: cty.String, Computed: true},
+			"ami": {Type: cty.String, Optional: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("test_instance.example")
+	pc := addrs.NewDefaultLocalProviderConfig("test")
+
+	t.Run("default", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true}
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+		expected := `resource "test_instance" "example" {
+  # (no required attributes)
+}
+`
+		if !cmp.Equal(output, expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output))
+		}
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), optional: true, quiet: true, skipExperimentNote: true}
+		if err := v.Resource(addr, schema, pc, cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+		if !strings.Contains(output, "ami = null # OPTIONAL string") {
+			t.Errorf("missing optional attribute in output:\n%s", output)
+		}
+		if strings.Contains(output, "(no required attributes)") {
+			t.Errorf("empty-body comment shown despite non-empty body:\n%s", output)
+		}
+	})
+}
+
+func TestAddResource_forEach(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id":   {Type: cty.String, Optional: true, Computed: true},
+			"ami":  {Type: cty.String, Optional: true},
+			"name": {Type: cty.String, Required: true},
+		},
+	}
+	insts := map[string]cty.Value{
+		"one": cty.ObjectVal(map[string]cty.Value{
+			"id":   cty.StringVal("i-1"),
+			"ami":  cty.StringVal("ami-123"),
+			"name": cty.StringVal("web-one"),
+		}),
+		"two": cty.ObjectVal(map[string]cty.Value{
+			"id":   cty.StringVal("i-2"),
+			"ami":  cty.StringVal("ami-123"),
+			"name": cty.StringVal("web-two"),
+		}),
+	}
+
+	streams, done := terminal.StreamsForTesting(t)
+	v := addHuman{view: NewView(streams), quiet: true}
+
+	addr := mustResourceInstanceAddr("test_instance.web").ContainingResource()
+	if err := v.ResourceForEach(addr, schema, addrs.NewDefaultLocalProviderConfig("test"), insts); err != nil {
+		t.Fatal(err.Error())
+	}
+	output := done(t).Stdout()
+
+	for _, want := range []string{
+		"for_each = local.test_instance_web_instances",
+		"each.value.name",
+		`ami  = "ami-123"`,
+		"test_instance_web_instances = {",
+		`name = "web-one"`,
+		`name = "web-two"`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing %q in output:\n%s", want, output)
+		}
+	}
+	// The shared ami value belongs in the block, not the per-instance map,
+	// and optional+computed attributes stay out of both by default.
+	if strings.Contains(output, "each.value.ami") {
+		t.Errorf("common attribute went through the instance map:\n%s", output)
+	}
+	if strings.Contains(output, `"i-1"`) {
+		t.Errorf("optional+computed attribute survived in output:\n%s", output)
+	}
+	if got, want := strings.Count(output, `resource "test_instance" "web"`), 1; got != want {
+		t.Errorf("wrong number of resource blocks: got %d, want %d\n%s", got, want, output)
+	}
+}
+
+func TestAddResource_noComments(t *testing.T) {
+	schema := &configschema.Block{
+		Description: "Manages a single test instance.",
+		Attributes: map[string]*configschema.Attribute{
+			"ami":   {Type: cty.String, Required: true},
+			"value": {Type: cty.String, Required: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"network": {
+				Nesting:  configschema.NestingSingle,
+				MinItems: 1,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"subnet_id": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	streams, done := terminal.StreamsForTesting(t)
+	v := addHuman{view: NewView(streams), quiet: true, noComments: true, skipExperimentNote: true}
+
+	if err := v.Resource(mustResourceInstanceAddr("test_instance.example"), schema, addrs.NewDefaultLocalProviderConfig("test"), cty.NilVal); err != nil {
+		t.Fatal(err.Error())
+	}
+	output := done(t).Stdout()
+
+	expected := `resource "test_instance" "example" {
+  ami   = null
+  value = null
+  network {
+    subnet_id = null
+  }
+}
+`
+	if !cmp.Equal(output, expected) {
+		t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output))
+	}
+	if strings.Contains(output, "#") {
+		t.Fatalf("comment survived -no-comments output:\n%s", output)
+	}
+}
+
+func TestAddResource_commentStyle(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"ami":   {Type: cty.String, Required: true},
+			"value": {Type: cty.String, Required: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"network": {
+				Nesting:  configschema.NestingSingle,
+				MinItems: 1,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"subnet_id": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("slash", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, commentStyle: "slash", skipExperimentNote: true}
+
+		if err := v.Resource(mustResourceInstanceAddr("test_instance.example"), schema, addrs.NewDefaultLocalProviderConfig("test"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		expected := `resource "test_instance" "example" {
+  ami   = null       // REQUIRED string
+  value = null       // REQUIRED string
+  network {          // REQUIRED block
+    subnet_id = null // REQUIRED string
+  }
+}
+`
+		if !cmp.Equal(output, expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output))
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, commentStyle: "none", skipExperimentNote: true}
+
+		if err := v.Resource(mustResourceInstanceAddr("test_instance.example"), schema, addrs.NewDefaultLocalProviderConfig("test"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		expected := `resource "test_instance" "example" {
+  ami   = null
+  value = null
+  network {
+    subnet_id = null
+  }
+}
+`
+		if !cmp.Equal(output, expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output))
+		}
+	})
+}
+
+func TestAddResourceSubtree(t *testing.T) {
+	addr := mustResourceInstanceAddr("test_instance.example")
+
+	t.Run("optional nested block renders without -optional", func(t *testing.T) {
+		subtree := &configschema.Block{
+			BlockTypes: map[string]*configschema.NestedBlock{
+				"network": {
+					Nesting: configschema.NestingSingle,
+					Block: configschema.Block{
+						Attributes: map[string]*configschema.Attribute{
+							"subnet_id":   {Type: cty.String, Required: true},
+							"description": {Type: cty.String, Optional: true},
+						},
+					},
+				},
+			},
+		}
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+
+		if err := v.ResourceSubtree(addr, subtree); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		expected := `network {          # OPTIONAL block
+  subnet_id = null # REQUIRED string
+}
+`
+		if !cmp.Equal(output, expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output))
+		}
+	})
+
+	t.Run("inner optional arguments follow -optional", func(t *testing.T) {
+		subtree := &configschema.Block{
+			BlockTypes: map[string]*configschema.NestedBlock{
+				"network": {
+					Nesting: configschema.NestingSingle,
+					Block: configschema.Block{
+						Attributes: map[string]*configschema.Attribute{
+							"subnet_id":   {Type: cty.String, Required: true},
+							"description": {Type: cty.String, Optional: true},
+						},
+					},
+				},
+			},
+		}
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, optional: true}
+
+		if err := v.ResourceSubtree(addr, subtree); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		expected := `network {            # OPTIONAL block
+  description = null # OPTIONAL string
+  subnet_id   = null # REQUIRED string
+}
+`
+		if !cmp.Equal(output, expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output))
+		}
+	})
+
+	t.Run("plain attribute", func(t *testing.T) {
+		subtree := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"ami": {Type: cty.String, Optional: true},
+			},
+		}
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+
+		if err := v.ResourceSubtree(addr, subtree); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		expected := "ami = null # OPTIONAL string\n"
+		if !cmp.Equal(output, expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output))
+		}
+	})
+
+	t.Run("nested object attribute expands its fields", func(t *testing.T) {
+		subtree := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"disks": {
+					Optional: true,
+					NestedType: &configschema.Object{
+						Nesting: configschema.NestingSingle,
+						Attributes: map[string]*configschema.Attribute{
+							"mount_point": {Type: cty.String, Optional: true},
+						},
+					},
+				},
+			},
+		}
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+
+		if err := v.ResourceSubtree(addr, subtree); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if !strings.Contains(output, "mount_point") {
+			t.Errorf("missing nested object field\ngot:\n%s", output)
+		}
+		if v.optional {
+			t.Error("v.optional was not restored after rendering")
+		}
+	})
+}
+
+func TestAddResource_schemaDescription(t *testing.T) {
+	addr := mustResourceInstanceAddr("test_instance.example")
+
+	t.Run("plain", func(t *testing.T) {
+		schema := &configschema.Block{
+			Description:     "Manages a single test instance.\n\nSee the provider docs for details.",
+			DescriptionKind: configschema.StringPlain,
+			Attributes: map[string]*configschema.Attribute{
+				"ami": {Type: cty.String, Required: true},
+			},
+		}
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("test"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+		want := `# Manages a single test instance.
+#
+# See the provider docs for details.
+resource "test_instance" "example" {`
+		if !strings.Contains(output, want) {
+			t.Errorf("missing description comment\ngot:\n%s\nwant to contain:\n%s", output, want)
+		}
+	})
+
+	t.Run("markdown with example", func(t *testing.T) {
+		schema := &configschema.Block{
+			Description:     "Manages a test instance. For example:\n\n```hcl\nresource \"test_instance\" \"example\" {\n  ami = \"ami-123\"\n}\n```",
+			DescriptionKind: configschema.StringMarkdown,
+			Attributes: map[string]*configschema.Attribute{
+				"ami": {Type: cty.String, Required: true},
+			},
+		}
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("test"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+		for _, want := range []string{
+			"# Manages a test instance. For example:",
+			`# resource "test_instance" "example" {`,
+			`#   ami = "ami-123"`,
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("missing %q in output:\n%s", want, output)
+			}
+		}
+		// The fence markers carry no information once the example is a
+		// comment.
+		if strings.Contains(output, "```") {
+			t.Errorf("code fence markers survived in output:\n%s", output)
+		}
+	})
+}
+
+func TestAddResource_withCheck(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id":  {Type: cty.String, Computed: true},
+			"ami": {Type: cty.String, Required: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("test_instance.new")
+
+	streams, done := terminal.StreamsForTesting(t)
+	v := addHuman{view: NewView(streams), quiet: true, withCheck: true}
+
+	if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("test"), cty.NilVal); err != nil {
+		t.Fatal(err.Error())
+	}
+	output := done(t).Stdout()
+
+	want := `check "test_instance_new" {
+  data "test_instance" "new" {
+    # TODO: Set arguments which look up the object this resource manages,
+    # for example by its id or name.
+  }
+
+  assert {
+    condition     = data.test_instance.new.id == test_instance.new.id
+    error_message = "test_instance.new no longer matches the object it manages."
+  }
+}
+`
+	if !strings.Contains(output, want) {
+		t.Fatalf("wrong output:\ngot:\n%s\nwant to contain:\n%s", output, want)
+	}
+	if !strings.Contains(output, `resource "test_instance" "new" {`) {
+		t.Fatalf("missing resource block in output:\n%s", output)
+	}
+}
+
+func TestAddResource_redact(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"ami":            {Type: cty.String, Optional: true},
+			"password":       {Type: cty.String, Optional: true, Sensitive: true},
+			"api_secret_key": {Type: cty.String, Optional: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_instance.example")
+	stateVal := cty.ObjectVal(map[string]cty.Value{
+		"ami":            cty.StringVal("ami-123456"),
+		"password":       cty.StringVal("hunter2"),
+		"api_secret_key": cty.StringVal("s3cr3t"),
+	})
+	patterns := []string{"password", "*secret*"}
+
+	t.Run("to stdout", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, redactPatterns: patterns}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		wantResource := `resource "aws_instance" "example" {
+  ami            = "ami-123456"
+  api_secret_key = var.api_secret_key
+  password       = var.password
+}
+`
+		if !strings.Contains(output, wantResource) {
+			t.Errorf("wrong resource block:\ngot:\n%s\nwant to contain:\n%s", output, wantResource)
+		}
+		wantVariable := `variable "password" {
+  type      = string
+  sensitive = true
+}
+`
+		if !strings.Contains(output, wantVariable) {
+			t.Errorf("wrong variable declaration:\ngot:\n%s\nwant to contain:\n%s", output, wantVariable)
+		}
+		if !strings.Contains(output, `variable "api_secret_key" {`) {
+			t.Errorf("missing variable declaration for api_secret_key:\n%s", output)
+		}
+		if strings.Contains(output, "hunter2") || strings.Contains(output, "s3cr3t") {
+			t.Errorf("redacted value leaked into output:\n%s", output)
+		}
+	})
+
+	t.Run("to a file", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		dir := t.TempDir()
+		outPath := filepath.Join(dir, "generated.tf")
+		v := addHuman{view: NewView(streams), quiet: true, outPath: outPath, redactPatterns: patterns}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		varsSrc, err := os.ReadFile(filepath.Join(dir, "variables.tf"))
+		if err != nil {
+			t.Fatalf("error reading variables.tf: %s", err.Error())
+		}
+		if !strings.Contains(string(varsSrc), `variable "password" {`) {
+			t.Errorf("missing variable declaration in variables.tf:\n%s", varsSrc)
+		}
+		resourceSrc, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		if !strings.Contains(string(resourceSrc), "password       = var.password") {
+			t.Errorf("missing variable reference in result:\n%s", resourceSrc)
+		}
+	})
+
+	t.Run("provider descriptions carry into the declarations", func(t *testing.T) {
+		describedSchema := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"ami": {Type: cty.String, Optional: true},
+				"password": {
+					Type:        cty.String,
+					Optional:    true,
+					Sensitive:   true,
+					Description: "The master password for the database.\n\nLonger documentation follows here.",
+				},
+			},
+		}
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, redactPatterns: patterns}
+
+		if err := v.Resource(addr, describedSchema, addrs.NewDefaultLocalProviderConfig("aws"), stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		wantVariable := `variable "password" {
+  type        = string
+  description = "The master password for the database."
+  sensitive   = true
+}
+`
+		if !strings.Contains(output, wantVariable) {
+			t.Errorf("wrong variable declaration:\ngot:\n%s\nwant to contain:\n%s", output, wantVariable)
+		}
+	})
+}
+
+func TestAddResource_extractVariables(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"acl": {
+				Type:        cty.String,
+				Required:    true,
+				Description: "The canned ACL to apply. Valid values are `private`, `public-read` or `authenticated-read`.",
+			},
+			"bucket": {
+				Type:        cty.String,
+				Required:    true,
+				Description: "The name of the bucket. Must be between 3 and 63 characters long.\n\nLonger documentation follows here.",
+			},
+			"tier": {
+				Type:        cty.String,
+				Optional:    true,
+				Description: "One of the tiers documented in the pricing guide.",
+			},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_s3_bucket.example")
+
+	t.Run("declarations with validation scaffolds", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, optional: true, extractVariables: true}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		wantResource := `resource "aws_s3_bucket" "example" {
+  acl    = var.acl
+  bucket = var.bucket
+  tier   = var.tier
+}
+`
+		if !strings.Contains(output, wantResource) {
+			t.Errorf("wrong resource block:\ngot:\n%s\nwant to contain:\n%s", output, wantResource)
+		}
+		for _, want := range []string{
+			`variable "acl" {`,
+			`description = "The canned ACL to apply. Valid values are ` + "`private`, `public-read` or `authenticated-read`" + `."`,
+			`condition     = contains(["private", "public-read", "authenticated-read"], var.acl)`,
+			`error_message = "The acl value must be one of: private, public-read, authenticated-read."`,
+			`description = "The name of the bucket. Must be between 3 and 63 characters long."`,
+			`condition     = length(var.bucket) >= 3 && length(var.bucket) <= 63`,
+			`# TODO: Add a validation block; the provider documents allowed values:`,
+			`# One of the tiers documented in the pricing guide`,
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("missing %q in output:\n%s", want, output)
+			}
+		}
+		// Only the first line of a multi-paragraph description belongs in
+		// the declaration.
+		if strings.Contains(output, "Longer documentation follows here") {
+			t.Errorf("multi-paragraph description not truncated:\n%s", output)
+		}
+	})
+
+	t.Run("values from stdin are not extracted", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, extractVariables: true}
+		v.SetValues(map[string]string{"acl": "private", "bucket": "my-bucket"})
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if !strings.Contains(output, `acl    = "private"`) {
+			t.Errorf("supplied value not written inline:\n%s", output)
+		}
+		if strings.Contains(output, `variable "acl" {`) || strings.Contains(output, `variable "bucket" {`) {
+			t.Errorf("variable declared for a supplied value:\n%s", output)
+		}
+	})
+
+	t.Run("to a file", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		dir := t.TempDir()
+		outPath := filepath.Join(dir, "generated.tf")
+		v := addHuman{view: NewView(streams), quiet: true, outPath: outPath, extractVariables: true}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		varsSrc, err := os.ReadFile(filepath.Join(dir, "variables.tf"))
+		if err != nil {
+			t.Fatalf("error reading variables.tf: %s", err.Error())
+		}
+		if !strings.Contains(string(varsSrc), `variable "bucket" {`) {
+			t.Errorf("missing variable declaration in variables.tf:\n%s", varsSrc)
+		}
+		resourceSrc, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		if !strings.Contains(string(resourceSrc), "bucket = var.bucket") {
+			t.Errorf("missing variable reference in result:\n%s", resourceSrc)
+		}
+	})
+}
+
+func TestAddExternalDataSource(t *testing.T) {
+	addr := mustResourceInstanceAddr("data.external.lookup")
+
+	t.Run("to stdout without a script", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true}
+
+		if err := v.ExternalDataSource(addr, ""); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		expected := `data "external" "lookup" {
+  # The program is run with a JSON object of the query arguments on its
+  # standard input, and must print a JSON object whose values are all
+  # strings; that object is available as result.
+  program = null # REQUIRED list(string)
+
+  query = {
+    # key = "value"
+  }
+}
+`
+		if !cmp.Equal(output, expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, output))
+		}
+	})
+
+	t.Run("with a sh script stub", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		dir := t.TempDir()
+		outPath := filepath.Join(dir, "generated.tf")
+		v := addHuman{view: NewView(streams), quiet: true, outPath: outPath}
+
+		if err := v.ExternalDataSource(addr, "sh"); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		src, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		if !strings.Contains(string(src), `program = ["${path.module}/lookup.sh"]`) {
+			t.Errorf("program does not point at the stub:\n%s", src)
+		}
+
+		scriptPath := filepath.Join(dir, "lookup.sh")
+		info, err := os.Stat(scriptPath)
+		if err != nil {
+			t.Fatalf("error reading script stub %s: %s", scriptPath, err.Error())
+		}
+		if info.Mode()&0111 == 0 {
+			t.Errorf("script stub is not executable: %s", info.Mode())
+		}
+		stub, err := os.ReadFile(scriptPath)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !strings.HasPrefix(string(stub), "#!/bin/sh\n") {
+			t.Errorf("wrong script stub:\n%s", stub)
+		}
+
+		// A stub the user has already filled in must survive a re-run.
+		if err := os.WriteFile(scriptPath, []byte("#!/bin/sh\necho edited\n"), 0755); err != nil {
+			t.Fatal(err.Error())
+		}
+		streams2, done2 := terminal.StreamsForTesting(t)
+		v2 := addHuman{view: NewView(streams2), quiet: true, outPath: outPath}
+		if err := v2.ExternalDataSource(addr, "sh"); err != nil {
+			t.Fatal(err.Error())
+		}
+		output2 := done2(t)
+		edited, err := os.ReadFile(scriptPath)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if string(edited) != "#!/bin/sh\necho edited\n" {
+			t.Errorf("re-run clobbered the script stub:\n%s", edited)
+		}
+		if !strings.Contains(output2.All(), "already exists and was left unchanged") {
+			t.Errorf("missing left-unchanged note:\n%s", output2.All())
+		}
+	})
+
+	t.Run("with a python script stub", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		dir := t.TempDir()
+		outPath := filepath.Join(dir, "generated.tf")
+		v := addHuman{view: NewView(streams), quiet: true, outPath: outPath}
+
+		if err := v.ExternalDataSource(addr, "python"); err != nil {
+			t.Fatal(err.Error())
+		}
+		done(t)
+
+		src, err := os.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("error reading result file %s: %s", outPath, err.Error())
+		}
+		if !strings.Contains(string(src), `program = ["python3", "${path.module}/lookup.py"]`) {
+			t.Errorf("program does not point at the stub:\n%s", src)
+		}
+		stub, err := os.ReadFile(filepath.Join(dir, "lookup.py"))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !strings.HasPrefix(string(stub), "#!/usr/bin/env python\n") {
+			t.Errorf("wrong script stub:\n%s", stub)
+		}
+	})
+}
+
+func TestAddResource_outputRefs(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"name":      {Type: cty.String, Optional: true},
+			"subnet_id": {Type: cty.String, Required: true},
+		},
+	}
+	stateVal := cty.ObjectVal(map[string]cty.Value{
+		"name":      cty.StringVal("web"),
+		"subnet_id": cty.StringVal("subnet-123"),
+	})
+
+	t.Run("root module resource uses the reference", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+		v.ModuleOutputs(map[string]cty.Value{
+			"module.network.subnet_id": cty.StringVal("subnet-123"),
+		})
+
+		addr := mustResourceInstanceAddr("aws_instance.example")
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		want := `resource "aws_instance" "example" {
+  name      = "web"
+  subnet_id = module.network.subnet_id
+}
+`
+		if !strings.Contains(output, want) {
+			t.Errorf("wrong output:\ngot:\n%s\nwant to contain:\n%s", output, want)
+		}
+	})
+
+	t.Run("child module resource keeps the literal", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+		v.ModuleOutputs(map[string]cty.Value{
+			"module.network.subnet_id": cty.StringVal("subnet-123"),
+		})
+
+		// module.network.subnet_id would refer to a different (nested) module
+		// from inside module.network itself, so the literal must be kept.
+		addr := mustResourceInstanceAddr("module.network.aws_instance.inner")
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if !strings.Contains(output, `subnet_id = "subnet-123"`) {
+			t.Errorf("wrong output:\n%s", output)
+		}
+	})
+
+	t.Run("ambiguous values keep the literal", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+		v.ModuleOutputs(map[string]cty.Value{
+			"module.network.subnet_id": cty.StringVal("subnet-123"),
+			"module.legacy.subnet_id":  cty.StringVal("subnet-123"),
+		})
+
+		addr := mustResourceInstanceAddr("aws_instance.example")
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), stateVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if !strings.Contains(output, `subnet_id = "subnet-123"`) {
+			t.Errorf("wrong output:\n%s", output)
+		}
+	})
+}
+
+func TestAddResource_hints(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"bucket": {Type: cty.String, Required: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("aws_s3_bucket.example")
+
+	t.Run("hints after writing to a file", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		v := addHuman{view: NewView(streams), outPath: outPath}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		stderr := done(t).Stderr()
+		for _, want := range []string{
+			"Next steps:",
+			"terraform import 'aws_s3_bucket.example' ID",
+			`"terraform init"`,
+			`"terraform fmt"`,
+		} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("missing hint %q in stderr:\n%s", want, stderr)
+			}
+		}
+	})
+
+	t.Run("quiet suppresses hints", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		v := addHuman{view: NewView(streams), outPath: outPath, quiet: true}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if stderr := done(t).Stderr(); stderr != "" {
+			t.Errorf("expected no hints with quiet set, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("SetHintsDisabled suppresses hints", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		outPath := filepath.Join(t.TempDir(), "generated.tf")
+		v := addHuman{view: NewView(streams), outPath: outPath}
+		v.SetHintsDisabled(true)
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if stderr := done(t).Stderr(); stderr != "" {
+			t.Errorf("expected no hints with hints disabled, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("no hints when stdout is piped", func(t *testing.T) {
+		// With no output path the template goes to stdout, and the testing
+		// streams are not a terminal, so the hints must stay silent to avoid
+		// polluting captured output.
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams)}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("aws"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if stderr := done(t).Stderr(); stderr != "" {
+			t.Errorf("expected no hints when piping stdout, got:\n%s", stderr)
+		}
+	})
+}
+
+func TestAddInstallProgress(t *testing.T) {
+	t.Run("human", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams)}
+
+		v.InstallProgress("- Installing hashicorp/aws v4.0.0...\n")
+
+		output := done(t)
+		if got, want := output.Stderr(), "- Installing hashicorp/aws v4.0.0...\n"; got != want {
+			t.Errorf("wrong progress output on stderr\ngot:  %q\nwant: %q", got, want)
+		}
+		if output.Stdout() != "" {
+			t.Errorf("progress leaked into stdout:\n%s", output.Stdout())
+		}
+	})
+
+	t.Run("json-diags", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), jsonDiags: true}
+
+		v.InstallProgress("- Installing hashicorp/aws v4.0.0...")
+
+		stderr := done(t).Stderr()
+		var got addInstallProgressJSON
+		if err := json.Unmarshal([]byte(stderr), &got); err != nil {
+			t.Fatalf("progress line is not valid JSON: %s\n%s", err, stderr)
+		}
+		want := addInstallProgressJSON{
+			Type:    "install_progress",
+			Message: "- Installing hashicorp/aws v4.0.0...",
+		}
+		if got != want {
+			t.Errorf("wrong progress object\ngot:  %#v\nwant: %#v", got, want)
+		}
+	})
+}
+
+func TestAdd_writeConfigAttributes(t *testing.T) {
+	tests := map[string]struct {
+		attrs    map[string]*configschema.Attribute
+		expected string
+	}{
+		"empty returns nil": {
+			map[string]*configschema.Attribute{},
+			"",
+		},
+		"attributes": {
+			map[string]*configschema.Attribute{
+				"ami": {
+					Type:     cty.Number,
+					Required: true,
+				},
+				"boot_disk": {
+					Type:     cty.String,
+					Optional: true,
+				},
+				"password": {
+					Type:      cty.String,
+					Optional:  true,
+					Sensitive: true, // sensitivity is ignored when printing blank templates
+				},
+			},
+			`ami = null # REQUIRED number
+boot_disk = null # OPTIONAL string
+password = null # OPTIONAL string
+`,
+		},
+		"attributes with nested types": {
+			map[string]*configschema.Attribute{
+				"ami": {
+					Type:     cty.Number,
+					Required: true,
+				},
+				"disks": {
+					NestedType: &configschema.Object{
+						Nesting: configschema.NestingSingle,
+						Attributes: map[string]*configschema.Attribute{
+							"size": {
+								Type:     cty.Number,
+								Optional: true,
+							},
+							"mount_point": {
+								Type:     cty.String,
+								Required: true,
+							},
+						},
+					},
+					Optional: true,
+				},
+			},
+			`ami = null # REQUIRED number
+disks = { # OPTIONAL object({ mount_point = string, size = number })
+  mount_point = null # REQUIRED string
+  size = null # OPTIONAL number
+}
+`,
+		},
+	}
+
+	v := addHuman{optional: true}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf strings.Builder
+			if err := v.writeConfigAttributes(&buf, test.attrs, 0); err != nil {
+				t.Errorf("unexpected error")
+			}
+			if buf.String() != test.expected {
+				t.Errorf("wrong result: %s", cmp.Diff(test.expected, buf.String()))
+			}
+		})
+	}
+}
+
+func TestAdd_writeConfigAttributes_examples(t *testing.T) {
+	attrs := map[string]*configschema.Attribute{
+		"ami": {
+			Type:     cty.String,
+			Required: true,
+		},
+		"subnet_id": {
+			Type:     cty.String,
+			Optional: true,
+		},
+	}
+
+	v := addHuman{optional: true, examples: exampleExpressions(map[string]string{
+		"subnet_id": "aws_subnet.private.id",
+	})}
+
+	var buf strings.Builder
+	if err := v.writeConfigAttributes(&buf, attrs, 0); err != nil {
+		t.Errorf("unexpected error")
+	}
+
+	// The override replaces the built-in suggestion for subnet_id, while ami
+	// keeps its default.
+	expected := `ami = null # REQUIRED string (e.g. data.aws_ami.selected.id)
+subnet_id = null # OPTIONAL string (e.g. aws_subnet.private.id)
+`
+	if buf.String() != expected {
+		t.Errorf("wrong result: %s", cmp.Diff(expected, buf.String()))
+	}
+}
+
+func TestAdd_writeConfigAttributes_secureDefaults(t *testing.T) {
+	attrs := map[string]*configschema.Attribute{
+		"encrypted": {
+			Type:     cty.Bool,
+			Optional: true,
+		},
+		"minimum_tls_version": {
+			Type:     cty.String,
+			Optional: true,
+		},
+		// Catalog entries which cannot convert to the attribute's type keep
+		// their normal placeholder.
+		"publicly_accessible": {
+			Type:     cty.List(cty.String),
+			Optional: true,
+		},
+		"name": {
+			Type:     cty.String,
+			Required: true,
+		},
+	}
+
+	v := addHuman{optional: true, secureDefaults: true}
+
+	var buf strings.Builder
+	if err := v.writeConfigAttributes(&buf, attrs, 0); err != nil {
+		t.Errorf("unexpected error")
+	}
+
+	expected := `encrypted = true # OPTIONAL bool
+minimum_tls_version = "1.2" # OPTIONAL string
+name = null # REQUIRED string
+publicly_accessible = null # OPTIONAL list(string)
+`
+	if buf.String() != expected {
+		t.Errorf("wrong result: %s", cmp.Diff(expected, buf.String()))
+	}
+}
+
+func TestAdd_writeConfigAttributesFromExisting(t *testing.T) {
+	attrs := map[string]*configschema.Attribute{
+		"ami": {
+			Type:     cty.Number,
+			Required: true,
+		},
+		"boot_disk": {
+			Type:     cty.String,
+			Optional: true,
+		},
+		"password": {
+			Type:      cty.String,
+			Optional:  true,
+			Sensitive: true,
+		},
+		"disks": {
+			NestedType: &configschema.Object{
+				Nesting: configschema.NestingSingle,
+				Attributes: map[string]*configschema.Attribute{
+					"size": {
+						Type:     cty.Number,
+						Optional: true,
+					},
+					"mount_point": {
+						Type:     cty.String,
+						Required: true,
+					},
+				},
+			},
+			Optional: true,
+		},
+	}
+
+	tests := map[string]struct {
+		attrs    map[string]*configschema.Attribute
+		val      cty.Value
+		expected string
+	}{
+		"empty returns nil": {
+			map[string]*configschema.Attribute{},
+			cty.NilVal,
+			"",
+		},
+		"mixed attributes": {
+			attrs,
+			cty.ObjectVal(map[string]cty.Value{
+				"ami":       cty.NumberIntVal(123456),
+				"boot_disk": cty.NullVal(cty.String),
+				"password":  cty.StringVal("i am secret"),
+				"disks": cty.ObjectVal(map[string]cty.Value{
+					"size":        cty.NumberIntVal(50),
+					"mount_point": cty.NullVal(cty.String),
+				}),
+			}),
+			`ami = 123456
+boot_disk = null
+disks = {
+  mount_point = null
+  size = 50
+}
+password = null # sensitive
+`,
+		},
+	}
+
+	v := addHuman{optional: true}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf strings.Builder
+			if err := v.writeConfigAttributesFromExisting(&buf, test.val, test.attrs, 0, nil); err != nil {
+				t.Errorf("unexpected error")
+			}
+			if buf.String() != test.expected {
+				t.Errorf("wrong result: %s", cmp.Diff(test.expected, buf.String()))
+			}
+		})
+	}
+}
+
+func TestAdd_writeConfigBlocks(t *testing.T) {
+	t.Run("NestingSingle", func(t *testing.T) {
+		v := addHuman{optional: true}
+		schema := addTestSchema(configschema.NestingSingle)
+		var buf strings.Builder
+		v.writeConfigBlocks(&buf, schema.BlockTypes, 0)
+
+		expected := `network_rules { # REQUIRED block
+  ip_address = null # OPTIONAL/COMPUTED string
+}
+root_block_device { # OPTIONAL block
+  volume_type = null # OPTIONAL/COMPUTED string
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Errorf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingList", func(t *testing.T) {
+		v := addHuman{optional: true}
+		schema := addTestSchema(configschema.NestingList)
+		var buf strings.Builder
+		v.writeConfigBlocks(&buf, schema.BlockTypes, 0)
+
+		expected := `network_rules { # REQUIRED block
+  ip_address = null # OPTIONAL/COMPUTED string
+}
+root_block_device { # OPTIONAL block
+  volume_type = null # OPTIONAL/COMPUTED string
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingSet", func(t *testing.T) {
+		v := addHuman{optional: true}
+		schema := addTestSchema(configschema.NestingSet)
+		var buf strings.Builder
+		v.writeConfigBlocks(&buf, schema.BlockTypes, 0)
+
+		expected := `network_rules { # REQUIRED block
+  ip_address = null # OPTIONAL/COMPUTED string
+}
+root_block_device { # OPTIONAL block
+  volume_type = null # OPTIONAL/COMPUTED string
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("cardinality constraints", func(t *testing.T) {
+		v := addHuman{optional: true}
+		blockTypes := map[string]*configschema.NestedBlock{
+			"bounded": {
+				Nesting:  configschema.NestingList,
+				MinItems: 1,
+				MaxItems: 5,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"cidr": {Type: cty.String, Required: true},
+					},
+				},
+			},
+			"capped": {
+				Nesting:  configschema.NestingSet,
+				MaxItems: 2,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"name": {Type: cty.String, Required: true},
+					},
+				},
+			},
+		}
+		var buf strings.Builder
+		v.writeConfigBlocks(&buf, blockTypes, 0)
+
+		expected := `bounded { # REQUIRED block (min 1, max 5)
+  cidr = null # REQUIRED string
+}
+capped { # OPTIONAL block (max 2)
+  name = null # REQUIRED string
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingMap", func(t *testing.T) {
+		v := addHuman{optional: true}
+		schema := addTestSchema(configschema.NestingMap)
+		var buf strings.Builder
+		v.writeConfigBlocks(&buf, schema.BlockTypes, 0)
+
+		expected := `network_rules "key" { # REQUIRED block
+  ip_address = null # OPTIONAL/COMPUTED string
+}
+root_block_device "key" { # OPTIONAL block
+  volume_type = null # OPTIONAL/COMPUTED string
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("empty blocks collapse to a single line", func(t *testing.T) {
+		// Without -optional a required block whose attributes are all
+		// optional renders with nothing inside, so the compact {} form is
+		// used; a body appears as soon as any attribute is rendered.
+		v := addHuman{}
+		blockTypes := map[string]*configschema.NestedBlock{
+			"network_interface": {
+				Nesting:  configschema.NestingList,
+				MinItems: 1,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"device_index": {Type: cty.Number, Optional: true},
+					},
+				},
+			},
+			"timeouts": {
+				Nesting:  configschema.NestingSingle,
+				MinItems: 1,
+				Block:    configschema.Block{},
+			},
+			"versioning": {
+				Nesting:  configschema.NestingMap,
+				MinItems: 1,
+				Block:    configschema.Block{},
+			},
+			"required_settings": {
+				Nesting:  configschema.NestingList,
+				MinItems: 1,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"size": {Type: cty.Number, Required: true},
+					},
+				},
+			},
+		}
+		var buf strings.Builder
+		v.writeConfigBlocks(&buf, blockTypes, 0)
+
+		expected := `network_interface {} # REQUIRED block
+required_settings { # REQUIRED block
+  size = null # REQUIRED number
+}
+timeouts {} # REQUIRED block
+versioning "key" {} # REQUIRED block
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+}
+
+func TestAdd_writeConfigBlocksFromExisting(t *testing.T) {
+
+	t.Run("NestingSingle", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.ObjectVal(map[string]cty.Value{
+				"volume_type": cty.StringVal("foo"),
+			}),
+		})
+		schema := addTestSchema(configschema.NestingSingle)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		expected := `root_block_device {
+  volume_type = "foo"
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Errorf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingSingle_marked_attr", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.ObjectVal(map[string]cty.Value{
+				"volume_type": cty.StringVal("foo").Mark("sensitive"),
+			}),
+		})
+		schema := addTestSchema(configschema.NestingSingle)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		expected := `root_block_device {
+  volume_type = null # sensitive
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Errorf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingSingle_entirely_marked", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.ObjectVal(map[string]cty.Value{
+				"volume_type": cty.StringVal("foo"),
+			}),
+		}).Mark("sensitive")
+		schema := addTestSchema(configschema.NestingSingle)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		expected := `root_block_device {} # sensitive
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Errorf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingList", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("foo"),
+				}),
+				cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("bar"),
+				}),
+			}),
+		})
+		schema := addTestSchema(configschema.NestingList)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		expected := `root_block_device {
+  volume_type = "foo"
+}
+root_block_device {
+  volume_type = "bar"
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingList_marked_attr", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("foo").Mark("sensitive"),
+				}),
+				cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("bar"),
+				}),
+			}),
+		})
+		schema := addTestSchema(configschema.NestingList)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		expected := `root_block_device {
+  volume_type = null # sensitive
+}
+root_block_device {
+  volume_type = "bar"
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingList_entirely_marked", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("foo"),
+				}),
+				cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("bar"),
+				}),
+			}).Mark("sensitive"),
+		})
+		schema := addTestSchema(configschema.NestingList)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		expected := `root_block_device {} # sensitive
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingSet", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.SetVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("foo"),
+				}),
+				cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("bar"),
+				}),
+			}),
+		})
+		schema := addTestSchema(configschema.NestingSet)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		expected := `root_block_device {
+  volume_type = "bar"
+}
+root_block_device {
+  volume_type = "foo"
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingSet_marked", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		// In cty.Sets, the entire set ends up marked if any element is marked.
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.SetVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("foo"),
+				}),
+				cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("bar"),
+				}),
+			}).Mark("sensitive"),
+		})
+		schema := addTestSchema(configschema.NestingSet)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		// When the entire set of blocks is sensitive, we only print one block.
+		expected := `root_block_device {} # sensitive
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingMap", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.MapVal(map[string]cty.Value{
+				"1": cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("foo"),
+				}),
+				"2": cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("bar"),
+				}),
+			}),
+		})
+		schema := addTestSchema(configschema.NestingMap)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		expected := `root_block_device "1" {
+  volume_type = "foo"
+}
+root_block_device "2" {
+  volume_type = "bar"
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingMap_marked", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.MapVal(map[string]cty.Value{
+				"1": cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("foo").Mark("sensitive"),
+				}),
+				"2": cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("bar"),
+				}),
+			}),
+		})
+		schema := addTestSchema(configschema.NestingMap)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		expected := `root_block_device "1" {
+  volume_type = null # sensitive
+}
+root_block_device "2" {
+  volume_type = "bar"
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingMap_entirely_marked", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.MapVal(map[string]cty.Value{
+				"1": cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("foo"),
+				}),
+				"2": cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("bar"),
+				}),
+			}).Mark("sensitive"),
+		})
+		schema := addTestSchema(configschema.NestingMap)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		expected := `root_block_device {} # sensitive
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingMap_marked_elem", func(t *testing.T) {
+		v := addHuman{optional: true, includeOptionalComputed: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"root_block_device": cty.MapVal(map[string]cty.Value{
+				"1": cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("foo"),
+				}),
+				"2": cty.ObjectVal(map[string]cty.Value{
+					"volume_type": cty.StringVal("bar"),
+				}).Mark("sensitive"),
+			}),
+		})
+		schema := addTestSchema(configschema.NestingMap)
+		var buf strings.Builder
+		v.writeConfigBlocksFromExisting(&buf, val, schema.BlockTypes, 0, nil)
+
+		expected := `root_block_device "1" {
+  volume_type = "foo"
+}
+root_block_device "2" {} # sensitive
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+}
+
+func TestAdd_writeConfigNestedTypeAttribute(t *testing.T) {
+	t.Run("NestingSingle", func(t *testing.T) {
+		v := addHuman{optional: true}
+		schema := addTestSchema(configschema.NestingSingle)
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttribute(&buf, "disks", schema.Attributes["disks"], 0)
+
+		expected := `disks = { # OPTIONAL object({ mount_point = string, size = string })
+  mount_point = null # OPTIONAL string
+  size = null # OPTIONAL string
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingList", func(t *testing.T) {
+		v := addHuman{optional: true}
+		schema := addTestSchema(configschema.NestingList)
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttribute(&buf, "disks", schema.Attributes["disks"], 0)
+
+		expected := `disks = [{ # OPTIONAL list(object({ mount_point = string, size = string }))
+  mount_point = null # OPTIONAL string
+  size = null # OPTIONAL string
+}]
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingSet", func(t *testing.T) {
+		v := addHuman{optional: true}
+		schema := addTestSchema(configschema.NestingSet)
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttribute(&buf, "disks", schema.Attributes["disks"], 0)
+
+		expected := `disks = [{ # OPTIONAL set(object({ mount_point = string, size = string }))
+  mount_point = null # OPTIONAL string
+  size = null # OPTIONAL string
+}]
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingMap", func(t *testing.T) {
+		v := addHuman{optional: true}
+		schema := addTestSchema(configschema.NestingMap)
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttribute(&buf, "disks", schema.Attributes["disks"], 0)
+
+		expected := `disks = { # OPTIONAL map(object({ mount_point = string, size = string }))
+  key = {
+    mount_point = null # OPTIONAL string
+    size = null # OPTIONAL string
+  }
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("required object expands its optional fields", func(t *testing.T) {
+		// Without -optional, a required object attribute still scaffolds its
+		// optional fields; an empty object would leave the user reconstructing
+		// the shape from documentation.
+		v := addHuman{}
+		attrS := &configschema.Attribute{
+			Required: true,
+			NestedType: &configschema.Object{
+				Nesting: configschema.NestingSingle,
+				Attributes: map[string]*configschema.Attribute{
+					"mount_point": {Type: cty.String, Optional: true},
+					"size":        {Type: cty.String, Optional: true},
+				},
+			},
+		}
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttribute(&buf, "disks", attrS, 0)
+
+		expected := `disks = { # REQUIRED object({ mount_point = string, size = string })
+  mount_point = null # OPTIONAL string
+  size = null # OPTIONAL string
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+		if v.optional {
+			t.Fatal("optional flag not restored after rendering the required object")
+		}
+	})
+
+	t.Run("optional object still omitted without -optional", func(t *testing.T) {
+		v := addHuman{}
+		attrS := &configschema.Attribute{
+			Optional: true,
+			NestedType: &configschema.Object{
+				Nesting: configschema.NestingSingle,
+				Attributes: map[string]*configschema.Attribute{
+					"mount_point": {Type: cty.String, Optional: true},
+				},
+			},
+		}
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttribute(&buf, "disks", attrS, 0)
+
+		if buf.Len() != 0 {
+			t.Fatalf("unexpected output for optional object:\n%s", buf.String())
+		}
+	})
+}
+
+func TestAdd_WriteConfigNestedTypeAttributeFromExisting(t *testing.T) {
+	t.Run("NestingSingle", func(t *testing.T) {
+		v := addHuman{optional: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"disks": cty.ObjectVal(map[string]cty.Value{
+				"mount_point": cty.StringVal("/mnt/foo"),
+				"size":        cty.StringVal("50GB"),
+			}),
+		})
+		schema := addTestSchema(configschema.NestingSingle)
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttributeFromExisting(&buf, "disks", schema.Attributes["disks"], val, 0, nil)
+
+		expected := `disks = {
+  mount_point = "/mnt/foo"
+  size = "50GB"
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingSingle_sensitive", func(t *testing.T) {
+		v := addHuman{optional: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"disks": cty.ObjectVal(map[string]cty.Value{
+				"mount_point": cty.StringVal("/mnt/foo"),
+				"size":        cty.StringVal("50GB"),
+			}),
+		})
+		schema := addTestSchemaSensitive(configschema.NestingSingle)
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttributeFromExisting(&buf, "disks", schema.Attributes["disks"], val, 0, nil)
+
+		expected := `disks = {} # sensitive
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingList", func(t *testing.T) {
+		v := addHuman{optional: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"disks": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"mount_point": cty.StringVal("/mnt/foo"),
+					"size":        cty.StringVal("50GB"),
+				}),
+				cty.ObjectVal(map[string]cty.Value{
+					"mount_point": cty.StringVal("/mnt/bar"),
+					"size":        cty.StringVal("250GB"),
+				}),
+			}),
+		})
+
+		schema := addTestSchema(configschema.NestingList)
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttributeFromExisting(&buf, "disks", schema.Attributes["disks"], val, 0, nil)
+
+		expected := `disks = [
+  {
+    mount_point = "/mnt/foo"
+    size = "50GB"
+  },
+  {
+    mount_point = "/mnt/bar"
+    size = "250GB"
+  },
+]
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingList - marked", func(t *testing.T) {
+		v := addHuman{optional: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"disks": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"mount_point": cty.StringVal("/mnt/foo"),
+					"size":        cty.StringVal("50GB").Mark("sensitive"),
+				}),
+				// This is an odd example, where the entire element is marked.
+				cty.ObjectVal(map[string]cty.Value{
+					"mount_point": cty.StringVal("/mnt/bar"),
+					"size":        cty.StringVal("250GB"),
+				}).Mark("sensitive"),
+			}),
+		})
+
+		schema := addTestSchema(configschema.NestingList)
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttributeFromExisting(&buf, "disks", schema.Attributes["disks"], val, 0, nil)
+
+		expected := `disks = [
+  {
+    mount_point = "/mnt/foo"
+    size = null # sensitive
+  },
+  {}, # sensitive
+]
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingList - entirely marked", func(t *testing.T) {
+		v := addHuman{optional: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"disks": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"mount_point": cty.StringVal("/mnt/foo"),
+					"size":        cty.StringVal("50GB"),
+				}),
+				// This is an odd example, where the entire element is marked.
+				cty.ObjectVal(map[string]cty.Value{
+					"mount_point": cty.StringVal("/mnt/bar"),
+					"size":        cty.StringVal("250GB"),
+				}),
+			}),
+		}).Mark("sensitive")
+
+		schema := addTestSchema(configschema.NestingList)
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttributeFromExisting(&buf, "disks", schema.Attributes["disks"], val, 0, nil)
+
+		expected := `disks = [] # sensitive
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingMap", func(t *testing.T) {
+		v := addHuman{optional: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"disks": cty.MapVal(map[string]cty.Value{
+				"foo": cty.ObjectVal(map[string]cty.Value{
+					"mount_point": cty.StringVal("/mnt/foo"),
+					"size":        cty.StringVal("50GB"),
+				}),
+				"bar": cty.ObjectVal(map[string]cty.Value{
+					"mount_point": cty.StringVal("/mnt/bar"),
+					"size":        cty.StringVal("250GB"),
+				}),
+			}),
+		})
+		schema := addTestSchema(configschema.NestingMap)
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttributeFromExisting(&buf, "disks", schema.Attributes["disks"], val, 0, nil)
+
+		expected := `disks = {
+  bar = {
+    mount_point = "/mnt/bar"
+    size = "250GB"
+  }
+  foo = {
+    mount_point = "/mnt/foo"
+    size = "50GB"
+  }
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+
+	t.Run("NestingMap - marked", func(t *testing.T) {
+		v := addHuman{optional: true}
+		val := cty.ObjectVal(map[string]cty.Value{
+			"disks": cty.MapVal(map[string]cty.Value{
+				"foo": cty.ObjectVal(map[string]cty.Value{
+					"mount_point": cty.StringVal("/mnt/foo"),
+					"size":        cty.StringVal("50GB").Mark("sensitive"),
+				}),
+				"bar": cty.ObjectVal(map[string]cty.Value{
+					"mount_point": cty.StringVal("/mnt/bar"),
+					"size":        cty.StringVal("250GB"),
+				}).Mark("sensitive"),
+			}),
+		})
+		schema := addTestSchema(configschema.NestingMap)
+		var buf strings.Builder
+		v.writeConfigNestedTypeAttributeFromExisting(&buf, "disks", schema.Attributes["disks"], val, 0, nil)
+
+		expected := `disks = {
+  bar = {} # sensitive
+  foo = {
+    mount_point = "/mnt/foo"
+    size = null # sensitive
+  }
+}
+`
+
+		if !cmp.Equal(buf.String(), expected) {
+			t.Fatalf("wrong output:\n%s", cmp.Diff(expected, buf.String()))
+		}
+	})
+}
+
+func addTestSchema(nesting configschema.NestingMode) *configschema.Block {
+	return &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {Type: cty.String, Optional: true, Computed: true},
+			// Attributes which are neither optional nor required should not print.
+			"uuid": {Type: cty.String, Computed: true},
+			"ami":  {Type: cty.String, Optional: true},
+			"disks": {
+				NestedType: &configschema.Object{
+					Attributes: map[string]*configschema.Attribute{
+						"mount_point": {Type: cty.String, Optional: true},
+						"size":        {Type: cty.String, Optional: true},
+					},
+					Nesting: nesting,
+				},
+			},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"root_block_device": {
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"volume_type": {
+							Type:     cty.String,
+							Optional: true,
+							Computed: true,
+						},
+					},
+				},
+				Nesting: nesting,
+			},
+			"network_rules": {
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"ip_address": {
+							Type:     cty.String,
+							Optional: true,
+							Computed: true,
+						},
+					},
+				},
+				Nesting:  nesting,
+				MinItems: 1,
+			},
+		},
+	}
+}
+
+// addTestSchemaSensitive returns a schema with a sensitive NestedType and a
+// NestedBlock with sensitive attributes.
+func addTestSchemaSensitive(nesting configschema.NestingMode) *configschema.Block {
+	return &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {Type: cty.String, Optional: true, Computed: true},
+			// Attributes which are neither optional nor required should not print.
+			"uuid": {Type: cty.String, Computed: true},
+			"ami":  {Type: cty.String, Optional: true},
+			"disks": {
+				NestedType: &configschema.Object{
+					Attributes: map[string]*configschema.Attribute{
+						"mount_point": {Type: cty.String, Optional: true},
+						"size":        {Type: cty.String, Optional: true},
+					},
+					Nesting: nesting,
+				},
+				Sensitive: true,
+			},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"root_block_device": {
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"volume_type": {
+							Type:      cty.String,
+							Optional:  true,
+							Computed:  true,
+							Sensitive: true,
+						},
+					},
+				},
+				Nesting: nesting,
+			},
+		},
+	}
+}
+
+func TestAddResource_providerScaffold(t *testing.T) {
+	streams, done := terminal.StreamsForTesting(t)
+	v := addHuman{view: NewView(streams), quiet: true}
+
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"tenant_id":  {Type: cty.String, Required: true},
+			"partner_id": {Type: cty.String, Optional: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"features": {
+				Nesting:  configschema.NestingSingle,
+				MinItems: 1,
+				MaxItems: 1,
+			},
+		},
+	}
+	addr := mustResourceInstanceAddr("azurerm_resource_group.example")
+	pc := addrs.NewDefaultLocalProviderConfig("azurerm")
+	provider := addrs.NewDefaultProvider("azurerm")
+
+	// The second call must be a no-op: each provider is scaffolded once.
+	for i := 0; i < 2; i++ {
+		if err := v.ProviderScaffold(addr, pc, provider, schema); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	output := done(t).Stdout()
+
+	for _, want := range []string{
+		`source = "hashicorp/azurerm"`,
+		"features {",
+		"# REQUIRED block",
+		"tenant_id = null # REQUIRED string",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "partner_id") {
+		t.Errorf("optional provider argument should not be scaffolded:\n%s", output)
+	}
+	if got := strings.Count(output, "required_providers"); got != 1 {
+		t.Errorf("provider scaffolded %d times; want 1:\n%s", got, output)
+	}
+}
+
+func TestAddResource_providerVersionFloor(t *testing.T) {
+	addr := mustResourceInstanceAddr("test_instance.example")
+	pc := addrs.NewDefaultLocalProviderConfig("test")
+	provider := addrs.NewDefaultProvider("test")
+
+	t.Run("installed version known", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+		v.SetProviderVersions(map[addrs.Provider]string{provider: "2.1.0"})
+
+		if err := v.ProviderScaffold(addr, pc, provider, nil); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		for _, want := range []string{
+			`source = "hashicorp/test"`,
+			`version = ">= 2.1.0"`,
+			"# Floor taken from the installed provider version",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("missing %q in output:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("installed version unknown", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true}
+
+		if err := v.ProviderScaffold(addr, pc, provider, nil); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if strings.Contains(output, "version") {
+			t.Errorf("no version floor should be pinned without a known installed version:\n%s", output)
+		}
+	})
+}
+
+func TestAddResource_generator(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("generator programs are exercised with shell scripts")
+	}
+
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"ami": {Type: cty.String, Required: true},
+		},
+	}
+	addr := mustResourceInstanceAddr("test_instance.example")
+
+	dir := t.TempDir()
+	wrap := filepath.Join(dir, "wrap.sh")
+	wrapScript := "#!/bin/sh\nprintf '# wrapped %s\\n' \"$TF_ADD_RESOURCE_ADDRESS\"\ncat\n"
+	if err := os.WriteFile(wrap, []byte(wrapScript), 0755); err != nil {
+		t.Fatal(err.Error())
+	}
+	fail := filepath.Join(dir, "fail.sh")
+	failScript := "#!/bin/sh\necho 'policy factory unavailable' >&2\nexit 1\n"
+	if err := os.WriteFile(fail, []byte(failScript), 0755); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	t.Run("post-processes matching resource types", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true,
+			generators: []arguments.AddGenerator{{Pattern: "test_*", Command: wrap}}}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("test"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if !strings.Contains(output, "# wrapped test_instance.example") {
+			t.Errorf("generator output missing:\n%s", output)
+		}
+		if !strings.Contains(output, `resource "test_instance" "example" {`) {
+			t.Errorf("generated block not passed through:\n%s", output)
+		}
+	})
+
+	t.Run("non-matching resource types are left alone", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true,
+			generators: []arguments.AddGenerator{{Pattern: "aws_iam_*", Command: wrap}}}
+
+		if err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("test"), cty.NilVal); err != nil {
+			t.Fatal(err.Error())
+		}
+		output := done(t).Stdout()
+
+		if strings.Contains(output, "# wrapped") {
+			t.Errorf("generator ran for a non-matching resource type:\n%s", output)
+		}
+	})
+
+	t.Run("failing generator", func(t *testing.T) {
+		streams, done := terminal.StreamsForTesting(t)
+		v := addHuman{view: NewView(streams), quiet: true, skipExperimentNote: true,
+			generators: []arguments.AddGenerator{{Pattern: "test_*", Command: fail}}}
+
+		err := v.Resource(addr, schema, addrs.NewDefaultLocalProviderConfig("test"), cty.NilVal)
+		done(t)
+		if err == nil {
+			t.Fatal("succeeded; want error from the generator program")
+		}
+		var genErr *GeneratorError
+		if !errors.As(err, &genErr) {
+			t.Fatalf("error is %T; want *GeneratorError", err)
+		}
+		if !strings.Contains(err.Error(), "policy factory unavailable") {
+			t.Errorf("error does not include the program's stderr: %s", err)
+		}
+	})
+}
+
+func TestLintGeneratedBlock(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"ami": {Type: cty.String, Required: true},
+			"rule": {
+				Type:     cty.List(cty.Object(map[string]cty.Type{"action": cty.String})),
+				Optional: true,
+			},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"network_interface": {
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"device_index": {Type: cty.Number, Optional: true},
+					},
+				},
+				Nesting: configschema.NestingList,
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		src       string
+		wantError string
+	}{
+		"valid": {
+			`resource "test_instance" "ok" {
+  provider = test.alias
+  ami      = null # REQUIRED string
+  network_interface {
+    device_index = 0
+  }
+  rule {
+    action = "allow"
+  }
+}`,
+			``,
+		},
+		"unknown attribute": {
+			`resource "test_instance" "bad" {
+  ami    = null
+  bucket = "oops"
+}`,
+			`generated attribute "bucket" is not declared in the schema`,
+		},
+		"unknown block type": {
+			`resource "test_instance" "bad" {
+  ami = null
+  lifecycle_rule {
+  }
+}`,
+			`generated block type "lifecycle_rule" is not declared in the schema`,
+		},
+		"unknown nested attribute": {
+			`resource "test_instance" "bad" {
+  ami = null
+  network_interface {
+    subnet = "oops"
+  }
+}`,
+			`generated attribute "subnet" is not declared in the schema`,
+		},
+		"does not parse": {
+			`resource "test_instance" "bad" {`,
+			`the generated configuration does not parse`,
+		},
+		"other block types are ignored": {
+			`# a comment
+locals {
+  anything = "goes"
+}`,
+			``,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := lintGeneratedBlock([]byte(test.src), schema)
+			if test.wantError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("succeeded; want error containing %q", test.wantError)
+			}
+			if !strings.Contains(err.Error(), test.wantError) {
+				t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, test.wantError)
+			}
+		})
+	}
+}
+
+func TestAdd_typeConstraintString(t *testing.T) {
+	tests := map[string]struct {
+		ty   cty.Type
+		want string
+	}{
+		"primitive": {
+			cty.String,
+			`string`,
+		},
+		"dynamic": {
+			cty.DynamicPseudoType,
+			`any`,
+		},
+		"list of string": {
+			cty.List(cty.String),
+			`list(string)`,
+		},
+		"list of object": {
+			cty.List(cty.Object(map[string]cty.Type{
+				"size":        cty.String,
+				"mount_point": cty.String,
+			})),
+			`list(object({ mount_point = string, size = string }))`,
+		},
+		"empty object": {
+			cty.EmptyObject,
+			`object({})`,
+		},
+		"tuple": {
+			cty.Tuple([]cty.Type{cty.String, cty.Number}),
+			`tuple([string, number])`,
+		},
+		// Constructors nested beyond the depth limit are elided rather than
+		// spelled out in full.
+		"elided beyond the depth limit": {
+			cty.Map(cty.Object(map[string]cty.Type{
+				"rules": cty.List(cty.Object(map[string]cty.Type{
+					"port": cty.Number,
+				})),
+			})),
+			`map(object({ rules = list(object({...})) }))`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := typeConstraintString(test.ty, typeExprRenderDepth)
+			if got != test.want {
+				t.Errorf("wrong result\ngot:  %s\nwant: %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAdd_diagnosticCodes(t *testing.T) {
+	diags := tfdiags.Diagnostics{}
+	diags = diags.Append(tfdiags.Sourceless(
+		tfdiags.Error,
+		"Incompatible command-line options",
+		"Cannot use both -out and -out-dir.",
+	))
+	diags = diags.Append(tfdiags.Sourceless(
+		tfdiags.Error,
+		"Error parsing resource address: boop",
+		"This command requires that each address argument specifies one resource instance.",
+	))
+	diags = diags.Append(tfdiags.Sourceless(
+		tfdiags.Warning,
+		"Some unrelated warning",
+		"A diagnostic passed through from elsewhere keeps its presentation.",
+	))
+
+	got := withAddDiagCodes(diags)
+	if len(got) != 3 {
+		t.Fatalf("wrong number of diagnostics: got %d, want 3", len(got))
+	}
+	if summary := got[0].Description().Summary; summary != "Incompatible command-line options [TF-ADD-002]" {
+		t.Errorf("wrong summary for coded diagnostic: %q", summary)
+	}
+	// Summaries carrying their subject are recognized by prefix.
+	if summary := got[1].Description().Summary; summary != "Error parsing resource address: boop [TF-ADD-020]" {
+		t.Errorf("wrong summary for prefix-coded diagnostic: %q", summary)
+	}
+	if summary := got[2].Description().Summary; summary != "Some unrelated warning" {
+		t.Errorf("unrecognized summary should pass through unchanged: %q", summary)
+	}
+	if sev := got[2].Severity(); sev != tfdiags.Warning {
+		t.Errorf("severity not preserved: %v", sev)
+	}
+}
+
+func mustResourceInstanceAddr(s string) addrs.AbsResourceInstance {
+	addr, diags := addrs.ParseAbsResourceInstanceStr(s)
+	if diags.HasErrors() {
+		panic(diags.Err())
+	}
+	return addr
+}
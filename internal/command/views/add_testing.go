@@ -0,0 +1,32 @@
+package views
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+)
+
+// RenderResourceForTesting renders a resource block for the given schema,
+// and optionally a state value, exactly as the "terraform add" view would
+// write it to standard output, including the experimental-command note. It
+// is exported so that tests can golden-test the rendering of new schema
+// shapes without reaching into the view's internals, following the same
+// convention as terminal.StreamsForTesting.
+func RenderResourceForTesting(t *testing.T, addrStr string, pc addrs.LocalProviderConfig, schema *configschema.Block, stateVal cty.Value, optional bool) string {
+	t.Helper()
+
+	addr, diags := addrs.ParseAbsResourceInstanceStr(addrStr)
+	if diags.HasErrors() {
+		t.Fatalf("invalid resource address %q: %s", addrStr, diags.Err())
+	}
+
+	v := &addHuman{optional: optional}
+	rendered, err := v.render(addr, schema, pc, stateVal)
+	if err != nil {
+		t.Fatalf("rendering %s: %s", addrStr, err)
+	}
+	return string(rendered)
+}
@@ -0,0 +1,91 @@
+package views
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// typeExprRenderDepth caps how many levels of nested type constructors the
+// attribute type comments spell out. A collection of objects — the common
+// provider shape — still renders in full, while anything deeper is elided
+// so a single comment doesn't swallow the line it annotates.
+const typeExprRenderDepth = 3
+
+// typeConstraintString renders a type the way it would be written in a
+// Terraform type expression, such as in a variable's "type" argument, so the
+// comment can be pasted into a declaration as-is. This differs from
+// cty's FriendlyName, which produces English-like phrasing ("list of object")
+// that isn't valid syntax anywhere in a configuration. Constructors nested
+// deeper than the given depth are elided with "...".
+func typeConstraintString(ty cty.Type, depth int) string {
+	switch ty {
+	case cty.String:
+		return "string"
+	case cty.Bool:
+		return "bool"
+	case cty.Number:
+		return "number"
+	case cty.DynamicPseudoType:
+		return "any"
+	}
+
+	if ty.IsCollectionType() {
+		var kw string
+		switch {
+		case ty.IsListType():
+			kw = "list"
+		case ty.IsSetType():
+			kw = "set"
+		case ty.IsMapType():
+			kw = "map"
+		default:
+			// Should never happen; FriendlyName copes with anything.
+			return ty.FriendlyName()
+		}
+		if depth <= 0 {
+			return kw + "(...)"
+		}
+		return kw + "(" + typeConstraintString(ty.ElementType(), depth-1) + ")"
+	}
+
+	if ty.IsObjectType() {
+		atys := ty.AttributeTypes()
+		if len(atys) == 0 {
+			return "object({})"
+		}
+		if depth <= 0 {
+			return "object({...})"
+		}
+		names := make([]string, 0, len(atys))
+		for name := range atys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		attrs := make([]string, len(names))
+		for i, name := range names {
+			attrs[i] = name + " = " + typeConstraintString(atys[name], depth-1)
+		}
+		return "object({ " + strings.Join(attrs, ", ") + " })"
+	}
+
+	if ty.IsTupleType() {
+		etys := ty.TupleElementTypes()
+		if len(etys) == 0 {
+			return "tuple([])"
+		}
+		if depth <= 0 {
+			return "tuple([...])"
+		}
+		elems := make([]string, len(etys))
+		for i, ety := range etys {
+			elems[i] = typeConstraintString(ety, depth-1)
+		}
+		return "tuple([" + strings.Join(elems, ", ") + "])"
+	}
+
+	// Capsule types and anything else unexpected fall back to the friendly
+	// name rather than panicking on a schema we didn't anticipate.
+	return ty.FriendlyName()
+}
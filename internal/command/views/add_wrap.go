@@ -0,0 +1,82 @@
+package views
+
+import (
+	"strings"
+)
+
+// wrapLongLines breaks single-line list and object values which make a line
+// longer than maxWidth across several lines, by inserting newlines at token
+// boundaries: after opening brackets and element separators, and before
+// closing brackets. The result is passed through hclwrite.Format again, so
+// the indentation of the broken lines is the formatter's own and repeated
+// formatting is stable. Lines which are long without any structure to break
+// (such as a single long quoted string) are left alone.
+func wrapLongLines(src []byte, maxWidth int) []byte {
+	lines := strings.Split(string(src), "\n")
+	for i, line := range lines {
+		if len(line) > maxWidth {
+			lines[i] = breakLine(line)
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+func breakLine(line string) string {
+	var b strings.Builder
+	inString := false
+	escaped := false
+
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+
+		if inString {
+			b.WriteByte(ch)
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch ch {
+		case '"':
+			inString = true
+			b.WriteByte(ch)
+		case '#':
+			// A trailing comment; leave the rest of the line alone.
+			b.WriteString(line[i:])
+			return b.String()
+		case '[', '{':
+			// Keep empty collections on one line.
+			j := i + 1
+			for j < len(line) && line[j] == ' ' {
+				j++
+			}
+			if j < len(line) && ((ch == '[' && line[j] == ']') || (ch == '{' && line[j] == '}')) {
+				b.WriteByte(ch)
+				b.WriteByte(line[j])
+				i = j
+				continue
+			}
+			b.WriteByte(ch)
+			b.WriteByte('\n')
+		case ']', '}':
+			b.WriteByte('\n')
+			b.WriteByte(ch)
+		case ',':
+			b.WriteByte(ch)
+			b.WriteByte('\n')
+			// Swallow the space that followed the separator.
+			if i+1 < len(line) && line[i+1] == ' ' {
+				i++
+			}
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}